@@ -0,0 +1,110 @@
+package promise
+
+import (
+	"errors"
+	"sync/atomic"
+
+	"github.com/gopherjs/gopherjs/js"
+)
+
+// ErrResolvedWithItself is the rejection reason of a promise that was resolved
+// with itself, which could never settle.  In JS it is a TypeError, as the
+// Promises/A+ spec requires.
+var ErrResolvedWithItself = errors.New("promise: resolved with itself")
+
+// adopt makes p settle like v if v is a promise or a JS thenable (see
+// IsPromise and IsThenable), and returns whether it is.  p then resolves with
+// v's value or is rejected with v's reason once v settles.  If p has already
+// settled, adopt returns false so that settling it again fails as usual.
+//
+// From then on p is locked in: it is still pending, but settling it other
+// than by cancellation panics as if it had settled.
+func (p *Promise) adopt(v interface{}) bool {
+	p.mu.Lock()
+	settled := (p.state != StatePending || p.locked) && !p.canceled && !p.never
+	p.mu.Unlock()
+	if settled {
+		return false
+	}
+	var src *Promise
+	switch v := v.(type) {
+	case *Promise:
+		src = v
+	case *CancelablePromise:
+		if v != nil {
+			src = v.Promise
+		}
+	case *js.Object:
+		then, err := thenOf(v)
+		if err != nil {
+			p.rejectReason(err)
+			return true
+		} else if then == nil {
+			return false
+		}
+		src = fromThen(v, then)
+	}
+	if src == nil {
+		return false
+	} else if src == p {
+		p.rejectReason(ErrResolvedWithItself)
+		return true
+	}
+	p.mu.Lock()
+	if was := p.state; (was != StatePending || p.locked) && !p.canceled && !p.never {
+		p.mu.Unlock()
+		panic(&notPendingError{p, was}) // settled meanwhile
+	}
+	p.locked = true
+	p.mu.Unlock()
+	atomic.AddInt64(&counters.Adoptions, 1)
+	graphEdge(src, p, EdgeAdopt)
+	src.listen(
+		func(val interface{}) interface{} {
+			if p.trySettle(StateFulfilled, val) {
+				p.flush()
+			}
+			return val
+		},
+		func(reason interface{}) interface{} {
+			if p.trySettle(StateRejected, reason) { // already transformed
+				p.dispatchRejection()
+			}
+			return reason
+		},
+		p)
+	return true
+}
+
+// fromThen returns a promise that settles like the JS thenable o, whose then
+// method is then, rejecting with a *js.Error for JS rejection reasons.  Only
+// the first call of either of the functions passed to then counts, and an
+// exception thrown by then rejects the promise unless one was called before.
+func fromThen(o, then *js.Object) (q *Promise) {
+	q = newPromise()
+	var called int32
+	first := func() bool { return atomic.CompareAndSwapInt32(&called, 0, 1) }
+	defer func() {
+		if x := recover(); x != nil {
+			jsErr, ok := x.(*js.Error)
+			if !ok {
+				panic(x)
+			}
+			if first() {
+				q.Reject(jsErr)
+			}
+		}
+	}()
+	then.Call("call", o,
+		func(val *js.Object) {
+			if first() {
+				q.Resolve(val)
+			}
+		},
+		func(reason *js.Object) {
+			if first() {
+				q.Reject(&js.Error{Object: reason})
+			}
+		})
+	return q
+}
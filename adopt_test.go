@@ -0,0 +1,125 @@
+package promise
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestThenAdoptsReturnedPromise(t *testing.T) {
+	defer time.AfterFunc(time.Second, t.FailNow).Stop() // limit test to 1 second running time.
+
+	before := Stats().Adoptions
+	op2 := newPromise()
+	logged := make(chan interface{}, 1)
+	Resolved(1).
+		Then(func(interface{}) interface{} { return op2 }, nil).
+		Then(func(val interface{}) interface{} { logged <- val; return nil }, nil)
+	select {
+	case val := <-logged:
+		t.Fatalf("Logged %v before op2 settled", val)
+	case <-time.After(10 * time.Millisecond):
+	}
+	op2.Resolve("op2 done")
+	assert.Equal(t, "op2 done", <-logged)
+	assert.Equal(t, int64(1), Stats().Adoptions-before)
+
+	cancelable := NewCancelable(nil)
+	cancelable.Resolve("cancelable")
+	val, ok := settled(Resolved(1).Then(func(interface{}) interface{} { return cancelable }, nil))
+	assert.True(t, ok)
+	assert.Equal(t, "cancelable", val)
+
+	// Rejections are adopted too, and a failure callback returning a promise
+	// recovers with its value.
+	failed := newPromise()
+	failed.Reject(errors.New("op2 failed"))
+	val, ok = settled(Resolved(1).Then(func(interface{}) interface{} { return failed }, nil))
+	assert.False(t, ok)
+	assert.EqualError(t, val.(error), "op2 failed")
+
+	val, ok = settled(failed.Then(nil, func(interface{}) interface{} { return Resolved("recovered") }))
+	assert.True(t, ok)
+	assert.Equal(t, "recovered", val)
+
+	// A nil promise is passed along as a value.
+	val, ok = settled(Resolved(1).Then(func(interface{}) interface{} { return (*Promise)(nil) }, nil))
+	assert.True(t, ok)
+	assert.Equal(t, (*Promise)(nil), val)
+}
+
+func TestAdoptionGraph(t *testing.T) {
+	defer time.AfterFunc(time.Second, t.FailNow).Stop() // limit test to 1 second running time.
+
+	EnableGraph(true)
+	defer EnableGraph(false)
+	inner := newPromise()
+	outer := Resolved(1).Then(func(interface{}) interface{} { return inner }, nil)
+	inner.Resolve(2)
+	val, _ := settled(outer)
+	assert.Equal(t, 2, val)
+	_, edges := Graph()
+	assert.Contains(t, edges, GraphEdge{From: 1, To: 3, Kind: EdgeAdopt})
+}
+
+func TestResolveAdopts(t *testing.T) {
+	defer time.AfterFunc(time.Second, t.FailNow).Stop() // limit test to 1 second running time.
+
+	other, p := newPromise(), newPromise()
+	p.Resolve(other)
+	assert.True(t, p.IsPending())
+	other.Resolve("other")
+	val, ok := settled(p)
+	assert.True(t, ok)
+	assert.Equal(t, "other", val)
+
+	// Adoption is recursive.
+	a, b, c := newPromise(), newPromise(), newPromise()
+	a.Resolve(b)
+	b.Resolve(c)
+	c.Reject("c failed")
+	val, ok = settled(a)
+	assert.False(t, ok)
+	assert.Equal(t, "c failed", val)
+
+	self := newPromise()
+	self.Resolve(self)
+	val, ok = settled(self)
+	assert.False(t, ok)
+	assert.Equal(t, ErrResolvedWithItself, val)
+
+	// Resolving a settled promise with a promise still fails right away, and
+	// a canceled promise ignores it.
+	assert.Panics(t, func() { Resolved(1).Resolve(newPromise()) })
+	canceled := newPromise()
+	canceled.Cancel("stop")
+	assert.NotPanics(t, func() { canceled.Resolve(Resolved(2)) })
+	val, _ = settled(canceled)
+	assert.Equal(t, &CancelError{"stop"}, val)
+}
+
+func TestResolveAdoptingTwice(t *testing.T) {
+	defer time.AfterFunc(time.Second, t.FailNow).Stop() // limit test to 1 second running time.
+
+	// A promise that adopts another is locked in, so settling it again fails
+	// right away, rather than when the adopted promise settles.
+	src, p := newPromise(), newPromise()
+	p.Resolve(src)
+	assert.PanicsWithError(t, "Cannot change p promise that was resolved with another promise", func() { p.Resolve(1) })
+	assert.Panics(t, func() { p.Reject("failed") })
+	assert.Panics(t, func() { p.Resolve(newPromise()) })
+	src.Resolve(2)
+	val, ok := settled(p)
+	assert.True(t, ok)
+	assert.Equal(t, 2, val)
+
+	// It can still be canceled, and then ignores the adopted promise.
+	src, p = newPromise(), newPromise()
+	p.Resolve(src)
+	p.Cancel("stop")
+	src.Reject("failed")
+	val, _ = settled(p)
+	assert.Equal(t, &CancelError{"stop"}, val)
+}
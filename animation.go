@@ -0,0 +1,81 @@
+package promise
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gopherjs/gopherjs/js"
+)
+
+// animationGrace is added to the computed duration of a transition or
+// animation before the fallback timer settles its promise.
+const animationGrace = 50 * time.Millisecond
+
+// TransitionEnd returns a promise that is resolved with el when its CSS
+// transition ends or is canceled.  Since browsers don't fire any event for
+// transitions that never start (e.g. because the property didn't change),
+// the promise is also resolved once the computed transition duration and
+// delay have passed.
+func TransitionEnd(el *js.Object) *Promise {
+	return animationEvent(el, "transition", "transitionend", "transitioncancel")
+}
+
+// AnimationEnd returns a promise that is resolved with el when its CSS
+// animation ends or is canceled, or once the computed animation duration and
+// delay have passed, like TransitionEnd.
+func AnimationEnd(el *js.Object) *Promise {
+	return animationEvent(el, "animation", "animationend", "animationcancel")
+}
+
+// animationEvent resolves with el on the first of events or after the
+// duration of the CSS property group prefix.
+func animationEvent(el *js.Object, prefix string, events ...string) *Promise {
+	p := newPromise()
+	var once sync.Once
+	var timer *time.Timer
+	var listener func(event *js.Object)
+	done := func() {
+		once.Do(func() {
+			timer.Stop()
+			for _, event := range events {
+				el.Call("removeEventListener", event, listener)
+			}
+			p.Resolve(el)
+		})
+	}
+	listener = func(event *js.Object) {
+		if event.Get("target") == el {
+			done()
+		}
+	}
+	style := js.Global.Call("getComputedStyle", el)
+	timeout := maxCSSDuration(style.Get(prefix+"Duration").String()) +
+		maxCSSDuration(style.Get(prefix+"Delay").String()) + animationGrace
+	timer = time.AfterFunc(timeout, done)
+	for _, event := range events {
+		el.Call("addEventListener", event, listener)
+	}
+	return p
+}
+
+// maxCSSDuration returns the longest of a comma-separated list of CSS times,
+// such as "0.3s, 150ms".  Invalid entries are ignored.
+func maxCSSDuration(list string) time.Duration {
+	var max time.Duration
+	for _, s := range strings.Split(list, ",") {
+		s = strings.TrimSpace(s)
+		unit := time.Second
+		if strings.HasSuffix(s, "ms") {
+			s, unit = strings.TrimSuffix(s, "ms"), time.Millisecond
+		} else {
+			s = strings.TrimSuffix(s, "s")
+		}
+		f, err := strconv.ParseFloat(s, 64)
+		if d := time.Duration(f * float64(unit)); err == nil && d > max {
+			max = d
+		}
+	}
+	return max
+}
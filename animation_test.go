@@ -0,0 +1,15 @@
+package promise
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMaxCSSDuration(t *testing.T) {
+	assert.Equal(t, 300*time.Millisecond, maxCSSDuration("0.3s, 150ms"))
+	assert.Equal(t, 2*time.Second, maxCSSDuration("2s"))
+	assert.Equal(t, 250*time.Millisecond, maxCSSDuration("250ms, bogus, -1s"))
+	assert.Equal(t, time.Duration(0), maxCSSDuration(""))
+}
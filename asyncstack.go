@@ -0,0 +1,40 @@
+package promise
+
+import (
+	"runtime"
+
+	"github.com/gopherjs/gopherjs/js"
+)
+
+// newAsyncTask creates a DevTools async stack tagging task named name with
+// console.createTask, if the browser supports it, or returns nil otherwise.
+// Running the JS callbacks of a promise in the task of the promisified call
+// that created it lets DevTools show the originating call site in async stack
+// traces, instead of dead-ending at the Go scheduler.
+func newAsyncTask(name string) *js.Object {
+	console := js.Global.Get("console")
+	if console == js.Undefined || console == nil || console.Get("createTask") == js.Undefined {
+		return nil
+	}
+	return console.Call("createTask", name)
+}
+
+// inTask wraps cb to run inside task, if there is one.
+func inTask(task *js.Object, cb Callback) Callback {
+	if task == nil || cb == nil {
+		return cb
+	}
+	return func(val interface{}) interface{} {
+		var result interface{}
+		task.Call("run", func() { result = cb(val) })
+		return result
+	}
+}
+
+// funcName returns the Go name of fn for use in diagnostics.
+func funcName(pc uintptr) string {
+	if f := runtime.FuncForPC(pc); f != nil {
+		return f.Name()
+	}
+	return "promise"
+}
@@ -0,0 +1,15 @@
+package promise
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFuncName(t *testing.T) {
+	name := funcName(reflect.ValueOf(TestFuncName).Pointer())
+	assert.True(t, strings.HasSuffix(name, ".TestFuncName"), name)
+	assert.Nil(t, inTask(nil, nil))
+}
@@ -0,0 +1,86 @@
+package promise
+
+import (
+	"math"
+	"math/rand"
+	"time"
+)
+
+// Backoff determines how long to wait between successive attempts of an
+// operation.  It is shared by everything in this package that retries:
+// PollBackoff, the reconnects of DialWS and ConnectSSE, the tasks of a
+// DurableQueue and the restarts of Supervise.
+type Backoff interface {
+	// Delay returns how long to wait before the given attempt, where attempt
+	// 1 is the first attempt after the initial one.  previous is the delay
+	// that was returned for the prior attempt, or 0 for attempt 1.
+	Delay(attempt int, previous time.Duration) time.Duration
+}
+
+// ConstantBackoff waits the same Interval before every attempt.
+type ConstantBackoff struct {
+	Interval time.Duration
+}
+
+// Delay implements Backoff.
+func (b ConstantBackoff) Delay(attempt int, previous time.Duration) time.Duration {
+	return b.Interval
+}
+
+// ExponentialBackoff waits Initial before the first attempt and multiplies the
+// delay by Multiplier (2 if unset) for each subsequent attempt, up to Max (if
+// positive).
+//
+// If Jitter is set, the actual delay is chosen uniformly at random between 0
+// and the exponential delay ("full jitter"), which avoids many clients
+// retrying in lockstep.
+type ExponentialBackoff struct {
+	Initial    time.Duration
+	Max        time.Duration
+	Multiplier float64
+	Jitter     bool
+}
+
+// Delay implements Backoff.
+func (b ExponentialBackoff) Delay(attempt int, previous time.Duration) time.Duration {
+	multiplier := b.Multiplier
+	if multiplier <= 0 {
+		multiplier = 2
+	}
+	if attempt < 1 {
+		attempt = 1
+	}
+	d := float64(b.Initial) * math.Pow(multiplier, float64(attempt-1))
+	if b.Max > 0 && d > float64(b.Max) {
+		d = float64(b.Max)
+	}
+	if b.Jitter {
+		d = rand.Float64() * d
+	}
+	if d >= math.MaxInt64 {
+		return math.MaxInt64
+	}
+	return time.Duration(d)
+}
+
+// DecorrelatedBackoff implements "decorrelated jitter": each delay is chosen
+// uniformly at random between Base and three times the previous delay, capped
+// at Max (if positive).  It spreads out attempts like jittered exponential
+// backoff while growing more smoothly.
+type DecorrelatedBackoff struct {
+	Base time.Duration
+	Max  time.Duration
+}
+
+// Delay implements Backoff.
+func (b DecorrelatedBackoff) Delay(attempt int, previous time.Duration) time.Duration {
+	if previous < b.Base {
+		previous = b.Base
+	}
+	hi := 3 * float64(previous)
+	d := float64(b.Base) + rand.Float64()*(hi-float64(b.Base))
+	if b.Max > 0 && d > float64(b.Max) {
+		d = float64(b.Max)
+	}
+	return time.Duration(d)
+}
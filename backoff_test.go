@@ -0,0 +1,63 @@
+package promise
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConstantBackoff(t *testing.T) {
+	b := ConstantBackoff{time.Second}
+	assert.Equal(t, time.Second, b.Delay(1, 0))
+	assert.Equal(t, time.Second, b.Delay(10, time.Second))
+}
+
+func TestExponentialBackoff(t *testing.T) {
+	b := ExponentialBackoff{Initial: time.Millisecond, Max: 10 * time.Millisecond}
+	var delays []time.Duration
+	var prev time.Duration
+	for i := 1; i <= 6; i++ {
+		prev = b.Delay(i, prev)
+		delays = append(delays, prev)
+	}
+	assert.Equal(t, []time.Duration{1, 2, 4, 8, 10, 10}, scale(delays, time.Millisecond))
+
+	b = ExponentialBackoff{Initial: time.Millisecond, Multiplier: 3}
+	assert.Equal(t, 9*time.Millisecond, b.Delay(3, 0))
+
+	// Huge attempts don't overflow.
+	assert.True(t, ExponentialBackoff{Initial: time.Second}.Delay(1000, 0) > 0)
+
+	b = ExponentialBackoff{Initial: time.Second, Jitter: true}
+	for i := 0; i < 100; i++ {
+		d := b.Delay(3, 0)
+		assert.True(t, d >= 0 && d <= 4*time.Second, "Out of range: %v", d)
+	}
+}
+
+func TestDecorrelatedBackoff(t *testing.T) {
+	b := DecorrelatedBackoff{Base: time.Second, Max: 5 * time.Second}
+	prev := time.Duration(0)
+	for i := 1; i < 100; i++ {
+		d := b.Delay(i, prev)
+		lo := time.Second
+		hi := 3 * prev
+		if hi < 3*time.Second {
+			hi = 3 * time.Second
+		}
+		if hi > 5*time.Second {
+			hi = 5 * time.Second
+		}
+		assert.True(t, d >= lo && d <= hi, "Out of range [%v, %v]: %v", lo, hi, d)
+		prev = d
+	}
+}
+
+func scale(ds []time.Duration, unit time.Duration) []time.Duration {
+	out := make([]time.Duration, len(ds))
+	for i, d := range ds {
+		out[i] = d / unit
+	}
+	return out
+}
@@ -0,0 +1,48 @@
+package promise
+
+import "sync"
+
+// Barrier holds back a group of parties until all of them have arrived, for
+// example to wait for several independent initialization steps to finish
+// before proceeding.
+type Barrier struct {
+	mu      sync.Mutex
+	parties int
+	arrived int
+	open    Promise
+}
+
+// NewBarrier returns a barrier that opens once n parties have arrived.  A
+// barrier for n less than 1 is already open.
+func NewBarrier(n int) *Barrier {
+	b := &Barrier{parties: n}
+	if n < 1 {
+		b.open.Resolve(nil)
+	}
+	return b
+}
+
+// Arrive records the arrival of a party and returns a promise that is resolved
+// (with nil) once all of the parties have arrived.  Arrivals after the barrier
+// has opened are resolved immediately.
+func (b *Barrier) Arrive() *Promise {
+	b.mu.Lock()
+	b.arrived++
+	opened := b.arrived == b.parties
+	b.mu.Unlock()
+	wait := b.open.Then(nil, nil)
+	if opened {
+		b.open.Resolve(nil)
+	}
+	return wait
+}
+
+// Waiting returns the number of parties that have not yet arrived.
+func (b *Barrier) Waiting() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.arrived >= b.parties {
+		return 0
+	}
+	return b.parties - b.arrived
+}
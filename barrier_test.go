@@ -0,0 +1,38 @@
+package promise
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBarrier(t *testing.T) {
+	defer time.AfterFunc(time.Second, t.FailNow).Stop() // limit test to 1 second running time.
+
+	b := NewBarrier(3)
+	opened := make(chan int, 3)
+	for i := 0; i < 2; i++ {
+		i := i
+		b.Arrive().Then(func(val interface{}) interface{} { opened <- i; return val }, nil)
+	}
+	assert.Equal(t, 1, b.Waiting())
+
+	// Nothing happens until the last party arrives.
+	select {
+	case <-opened:
+		t.Fatal("Barrier opened early!")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	_, ok := settled(b.Arrive())
+	assert.True(t, ok)
+	assert.ElementsMatch(t, []int{0, 1}, []int{<-opened, <-opened})
+	assert.Equal(t, 0, b.Waiting())
+
+	// Late arrivals pass straight through.
+	_, ok = settled(b.Arrive())
+	assert.True(t, ok)
+	_, ok = settled(NewBarrier(0).Arrive())
+	assert.True(t, ok)
+}
@@ -0,0 +1,80 @@
+package promise
+
+import "github.com/gopherjs/gopherjs/js"
+
+// BatchCall is a single call in a batch sent by a Batcher.
+type BatchCall struct {
+	Method string
+	Args   []interface{}
+}
+
+// BatchExecutor performs calls in a single round trip, e.g. as one JSON-RPC
+// batch request.  It must return one result per call, in the same order.  A
+// result that is an error rejects the promise for its call only, while a
+// non-nil error rejects the promises for all of the calls.
+type BatchExecutor func(calls []BatchCall) ([]interface{}, error)
+
+// BatchOptions configures a Batcher.
+type BatchOptions struct {
+	// MaxBatchSize splits larger batches into several round trips.  Zero
+	// means no limit.
+	MaxBatchSize int
+	// Scheduler runs the batches.  The calls made until a batch runs are
+	// included in it.  Defaults to the configured scheduler.
+	Scheduler Scheduler
+}
+
+// Batcher coalesces the calls made through it into batches for a
+// BatchExecutor, while each caller still gets a promise for its own result.
+// Pages that make dozens of small calls while loading can send them in one
+// round trip without restructuring their code:
+//
+//	rpc := promise.NewBatcher(sendJSONRPCBatch, promise.BatchOptions{})
+//	getUser := rpc.Func("users.get")
+//	getUser(42).Then(...)
+//	getUser(43).Then(...) // sent along with the first call
+//
+// Unlike a Loader, a Batcher doesn't cache results.  It is safe for
+// concurrent use.
+type Batcher struct {
+	loader *Loader
+}
+
+// NewBatcher returns a Batcher that performs calls with exec.
+func NewBatcher(exec BatchExecutor, opts BatchOptions) *Batcher {
+	batch := func(keys []interface{}) ([]interface{}, error) {
+		calls := make([]BatchCall, len(keys))
+		for i, key := range keys {
+			calls[i] = *key.(*BatchCall)
+		}
+		return exec(calls)
+	}
+	return &Batcher{NewLoader(batch, LoaderOptions{
+		MaxBatchSize: opts.MaxBatchSize,
+		NoCache:      true,
+		Scheduler:    opts.Scheduler,
+	})}
+}
+
+// Call adds a call of method with args to the next batch, and returns a
+// promise for its result.
+func (b *Batcher) Call(method string, args ...interface{}) *Promise {
+	return b.loader.Load(&BatchCall{method, args})
+}
+
+// Func returns a function that calls method through b.
+func (b *Batcher) Func(method string) func(args ...interface{}) *Promise {
+	return func(args ...interface{}) *Promise { return b.Call(method, args...) }
+}
+
+// Js returns a JS function that calls method through b with its arguments,
+// as converted by gopherjs, and returns a promise for the result.
+func (b *Batcher) Js(method string) *js.Object {
+	return js.MakeFunc(func(this *js.Object, arguments []*js.Object) interface{} {
+		args := make([]interface{}, len(arguments))
+		for i, arg := range arguments {
+			args[i] = arg.Interface()
+		}
+		return b.Call(method, args...).Js()
+	})
+}
@@ -0,0 +1,48 @@
+package promise
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBatcher(t *testing.T) {
+	defer time.AfterFunc(time.Second, t.FailNow).Stop() // limit test to 1 second running time.
+
+	var queue []func()
+	sched := SchedulerFunc(func(task func()) { queue = append(queue, task) })
+	var batches [][]BatchCall
+	forbidden := errors.New("forbidden")
+	b := NewBatcher(func(calls []BatchCall) ([]interface{}, error) {
+		batches = append(batches, calls)
+		results := make([]interface{}, len(calls))
+		for i, c := range calls {
+			if c.Method == "admin" {
+				results[i] = forbidden
+			} else {
+				results[i] = c.Method + ":" + c.Args[0].(string)
+			}
+		}
+		return results, nil
+	}, BatchOptions{Scheduler: sched})
+
+	get := b.Func("get")
+	p1, p2, p3 := get("a"), get("a"), b.Call("admin", "x")
+	assert.True(t, p1 != p2) // not cached
+	assert.Equal(t, 1, len(queue))
+	queue[0]()
+	assert.Equal(t, [][]BatchCall{{
+		{"get", []interface{}{"a"}},
+		{"get", []interface{}{"a"}},
+		{"admin", []interface{}{"x"}},
+	}}, batches)
+
+	val, ok := settled(p2)
+	assert.True(t, ok)
+	assert.Equal(t, "get:a", val)
+	val, ok = settled(p3)
+	assert.False(t, ok)
+	assert.Equal(t, forbidden, val)
+}
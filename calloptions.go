@@ -0,0 +1,117 @@
+package promise
+
+import (
+	"time"
+
+	"github.com/gopherjs/gopherjs/js"
+)
+
+// CallOptions control a single call of a promisified function.  JS callers
+// pass them as a trailing object after the regular arguments:
+//
+//	await search("query", {timeout: 5000, signal: controller.signal, label: "search"})
+//
+// The object is recognized, and removed from the arguments before they are
+// converted, if it is a plain object with nothing but the properties below
+// and it comes after all of the function's non-variadic parameters.
+type CallOptions struct {
+	// Timeout rejects the promise of the call with ErrTimeout if it doesn't
+	// settle in time, overriding Config.DefaultTimeout.  In JS it is given
+	// in milliseconds as "timeout".
+	Timeout time.Duration
+	// Signal is an AbortSignal ("signal") that cancels the call when aborted.
+	Signal *js.Object
+	// Priority ("priority") is the priority of the call and its promise, see
+	// SetPriority.  In JS it is a number or one of the scheduler.postTask
+	// priorities: "user-blocking", "user-visible" or "background".
+	Priority int
+	// Label ("label") replaces the default label of the promise, see
+	// SetLabel.
+	Label string
+	// Deadline rejects the promise of the call with ErrDeadlineExceeded if it
+	// doesn't settle before the deadline passes.  Calls sharing a Deadline
+	// share its time budget.  In JS it is given as "deadline", a Date or a
+	// number of milliseconds since the epoch like Date.now() returns, so that
+	// the steps of an operation can pass the same one:
+	//
+	//	const deadline = Date.now() + 5000
+	//	const user = await getUser(id, {deadline})
+	//	await loadProfile(user, {deadline})
+	Deadline *Deadline
+}
+
+var callOptionKeys = map[string]bool{
+	"timeout": true, "signal": true, "priority": true, "label": true, "deadline": true,
+}
+
+// parseCallOptions interprets props, the properties of a trailing JS object,
+// as CallOptions, apart from the signal.  It returns false if props has any
+// other properties, or none at all.
+func parseCallOptions(props map[string]interface{}) (CallOptions, bool) {
+	var opts CallOptions
+	if len(props) == 0 {
+		return opts, false
+	}
+	for key := range props {
+		if !callOptionKeys[key] {
+			return opts, false
+		}
+	}
+	if ms, ok := props["timeout"].(float64); ok {
+		opts.Timeout = time.Duration(ms * float64(time.Millisecond))
+	}
+	switch priority := props["priority"].(type) {
+	case float64:
+		opts.Priority = int(priority)
+	case string:
+		for _, p := range []int{BackgroundPriority, UserVisiblePriority, UserBlockingPriority} {
+			if priorityName(p) == priority {
+				opts.Priority = p
+			}
+		}
+	}
+	if label, ok := props["label"].(string); ok {
+		opts.Label = label
+	}
+	switch deadline := props["deadline"].(type) {
+	case float64:
+		opts.Deadline = DeadlineAt(time.Unix(0, int64(deadline*float64(time.Millisecond))))
+	case time.Time:
+		opts.Deadline = DeadlineAt(deadline)
+	}
+	return opts, true
+}
+
+// splitCallOptions removes the trailing options object from the arguments of
+// a call to a promisified function with min non-variadic parameters, if there
+// is one.
+func splitCallOptions(args []*js.Object, min int) ([]*js.Object, CallOptions) {
+	if len(args) <= min {
+		return args, CallOptions{}
+	}
+	last := args[len(args)-1]
+	if last == nil || last.Get("constructor") != js.Global.Get("Object") {
+		return args, CallOptions{}
+	}
+	props, _ := last.Interface().(map[string]interface{})
+	opts, ok := parseCallOptions(props)
+	if !ok {
+		return args, CallOptions{}
+	}
+	if signal := last.Get("signal"); signal != js.Undefined && signal != nil {
+		opts.Signal = signal
+	}
+	return args[:len(args)-1], opts
+}
+
+// abortOn cancels p with the reason of signal, an AbortSignal, when it is
+// aborted.
+func (p *Promise) abortOn(signal *js.Object) {
+	if signal.Get("aborted").Bool() {
+		p.cancel(signal.Get("reason"))
+		return
+	}
+	once := js.Global.Get("Object").New()
+	once.Set("once", true)
+	signal.Call("addEventListener", "abort", func() { p.cancel(signal.Get("reason")) }, once)
+}
@@ -0,0 +1,74 @@
+package promise
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseCallOptions(t *testing.T) {
+	opts, ok := parseCallOptions(map[string]interface{}{"timeout": 1500.0, "priority": 2.0, "label": "search"})
+	assert.True(t, ok)
+	assert.Equal(t, CallOptions{Timeout: 1500 * time.Millisecond, Priority: 2, Label: "search"}, opts)
+
+	opts, ok = parseCallOptions(map[string]interface{}{"signal": map[string]interface{}{}})
+	assert.True(t, ok)
+	assert.Equal(t, CallOptions{}, opts)
+
+	opts, _ = parseCallOptions(map[string]interface{}{"priority": "background"})
+	assert.Equal(t, BackgroundPriority, opts.Priority)
+	opts, _ = parseCallOptions(map[string]interface{}{"priority": "user-blocking"})
+	assert.Equal(t, UserBlockingPriority, opts.Priority)
+
+	at := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	opts, _ = parseCallOptions(map[string]interface{}{"deadline": float64(at.UnixNano() / int64(time.Millisecond))})
+	assert.True(t, opts.Deadline.Time().Equal(at))
+	opts, _ = parseCallOptions(map[string]interface{}{"deadline": at})
+	assert.Equal(t, at, opts.Deadline.Time())
+
+	_, ok = parseCallOptions(map[string]interface{}{"timeout": 10.0, "query": "x"})
+	assert.False(t, ok)
+	_, ok = parseCallOptions(map[string]interface{}{})
+	assert.False(t, ok)
+	_, ok = parseCallOptions(nil)
+	assert.False(t, ok)
+}
+
+type priorityQueue struct {
+	tasks      []func()
+	priorities []int
+}
+
+func (q *priorityQueue) Schedule(task func()) { q.SchedulePriority(task, 0) }
+
+func (q *priorityQueue) SchedulePriority(task func(), priority int) {
+	q.tasks = append(q.tasks, task)
+	q.priorities = append(q.priorities, priority)
+}
+
+func TestSchedulePriority(t *testing.T) {
+	q := &priorityQueue{}
+	schedulePriority(q, func() {}, 3)
+	assert.Equal(t, []int{3}, q.priorities)
+
+	ran := false
+	schedulePriority(SchedulerFunc(func(task func()) { task() }), func() { ran = true }, 3)
+	assert.True(t, ran)
+}
+
+func TestSetPriority(t *testing.T) {
+	q := &priorityQueue{}
+	p := NewWith(Options{Scheduler: q, Priority: UserBlockingPriority}, func(resolve, reject func(interface{})) {})
+	p.Then(nil, nil)
+	p.Resolve(nil)
+	assert.Equal(t, []int{UserBlockingPriority}, q.priorities)
+
+	child := p.SetPriority(BackgroundPriority).Then(nil, nil)
+	assert.Equal(t, BackgroundPriority, child.Priority())
+	assert.Equal(t, []int{UserBlockingPriority, BackgroundPriority}, q.priorities)
+
+	assert.Equal(t, "background", priorityName(-5))
+	assert.Equal(t, "user-visible", priorityName(UserVisiblePriority))
+	assert.Equal(t, "user-blocking", priorityName(UserBlockingPriority))
+}
@@ -0,0 +1,296 @@
+package promise
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/gopherjs/gopherjs/js"
+)
+
+// ErrCanceled matches, with errors.Is, the rejection reason of every promise
+// that was canceled: through a CancelToken, with Cancel, by the end of a
+// Scope, or by the AbortSignal of a promisified call.  In JS such rejections
+// are AbortError DOMExceptions, like those of fetch.
+var ErrCanceled = errors.New("promise: canceled")
+
+// CancelError is the rejection reason of a canceled promise.  Reason is the
+// reason given for the cancellation, e.g. to CancelToken.Cancel.
+type CancelError struct {
+	Reason interface{}
+}
+
+func (e *CancelError) Error() string {
+	if e.Reason == nil {
+		return ErrCanceled.Error()
+	}
+	return fmt.Sprintf("%v: %v", ErrCanceled, e.Reason)
+}
+
+// Is makes errors.Is(err, ErrCanceled) true for every *CancelError.
+func (e *CancelError) Is(target error) bool { return target == ErrCanceled }
+
+// jsAbortError converts the rejection reason of a canceled promise for JS: an
+// AbortError DOMException (or an Error named AbortError where DOMException
+// isn't available), unless the cancellation came from JS with such an error
+// already, e.g. as the reason of an AbortSignal.
+func jsAbortError(err error) *js.Object {
+	var c *CancelError
+	if errors.As(err, &c) {
+		if o, ok := c.Reason.(*js.Object); ok && o != nil && o.Get("name").String() == "AbortError" {
+			return o
+		}
+	}
+	if ctor := js.Global.Get("DOMException"); ctor != js.Undefined {
+		return ctor.New(err.Error(), "AbortError")
+	}
+	e := js.Global.Get("Error").New(err.Error())
+	e.Set("name", "AbortError")
+	return e
+}
+
+// canceled returns the rejection reason for a promise canceled with reason.
+func canceled(reason interface{}) error {
+	if err, ok := reason.(error); ok && errors.Is(err, ErrCanceled) {
+		return err
+	}
+	return &CancelError{reason}
+}
+
+// CancelToken cancels a whole tree of promises at once.  A token is bound to a
+// promise with WithToken, and every promise derived from it with Then inherits
+// the token.  Canceling the token rejects all of the promises in the tree that
+// are still pending with a *CancelError and notifies the producers of the
+// underlying work so that it can be stopped.
+//
+// A promise that was rejected by cancellation ignores any later attempt to
+// settle it, so producers that finish after cancellation don't need to check
+// for it.
+type CancelToken struct {
+	mu       sync.Mutex
+	canceled bool
+	reason   interface{}
+	promises []*Promise
+	onCancel []func(reason interface{})
+}
+
+// NewCancelToken returns a token that hasn't been canceled.
+func NewCancelToken() *CancelToken { return &CancelToken{} }
+
+// Cancel rejects all of the pending promises bound to the token with a
+// *CancelError for reason and then calls the functions registered with
+// OnCancel.  Only the first call to Cancel has any effect.
+func (t *CancelToken) Cancel(reason interface{}) {
+	t.mu.Lock()
+	if t.canceled {
+		t.mu.Unlock()
+		return
+	}
+	t.canceled, t.reason = true, reason
+	promises, onCancel := t.promises, t.onCancel
+	t.promises, t.onCancel = nil, nil
+	t.mu.Unlock()
+
+	for _, p := range promises {
+		p.cancel(reason)
+	}
+	for _, fn := range onCancel {
+		fn(reason)
+	}
+}
+
+// Canceled returns whether Cancel has been called.
+func (t *CancelToken) Canceled() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.canceled
+}
+
+// Reason returns the reason that the token was canceled with, or nil if it
+// hasn't been canceled.
+func (t *CancelToken) Reason() interface{} {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.reason
+}
+
+// OnCancel registers fn to be called with the reason when the token is
+// canceled.  This is how producers learn that their work is no longer needed.
+// If the token is already canceled, fn is called immediately.
+func (t *CancelToken) OnCancel(fn func(reason interface{})) {
+	t.mu.Lock()
+	if !t.canceled {
+		t.onCancel = append(t.onCancel, fn)
+		t.mu.Unlock()
+		return
+	}
+	t.mu.Unlock()
+	fn(t.reason)
+}
+
+// add binds p to the token, returning false if the token is already canceled.
+func (t *CancelToken) add(p *Promise) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.canceled {
+		return false
+	}
+	if len(t.promises) == cap(t.promises) {
+		// Drop the promises that have settled before growing.
+		live := t.promises[:0]
+		for _, p := range t.promises {
+			if p.IsPending() {
+				live = append(live, p)
+			}
+		}
+		t.promises = live
+	}
+	t.promises = append(t.promises, p)
+	return true
+}
+
+// WithToken binds p to t, so that canceling t rejects p if it is still pending,
+// and returns p.  Promises derived from p with Then are bound to t as well.
+// If t is already canceled, p is canceled immediately.
+//
+//	token := promise.NewCancelToken()
+//	p := new(promise.Promise).WithToken(token)
+//	go fetch(p, token)
+//	...
+//	token.Cancel("user navigated away")
+func (p *Promise) WithToken(t *CancelToken) *Promise {
+	p.mu.Lock()
+	p.token = t
+	p.mu.Unlock()
+	if !t.add(p) {
+		p.cancel(t.Reason())
+	}
+	return p
+}
+
+// Token returns the CancelToken bound to p, if any.
+func (p *Promise) Token() *CancelToken {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.token
+}
+
+// Cancel is called by a consumer that is no longer interested in p.  If p is
+// still pending, it is rejected with a *CancelError for reason just as if its
+// CancelToken was canceled.
+//
+// Cancellation also propagates upstream: once every promise derived from a
+// parent with Then has been canceled, the parent is canceled too, and so on up
+// the chain.  When this reaches a CancelablePromise, its producer is told to
+// stop the underlying work.
+func (p *Promise) Cancel(reason interface{}) {
+	if !p.cancel(reason) || p.parent == nil {
+		return
+	}
+	parent := p.parent
+	parent.mu.Lock()
+	parent.canceledChildren++
+	all := parent.canceledChildren == parent.children
+	parent.mu.Unlock()
+	if all {
+		parent.Cancel(reason)
+	}
+}
+
+// cancel rejects p with a *CancelError for reason if it is still pending, see
+// halt.  It returns whether p was canceled.
+func (p *Promise) cancel(reason interface{}) bool {
+	if !p.halt(canceled(reason), reason) {
+		return false
+	}
+	populationCanceled(p)
+	return true
+}
+
+// halt rejects p with rejection if it is still pending, marking it so that the
+// producer's eventual attempt to settle it is ignored, and stops the producer
+// with reason.  It returns whether p was halted.
+func (p *Promise) halt(rejection, reason interface{}) bool {
+	p.mu.Lock()
+	if p.state != StatePending || p.never {
+		p.mu.Unlock()
+		return false
+	}
+	p.stopReason = reason
+	p.mu.Unlock()
+	if !p.commitRejection(pluginsTransformRejection(p, coerceReason(p, rejection)), true) {
+		return false // settled by the producer in the meantime
+	}
+	p.mu.Lock()
+	stop := p.stop
+	p.stop = nil
+	p.mu.Unlock()
+	for _, fn := range stop {
+		fn(reason)
+	}
+	return true
+}
+
+// CancelablePromise is a Promise whose producer can stop the underlying work
+// (close a connection, abort a request, ...) when the promise is canceled,
+// either directly, through its CancelToken, or because all of its consumers
+// canceled.  The producer settles it with Resolve and Reject as usual.
+type CancelablePromise struct {
+	*Promise
+}
+
+// NewCancelable returns a pending promise that calls stop with the reason if
+// it is canceled before it settles.
+//
+//	func fetch(url string) *promise.Promise {
+//		xhr := newXHR(url)
+//		p := promise.NewCancelable(func(interface{}) { xhr.Call("abort") })
+//		xhr.Set("onload", func() { p.Resolve(xhr.Get("response")) })
+//		xhr.Call("send")
+//		return p.Promise
+//	}
+func NewCancelable(stop func(reason interface{})) *CancelablePromise {
+	p := newPromise()
+	if stop != nil {
+		p.stop = append(p.stop, stop)
+	}
+	return &CancelablePromise{p}
+}
+
+// OnCancel registers fn to tear down the underlying work (close sockets,
+// delete temporary state, ...) when p is canceled, in addition to the stop
+// function passed to NewCancelable.  fn is called at most once, with the
+// reason, and right away if p has already been canceled.  It is not called if
+// p settles normally.
+func (p *CancelablePromise) OnCancel(fn func(reason interface{})) { p.onStop(fn) }
+
+// onStop registers fn to be called with the reason when p is halted, or calls
+// it right away if p was halted already.  fn is dropped if p settled
+// normally.
+func (p *Promise) onStop(fn func(reason interface{})) {
+	p.mu.Lock()
+	if p.canceled {
+		reason := p.stopReason
+		p.mu.Unlock()
+		fn(reason)
+		return
+	}
+	if p.state == StatePending {
+		p.stop = append(p.stop, fn)
+	}
+	p.mu.Unlock()
+}
+
+// onSettle calls fn once p settles, on the goroutine that settles it, or right
+// away if it has already.  Unlike a callback registered with Then, fn doesn't
+// count as a consumer of p, see Cancel.
+func (p *Promise) onSettle(fn func()) {
+	p.mu.Lock()
+	if p.state == StatePending {
+		p.whenSettled = append(p.whenSettled, fn)
+		p.mu.Unlock()
+		return
+	}
+	p.mu.Unlock()
+	fn()
+}
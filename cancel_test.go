@@ -0,0 +1,124 @@
+package promise
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCancelToken(t *testing.T) {
+	defer time.AfterFunc(time.Second, t.FailNow).Stop() // limit test to 1 second running time.
+
+	token := NewCancelToken()
+	notified := make(chan interface{}, 1)
+	token.OnCancel(func(reason interface{}) { notified <- reason })
+
+	root := new(Promise).WithToken(token)
+	child := root.Then(panicIfCalled, nil)
+	grandchild := child.Then(panicIfCalled, nil)
+	assert.Equal(t, token, grandchild.Token())
+
+	var done Promise
+	done.WithToken(token)
+	done.Resolve("already done")
+
+	token.Cancel("stop")
+	token.Cancel("ignored")
+	assert.True(t, token.Canceled())
+	assert.Equal(t, "stop", token.Reason())
+	assert.Equal(t, "stop", <-notified)
+
+	for _, p := range []*Promise{root, child, grandchild} {
+		val, ok := settled(p)
+		assert.False(t, ok)
+		assert.Equal(t, &CancelError{"stop"}, val)
+	}
+	val, ok := settled(&done)
+	assert.True(t, ok)
+	assert.Equal(t, "already done", val)
+
+	// The producer finishing late doesn't panic.
+	assert.NotPanics(t, func() { root.Resolve("late") })
+	val, _ = settled(root)
+	assert.Equal(t, &CancelError{"stop"}, val)
+
+	// Binding to or registering with a canceled token takes effect right away.
+	val, ok = settled(new(Promise).WithToken(token))
+	assert.False(t, ok)
+	assert.Equal(t, &CancelError{"stop"}, val)
+	token.OnCancel(func(reason interface{}) { notified <- reason })
+	assert.Equal(t, "stop", <-notified)
+}
+
+func TestCancelPropagatesUpstream(t *testing.T) {
+	defer time.AfterFunc(time.Second, t.FailNow).Stop() // limit test to 1 second running time.
+
+	stopped := make(chan interface{}, 1)
+	producer := NewCancelable(func(reason interface{}) { stopped <- reason })
+	a := producer.Then(panicIfCalled, nil)
+	b := producer.Then(panicIfCalled, nil)
+	a2 := a.Then(panicIfCalled, nil)
+
+	// Canceling one of two consumers leaves the producer running.
+	b.Cancel("b done")
+	select {
+	case r := <-stopped:
+		t.Fatalf("Stopped early: %v", r)
+	case <-time.After(10 * time.Millisecond):
+	}
+	assert.True(t, producer.IsPending())
+
+	// Once the last consumer cancels, cancellation reaches the producer.
+	a2.Cancel("a2 done")
+	assert.Equal(t, "a2 done", <-stopped)
+	for _, p := range []*Promise{producer.Promise, a, a2} {
+		val, ok := settled(p)
+		assert.False(t, ok)
+		assert.Equal(t, &CancelError{"a2 done"}, val)
+	}
+	assert.NotPanics(t, func() { producer.Resolve("late") })
+
+	// Canceling a settled promise does nothing.
+	var done Promise
+	done.Resolve(1)
+	done.Cancel("too late")
+	val, ok := settled(&done)
+	assert.True(t, ok)
+	assert.Equal(t, 1, val)
+
+	// Cancel tokens stop the producer too.
+	token := NewCancelToken()
+	producer = NewCancelable(func(reason interface{}) { stopped <- reason })
+	producer.WithToken(token)
+	token.Cancel("token")
+	assert.Equal(t, "token", <-stopped)
+}
+
+func TestCancelError(t *testing.T) {
+	err := canceled("unmounted")
+	assert.True(t, errors.Is(err, ErrCanceled))
+	assert.EqualError(t, err, "promise: canceled: unmounted")
+	assert.EqualError(t, canceled(nil), "promise: canceled")
+	assert.Equal(t, err, canceled(err))
+	assert.Equal(t, ErrCanceled, canceled(ErrCanceled))
+	assert.False(t, errors.Is(ErrTimeout, ErrCanceled))
+}
+
+func TestOnCancel(t *testing.T) {
+	var calls []string
+	p := NewCancelable(nil)
+	p.OnCancel(func(reason interface{}) { calls = append(calls, "socket: "+reason.(string)) })
+	p.OnCancel(func(reason interface{}) { calls = append(calls, "temp: "+reason.(string)) })
+	p.Cancel("unmounted")
+	p.Cancel("again")
+	p.OnCancel(func(reason interface{}) { calls = append(calls, "late: "+reason.(string)) })
+	assert.Equal(t, []string{"socket: unmounted", "temp: unmounted", "late: unmounted"}, calls)
+
+	done := NewCancelable(nil)
+	done.OnCancel(func(interface{}) { t.Error("called after settling") })
+	done.Resolve(1)
+	done.Cancel("too late")
+	done.OnCancel(func(interface{}) { t.Error("called after settling") })
+}
@@ -0,0 +1,102 @@
+package promise
+
+import (
+	"context"
+	"time"
+)
+
+// ChainBuilder describes a chain of promises step by step, with options that
+// apply to the whole chain, so that long chains read top to bottom:
+//
+//	p := promise.Chain(fetchUser).
+//		Then(loadProfile).
+//		Catch(describeError).
+//		Finally(hideSpinner).
+//		Timeout(5 * time.Second).
+//		Label("profile").
+//		Run()
+//
+// Nothing happens until Run, which may be called more than once to run the
+// chain again.
+type ChainBuilder struct {
+	start   func() *Promise
+	steps   []func(p *Promise) *Promise
+	timeout time.Duration
+	label   string
+	ctx     context.Context
+}
+
+// Chain starts describing a chain that begins with the promise returned by
+// start.  If start panics or returns nil, the chain begins with a rejected
+// promise.
+func Chain(start func() *Promise) *ChainBuilder {
+	return &ChainBuilder{start: start}
+}
+
+// Then adds a step that transforms the value of the chain with fn.  Rejections
+// skip the step.
+func (b *ChainBuilder) Then(fn Callback) *ChainBuilder {
+	return b.step(func(p *Promise) *Promise { return p.Then(fn, nil) })
+}
+
+// Catch adds a step that handles a rejection of the chain with fn, like a
+// failure callback of Then: the result of fn rejects the rest of the chain.
+// Values skip the step.
+func (b *ChainBuilder) Catch(fn Callback) *ChainBuilder {
+	return b.step(func(p *Promise) *Promise { return p.Catch(fn) })
+}
+
+// Finally adds a step that calls fn once the chain settles either way, and
+// passes the value or rejection on unchanged.
+func (b *ChainBuilder) Finally(fn func()) *ChainBuilder {
+	return b.step(func(p *Promise) *Promise { return p.Finally(fn) })
+}
+
+// Timeout rejects the promise returned by Run with ErrTimeout if the chain
+// hasn't settled d after Run was called.  The steps still running are not
+// interrupted, but the outcome of the chain is ignored.
+func (b *ChainBuilder) Timeout(d time.Duration) *ChainBuilder {
+	b.timeout = d
+	return b
+}
+
+// Label labels the promises of the chain, including the one returned by
+// start, see SetLabel.
+func (b *ChainBuilder) Label(label string) *ChainBuilder {
+	b.label = label
+	return b
+}
+
+// Context attaches ctx to the promises of the chain, so that its steps can
+// retrieve it with ContextOf, see WithContext.
+func (b *ChainBuilder) Context(ctx context.Context) *ChainBuilder {
+	b.ctx = ctx
+	return b
+}
+
+func (b *ChainBuilder) step(s func(p *Promise) *Promise) *ChainBuilder {
+	b.steps = append(b.steps, s)
+	return b
+}
+
+// Run calls start, adds the steps in order and returns the promise for the
+// outcome of the chain.
+func (b *ChainBuilder) Run() *Promise {
+	p := call(b.start)
+	if b.label != "" {
+		p.SetLabel(b.label)
+	}
+	if b.ctx != nil {
+		p.WithContext(b.ctx)
+	}
+	for _, s := range b.steps {
+		p = s(p)
+	}
+	if b.timeout > 0 {
+		// Derive the promise to halt, which may otherwise be start's.
+		end := p.Then(nil, nil)
+		time.AfterFunc(b.timeout, func() { end.halt(ErrTimeout, ErrTimeout) })
+		p = end
+	}
+	return p
+}
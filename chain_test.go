@@ -0,0 +1,44 @@
+package promise
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChain(t *testing.T) {
+	defer time.AfterFunc(time.Second, t.FailNow).Stop() // limit test to 1 second running time.
+
+	var log []string
+	double := func(v interface{}) interface{} { return v.(int) * 2 }
+	fail := func(v interface{}) interface{} { panic(errors.New("too big")) }
+	recovered := func(r interface{}) interface{} { return r.(error).Error() }
+	chain := Chain(func() *Promise { return Resolved(21) }).
+		Then(double).
+		Finally(func() { log = append(log, "finally") }).
+		Label("answer")
+
+	p := chain.Run()
+	val, ok := settled(p)
+	assert.True(t, ok)
+	assert.Equal(t, 42, val)
+	assert.Equal(t, "answer", p.Label())
+	assert.Equal(t, []string{"finally"}, log)
+
+	// Running again reruns the whole chain, including added steps.
+	val, ok = settled(chain.Then(fail).Then(double).Catch(recovered).Run())
+	assert.False(t, ok)
+	assert.Equal(t, "too big", val)
+	assert.Equal(t, []string{"finally", "finally"}, log)
+
+	// Rejections pass through Finally.
+	val, ok = settled(Chain(func() *Promise { panic("start") }).Finally(func() {}).Run())
+	assert.False(t, ok)
+	assert.Equal(t, "start", val)
+
+	val, ok = settled(Chain(Never).Timeout(10 * time.Millisecond).Run())
+	assert.False(t, ok)
+	assert.Equal(t, ErrTimeout, val)
+}
@@ -0,0 +1,45 @@
+package promise
+
+import (
+	"reflect"
+	"sync/atomic"
+
+	"github.com/gopherjs/gopherjs/js"
+)
+
+// Coerce returns a promise for v, normalizing the "value or promise"
+// arguments of APIs that accept either:
+//
+//   - a *Promise is returned as is, and a *CancelablePromise as its Promise;
+//   - a JS thenable (see IsThenable) is adopted: the promise settles like it,
+//     rejecting with a *js.Error for JS rejection reasons;
+//   - a channel that can be received from resolves with the first value
+//     received, or with the zero value if the channel is closed first, like
+//     a receive in Go;
+//   - anything else, including nil, resolves with v itself.
+func Coerce(v interface{}) *Promise {
+	switch x := v.(type) {
+	case *Promise:
+		if x != nil {
+			return x
+		}
+	case *CancelablePromise:
+		if IsPromise(x) {
+			return x.Promise
+		}
+	case *js.Object:
+		if IsThenable(x) {
+			return fromJS(x)
+		}
+	}
+	if c := reflect.ValueOf(v); c.Kind() == reflect.Chan && c.Type().ChanDir()&reflect.RecvDir != 0 {
+		p := newPromise()
+		atomic.AddInt64(&counters.Goroutines, 1)
+		go func() {
+			val, _ := c.Recv()
+			p.Resolve(val.Interface())
+		}()
+		return p
+	}
+	return Resolved(v)
+}
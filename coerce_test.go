@@ -0,0 +1,36 @@
+package promise
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCoerce(t *testing.T) {
+	defer time.AfterFunc(time.Second, t.FailNow).Stop() // limit test to 1 second running time.
+
+	p := newPromise()
+	assert.True(t, p == Coerce(p))
+	c := NewCancelable(nil)
+	assert.True(t, c.Promise == Coerce(c))
+
+	for _, v := range []interface{}{nil, 42, "text", (*Promise)(nil), make(chan<- int)} {
+		val, ok := settled(Coerce(v))
+		assert.True(t, ok)
+		assert.Equal(t, v, val)
+	}
+
+	ch := make(chan string)
+	fromChan := Coerce(ch)
+	ch <- "sent"
+	val, ok := settled(fromChan)
+	assert.True(t, ok)
+	assert.Equal(t, "sent", val)
+
+	done := make(chan struct{})
+	close(done)
+	val, ok = settled(Coerce((<-chan struct{})(done)))
+	assert.True(t, ok)
+	assert.Equal(t, struct{}{}, val)
+}
@@ -0,0 +1,228 @@
+package promise
+
+import (
+	"fmt"
+	"sync"
+)
+
+// All returns a promise that is resolved with a slice of the values of ps (in
+// the same order) once every one has fulfilled, or rejected with the reason of
+// the first one that is rejected.  With no promises, it resolves with an empty
+// slice right away.  Canceling the returned promise, e.g. when it is attached
+// to a Deadline that passes, cancels the pending promises as their consumer,
+// see Cancel.
+func All(ps ...*Promise) *Promise {
+	tasks := make([]func() *Promise, len(ps))
+	for i, p := range ps {
+		p := p
+		tasks[i] = func() *Promise { return p }
+	}
+	return AllLimit(0, tasks...)
+}
+
+// AllLimit calls each of the tasks to start them, running at most limit of
+// their promises at a time, and returns a promise that is resolved with a slice
+// of all of their results (in the same order as tasks) once every one has
+// fulfilled.  A new task is started only when a running one fulfills, so tasks
+// can be used to bound the load placed on a backend.
+//
+// If any task's promise is rejected (or a task panics), the returned promise
+// is rejected with that reason and no further tasks are started.  A limit less
+// than 1 means that all tasks are started immediately.  Canceling the returned
+// promise cancels the running tasks and starts no further ones.
+func AllLimit(limit int, tasks ...func() *Promise) *Promise {
+	result := newPromise()
+	if len(tasks) == 0 {
+		result.Resolve([]interface{}{})
+		return result
+	}
+	if limit < 1 || limit > len(tasks) {
+		limit = len(tasks)
+	}
+
+	var (
+		mu        sync.Mutex
+		next      int
+		remaining = len(tasks)
+		failed    bool
+		values    = make([]interface{}, len(tasks))
+		running   []*Promise // consuming the started tasks, see cancelAll
+	)
+	var start func()
+	start = func() {
+		mu.Lock()
+		if failed || next >= len(tasks) {
+			mu.Unlock()
+			return
+		}
+		i := next
+		next++
+		mu.Unlock()
+
+		p := call(tasks[i])
+		graphEdge(p, result, EdgeMember)
+		child := p.Then(
+			func(val interface{}) interface{} {
+				mu.Lock()
+				values[i] = val
+				remaining--
+				done := remaining == 0 && !failed
+				mu.Unlock()
+				if done {
+					result.Resolve(values)
+				} else {
+					start()
+				}
+				return val
+			},
+			func(err interface{}) interface{} {
+				mu.Lock()
+				first := !failed
+				failed = true
+				mu.Unlock()
+				if first {
+					result.Reject(err)
+				}
+				return err
+			})
+		mu.Lock()
+		running = append(running, child)
+		mu.Unlock()
+	}
+	result.onStop(func(reason interface{}) {
+		mu.Lock()
+		failed = true
+		children := running
+		mu.Unlock()
+		cancelAll(children, reason)
+	})
+	for i := 0; i < limit; i++ {
+		start()
+	}
+	return result
+}
+
+// IndexError is the rejection reason used to report which item of a sequence
+// failed.
+type IndexError struct {
+	Index  int
+	Reason interface{}
+}
+
+func (e IndexError) Error() string { return fmt.Sprintf("item %d: %v", e.Index, e.Reason) }
+
+// EachSeries calls fn for each of the items in order and returns a promise
+// that is resolved with a slice of the results once all of them have
+// fulfilled.  The call for the next item is not made until the promise for
+// the previous item has fulfilled, so at most one is ever running.
+//
+// If the promise for any item is rejected (or fn panics), no further items
+// are processed and the returned promise is rejected with an IndexError
+// holding the index of the item and its rejection reason.
+func EachSeries(items []interface{}, fn func(i int, item interface{}) *Promise) *Promise {
+	result := newPromise()
+	values := make([]interface{}, len(items))
+	var step func(i int)
+	step = func(i int) {
+		if i == len(items) {
+			result.Resolve(values)
+			return
+		}
+		call(func() *Promise { return fn(i, items[i]) }).Then(
+			func(val interface{}) interface{} {
+				values[i] = val
+				step(i + 1)
+				return val
+			},
+			func(err interface{}) interface{} {
+				return result.Reject(IndexError{i, err})
+			})
+	}
+	step(0)
+	return result
+}
+
+// Times calls fn n times, with i from 0 to n-1, and returns a promise that is
+// resolved with a slice of the n results once all of them have fulfilled, or
+// rejected with the first rejection.  All n calls are made immediately; see
+// TimesLimit to bound how many are running at once.
+func Times(n int, fn func(i int) *Promise) *Promise {
+	return TimesLimit(n, 0, fn)
+}
+
+// TimesLimit is like Times but has at most limit of the promises running at a
+// time, as with AllLimit.
+func TimesLimit(n, limit int, fn func(i int) *Promise) *Promise {
+	tasks := make([]func() *Promise, n)
+	for i := range tasks {
+		i := i
+		tasks[i] = func() *Promise { return fn(i) }
+	}
+	return AllLimit(limit, tasks...)
+}
+
+// AggregateError is the rejection reason of Any when all of its promises are
+// rejected.  Errors holds their reasons, in the same order as the promises.
+// In JS it is an AggregateError, like that of Promise.any.
+type AggregateError struct {
+	Errors []interface{}
+}
+
+func (e AggregateError) Error() string {
+	return fmt.Sprintf("promise: all %d promises were rejected", len(e.Errors))
+}
+
+// Any returns a promise that is resolved with the value of the first of ps to
+// fulfill, or rejected with an AggregateError once all of them are rejected.
+// With no promises, it is rejected right away.  Canceling the returned promise
+// cancels the pending promises, as for All.
+func Any(ps ...*Promise) *Promise {
+	if len(ps) == 0 {
+		return Rejected(AggregateError{[]interface{}{}})
+	}
+	result := newPromise()
+	var (
+		mu        sync.Mutex
+		remaining = len(ps)
+		done      bool
+		reasons   = make([]interface{}, len(ps))
+		children  = make([]*Promise, len(ps))
+	)
+	for i, p := range ps {
+		i := i
+		graphEdge(p, result, EdgeMember)
+		children[i] = p.Then(
+			func(val interface{}) interface{} {
+				mu.Lock()
+				first := !done
+				done = true
+				mu.Unlock()
+				if first {
+					result.Resolve(val)
+				}
+				return val
+			},
+			func(err interface{}) interface{} {
+				mu.Lock()
+				reasons[i] = err
+				remaining--
+				last := remaining == 0 && !done
+				mu.Unlock()
+				if last {
+					result.Reject(AggregateError{reasons})
+				}
+				return err
+			})
+	}
+	result.onStop(func(reason interface{}) { cancelAll(children, reason) })
+	return result
+}
+
+// cancelAll cancels ps, the promises through which a combinator consumes its
+// members, when the promise of the combinator is canceled.  A member is
+// canceled in turn unless it has other consumers.
+func cancelAll(ps []*Promise, reason interface{}) {
+	for _, p := range ps {
+		p.Cancel(reason)
+	}
+}
@@ -0,0 +1,160 @@
+package promise
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// gauge tracks how many tasks are running at once.
+type gauge struct {
+	mu           sync.Mutex
+	current, max int
+}
+
+// task returns a task that resolves with val (or rejects if fail is set) after
+// a short delay, recording its concurrency in g.
+func (g *gauge) task(val interface{}, fail bool) func() *Promise {
+	return func() *Promise {
+		g.mu.Lock()
+		g.current++
+		if g.current > g.max {
+			g.max = g.current
+		}
+		g.mu.Unlock()
+
+		var p Promise
+		go func() {
+			time.Sleep(5 * time.Millisecond)
+			g.mu.Lock()
+			g.current--
+			g.mu.Unlock()
+			if fail {
+				p.Reject(val)
+			} else {
+				p.Resolve(val)
+			}
+		}()
+		return &p
+	}
+}
+
+func TestAll(t *testing.T) {
+	defer time.AfterFunc(time.Second, t.FailNow).Stop() // limit test to 1 second running time.
+
+	var g gauge
+	slow, fast := g.task("slow", false)(), Resolved("fast")
+	val, ok := settled(All(slow, fast))
+	assert.True(t, ok)
+	assert.Equal(t, []interface{}{"slow", "fast"}, val)
+
+	val, ok = settled(All())
+	assert.True(t, ok)
+	assert.Equal(t, []interface{}{}, val)
+
+	val, ok = settled(All(g.task(1, false)(), g.task("bad", true)(), newPromise()))
+	assert.False(t, ok)
+	assert.Equal(t, "bad", val)
+}
+
+func TestAny(t *testing.T) {
+	defer time.AfterFunc(time.Second, t.FailNow).Stop() // limit test to 1 second running time.
+
+	var g gauge
+	val, ok := settled(Any(g.task("bad", true)(), g.task("slow", false)(), newPromise()))
+	assert.True(t, ok)
+	assert.Equal(t, "slow", val)
+
+	val, ok = settled(Any(g.task("a", true)(), g.task("b", true)()))
+	assert.False(t, ok)
+	assert.Equal(t, AggregateError{[]interface{}{"a", "b"}}, val)
+	assert.EqualError(t, val.(error), "promise: all 2 promises were rejected")
+
+	val, ok = settled(Any())
+	assert.False(t, ok)
+	assert.Equal(t, AggregateError{[]interface{}{}}, val)
+}
+
+func TestAllLimit(t *testing.T) {
+	defer time.AfterFunc(time.Second, t.FailNow).Stop() // limit test to 1 second running time.
+
+	var g gauge
+	var tasks []func() *Promise
+	for i := 0; i < 7; i++ {
+		tasks = append(tasks, g.task(i, false))
+	}
+	val, ok := settled(AllLimit(2, tasks...))
+	assert.True(t, ok)
+	assert.Equal(t, []interface{}{0, 1, 2, 3, 4, 5, 6}, val)
+	assert.Equal(t, 2, g.max)
+
+	val, ok = settled(AllLimit(0))
+	assert.True(t, ok)
+	assert.Equal(t, []interface{}{}, val)
+
+	// The first rejection wins and stops starting new tasks.
+	started := 0
+	g = gauge{}
+	counted := func(task func() *Promise) func() *Promise {
+		return func() *Promise { started++; return task() }
+	}
+	val, ok = settled(AllLimit(1,
+		counted(g.task(0, false)),
+		counted(g.task("bad", true)),
+		counted(g.task(2, false))))
+	assert.False(t, ok)
+	assert.Equal(t, "bad", val)
+	time.Sleep(10 * time.Millisecond)
+	assert.Equal(t, 2, started)
+}
+
+func TestEachSeries(t *testing.T) {
+	defer time.AfterFunc(time.Second, t.FailNow).Stop() // limit test to 1 second running time.
+
+	var g gauge
+	var order []interface{}
+	val, ok := settled(EachSeries([]interface{}{"a", "b", "c"}, func(i int, item interface{}) *Promise {
+		order = append(order, item)
+		return g.task(i, false)()
+	}))
+	assert.True(t, ok)
+	assert.Equal(t, []interface{}{0, 1, 2}, val)
+	assert.Equal(t, []interface{}{"a", "b", "c"}, order)
+	assert.Equal(t, 1, g.max)
+
+	order = nil
+	val, ok = settled(EachSeries([]interface{}{"a", "b", "c"}, func(i int, item interface{}) *Promise {
+		order = append(order, item)
+		return g.task("bad", i == 1)()
+	}))
+	assert.False(t, ok)
+	assert.Equal(t, IndexError{1, "bad"}, val)
+	assert.EqualError(t, val.(error), "item 1: bad")
+	assert.Equal(t, []interface{}{"a", "b"}, order)
+
+	val, ok = settled(EachSeries(nil, nil))
+	assert.True(t, ok)
+	assert.Equal(t, []interface{}{}, val)
+}
+
+func TestTimes(t *testing.T) {
+	defer time.AfterFunc(time.Second, t.FailNow).Stop() // limit test to 1 second running time.
+
+	var g gauge
+	val, ok := settled(Times(4, func(i int) *Promise { return g.task(i*i, false)() }))
+	assert.True(t, ok)
+	assert.Equal(t, []interface{}{0, 1, 4, 9}, val)
+	assert.Equal(t, 4, g.max)
+
+	g = gauge{}
+	val, ok = settled(TimesLimit(5, 2, func(i int) *Promise { return g.task(i, false)() }))
+	assert.True(t, ok)
+	assert.Equal(t, []interface{}{0, 1, 2, 3, 4}, val)
+	assert.Equal(t, 2, g.max)
+
+	val, ok = settled(Times(3, func(i int) *Promise { return g.task(i, i == 2)() }))
+	assert.False(t, ok)
+	assert.Equal(t, 2, val)
+}
@@ -0,0 +1,290 @@
+package promise
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Scheduler runs the asynchronous work of promises: dispatching callbacks to
+// the handlers registered with Then and running promisified functions.
+type Scheduler interface {
+	Schedule(task func())
+}
+
+// SchedulerFunc adapts a function to the Scheduler interface.
+type SchedulerFunc func(task func())
+
+// Schedule implements Scheduler.
+func (f SchedulerFunc) Schedule(task func()) { f(task) }
+
+// PriorityScheduler is implemented by schedulers that can order tasks by
+// priority, such as the priority a JS caller passes in the options of a
+// promisified call.  Tasks with a higher priority should run first.
+type PriorityScheduler interface {
+	Scheduler
+	SchedulePriority(task func(), priority int)
+}
+
+// schedulePriority runs task with s, at the given priority if s supports it.
+func schedulePriority(s Scheduler, task func(), priority int) {
+	if ps, ok := s.(PriorityScheduler); ok {
+		ps.SchedulePriority(task, priority)
+	} else {
+		s.Schedule(task)
+	}
+}
+
+// GoroutineScheduler runs each task on a new goroutine.  It is the default.
+var GoroutineScheduler Scheduler = SchedulerFunc(func(task func()) {
+	atomic.AddInt64(&counters.Goroutines, 1)
+	go task()
+})
+
+// PanicPolicy determines what happens when a promisified function panics.
+type PanicPolicy int
+
+const (
+	// PanicDefault defers to the global configuration, or to PanicReject if
+	// it isn't set either.
+	PanicDefault PanicPolicy = iota
+	// PanicReject rejects the promise with the panic value.
+	PanicReject
+	// PanicCrash lets the panic propagate and crash the program, which keeps
+	// the original stack trace.
+	PanicCrash
+)
+
+// ConversionMode determines how JS arguments are checked and converted for
+// the parameters of promisified functions.
+type ConversionMode int
+
+const (
+	// ConversionDefault defers to the global configuration, or to Lenient if
+	// it isn't set either.
+	ConversionDefault ConversionMode = iota
+	// Lenient passes zero values for missing arguments, drops extra ones, and
+	// converts arguments as well as possible: e.g. 1.5 for an int is
+	// truncated, and numbers and numeric strings convert to each other.
+	// Arguments that can't be converted are passed as is.
+	Lenient
+	// Strict rejects calls with a *ConversionError if an argument doesn't
+	// convert to its parameter's type exactly, and with an *ArityError if
+	// there are missing or extra arguments.
+	Strict
+)
+
+// NilResultPolicy determines how nil results of promisified functions (nil
+// interfaces, pointers, slices and maps) are passed to JS.
+type NilResultPolicy int
+
+const (
+	// NilDefault defers to the global configuration, or to NilAsNull if it
+	// isn't set either.
+	NilDefault NilResultPolicy = iota
+	// NilAsNull converts nil to null.
+	NilAsNull
+	// NilAsUndefined converts nil to undefined, so that nil results and
+	// fields are falsy to JS checks like "x === undefined" and default
+	// parameters.
+	NilAsUndefined
+)
+
+// NullArgPolicy determines how null and undefined JS arguments (and object
+// properties and array elements) are converted for the parameters of
+// promisified functions.  Missing arguments and properties count as
+// undefined; gopherjs doesn't distinguish null from undefined properties and
+// elements, so they count as null.
+type NullArgPolicy int
+
+const (
+	// NullDefault defers to the global configuration, or to NullAsZero if it
+	// isn't set either.
+	NullDefault NullArgPolicy = iota
+	// NullAsZero converts both null and undefined to the zero value of the
+	// parameter's type, e.g. "" for strings and nil for pointers.
+	NullAsZero
+	// NullOnlyForNillable converts undefined to the zero value, but accepts
+	// null only for types that can be nil (pointers, slices, maps,
+	// interfaces and *js.Object), rejecting the call with a
+	// *ConversionError otherwise.  This keeps an explicit null from silently
+	// becoming e.g. 0.
+	NullOnlyForNillable
+)
+
+// RejectionPolicy determines what happens when a promise is rejected with a
+// reason that is neither an error nor a string (nor a JS value), such as a
+// struct or an int, which downstream handlers rarely expect.
+type RejectionPolicy int
+
+const (
+	// RejectionDefault defers to the global configuration, or to
+	// PassNonErrors if it isn't set either.
+	RejectionDefault RejectionPolicy = iota
+	// PassNonErrors rejects with such reasons unchanged.
+	PassNonErrors
+	// WrapNonErrors rejects with a RejectionError wrapping the reason instead.
+	WrapNonErrors
+	// ReportNonErrors rejects with the reason unchanged, but reports it to
+	// Config.NonErrorHandler first, or logs it if that isn't set.
+	ReportNonErrors
+)
+
+// Config holds the package-wide settings, see Configure.  The zero value of
+// each field selects the default behavior.
+type Config struct {
+	// Scheduler runs callbacks and promisified functions.  Promises keep the
+	// scheduler that was configured when they were created, and promises
+	// returned by Then inherit it.  Defaults to GoroutineScheduler.
+	Scheduler Scheduler
+	// ErrorSerializer converts errors returned by promisified functions (and
+	// other errors passed to JS) into rejection reasons for JS.  Defaults to
+	// the SerializeError functions of the registered plugins, and then to
+	// an AbortError for cancellations (see ErrCanceled), a TypeError for
+	// ErrResolvedWithItself, an AggregateError for AggregateError, and to the
+	// error's message.
+	ErrorSerializer func(err error) interface{}
+	// PanicPolicy determines what happens when a promisified function panics.
+	PanicPolicy PanicPolicy
+	// UnhandledRejectionHandler, if set, is installed with OnUnhandledRejection
+	// by Configure.
+	UnhandledRejectionHandler func(p *Promise, reason interface{})
+	// DefaultTimeout rejects the promises of promisified calls with ErrTimeout
+	// if they don't settle in time.  Zero means no timeout.
+	DefaultTimeout time.Duration
+	// Conversion determines how tolerant promisified functions are of the
+	// arguments they are called with.  Defaults to Lenient.
+	Conversion ConversionMode
+	// FieldNaming names the JS properties that struct fields are converted
+	// from for arguments and to for results.  Defaults to LowerCamelCase.
+	// Arguments may use the Go names of fields as well, unless they are
+	// tagged.
+	FieldNaming NamingPolicy
+	// ArgLimits guards promisified functions against oversized or malformed
+	// arguments.  Each unset limit defaults to the global configuration, and
+	// there are no limits by default.
+	ArgLimits ArgLimits
+	// NilResults determines how nil results are passed to JS.  Defaults to
+	// NilAsNull.
+	NilResults NilResultPolicy
+	// NullArgs determines how null and undefined arguments are converted.
+	// Defaults to NullAsZero.
+	NullArgs NullArgPolicy
+	// NonErrorRejections determines what happens when a promise is rejected
+	// with a reason that is neither an error, a string, nor a JS value.
+	// Defaults to PassNonErrors.
+	NonErrorRejections RejectionPolicy
+	// NonErrorHandler is called with such rejections under ReportNonErrors.
+	NonErrorHandler func(p *Promise, reason interface{})
+	// SynchronousThen makes callbacks registered with Then on settled
+	// promises run synchronously, see SetSynchronous.  Individual promises can
+	// opt out with SetSynchronous(false).
+	SynchronousThen bool
+	// CaptureStacks records the stack at which each promise is created, see
+	// CreationStack.  It is expensive, so it is off by default; NewWith can
+	// override it for individual promises.
+	CaptureStacks bool
+}
+
+// config holds a *Config, the configuration set by Configure.  It is read
+// when each promise is created, so it is loaded without locking or copying.
+var config atomic.Value
+
+// Configure replaces the package-wide configuration.  It applies to promises
+// and Promisify wrappers created afterwards; PromisifyWith overrides it for a
+// single function.
+func Configure(c Config) {
+	config.Store(&c)
+	if c.UnhandledRejectionHandler != nil {
+		OnUnhandledRejection(c.UnhandledRejectionHandler, nil)
+	}
+}
+
+// CurrentConfig returns the configuration set by Configure.
+func CurrentConfig() Config { return *currentConfig() }
+
+// currentConfig returns the configuration set by Configure, which must not
+// be modified.
+func currentConfig() *Config {
+	if c, _ := config.Load().(*Config); c != nil {
+		return c
+	}
+	return &unconfigured
+}
+
+// unconfigured is the configuration until Configure is called.
+var unconfigured Config
+
+// merge returns c with its unset fields taken from defaults.
+func (c Config) merge(defaults Config) Config {
+	if c.Scheduler == nil {
+		c.Scheduler = defaults.Scheduler
+	}
+	if c.ErrorSerializer == nil {
+		c.ErrorSerializer = defaults.ErrorSerializer
+	}
+	if c.PanicPolicy == PanicDefault {
+		c.PanicPolicy = defaults.PanicPolicy
+	}
+	if c.UnhandledRejectionHandler == nil {
+		c.UnhandledRejectionHandler = defaults.UnhandledRejectionHandler
+	}
+	if c.DefaultTimeout == 0 {
+		c.DefaultTimeout = defaults.DefaultTimeout
+	}
+	if c.Conversion == ConversionDefault {
+		c.Conversion = defaults.Conversion
+	}
+	if c.FieldNaming == nil {
+		c.FieldNaming = defaults.FieldNaming
+	}
+	c.ArgLimits = c.ArgLimits.merge(defaults.ArgLimits)
+	if c.NilResults == NilDefault {
+		c.NilResults = defaults.NilResults
+	}
+	if c.NullArgs == NullDefault {
+		c.NullArgs = defaults.NullArgs
+	}
+	if c.NonErrorRejections == RejectionDefault {
+		c.NonErrorRejections = defaults.NonErrorRejections
+	}
+	if c.NonErrorHandler == nil {
+		c.NonErrorHandler = defaults.NonErrorHandler
+	}
+	c.SynchronousThen = c.SynchronousThen || defaults.SynchronousThen
+	c.CaptureStacks = c.CaptureStacks || defaults.CaptureStacks
+	return c
+}
+
+// resolved returns c merged with the global configuration and the built-in
+// defaults, so that every field is set.
+func (c Config) resolved() Config {
+	return c.merge(CurrentConfig()).merge(Config{
+		Scheduler:       GoroutineScheduler,
+		ErrorSerializer: pluginsSerializeError,
+		PanicPolicy:     PanicReject,
+		Conversion:      Lenient,
+		FieldNaming:     LowerCamelCase,
+		NilResults:      NilAsNull,
+		NullArgs:        NullAsZero,
+
+		NonErrorRejections: PassNonErrors,
+		NonErrorHandler:    logNonError,
+	})
+}
+
+// schedule runs task with p's scheduler, at p's priority.
+func (p *Promise) schedule(task func()) {
+	p.mu.Lock()
+	scheduler, priority := p.scheduler, p.priority
+	p.mu.Unlock()
+	if scheduler == nil {
+		GoroutineScheduler.Schedule(task)
+	} else {
+		schedulePriority(scheduler, task, priority)
+	}
+}
+
+// serializeError converts err for JS according to the global configuration.
+func serializeError(err error) interface{} {
+	return Config{}.resolved().ErrorSerializer(err)
+}
@@ -0,0 +1,73 @@
+package promise
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfigure(t *testing.T) {
+	defer time.AfterFunc(time.Second, t.FailNow).Stop() // limit test to 1 second running time.
+	defer Configure(Config{})
+
+	tasks := make(chan func(), 10)
+	Configure(Config{
+		Scheduler:      SchedulerFunc(func(task func()) { tasks <- task }),
+		DefaultTimeout: time.Minute,
+	})
+	assert.Equal(t, time.Minute, CurrentConfig().DefaultTimeout)
+	c := CurrentConfig()
+	c.DefaultTimeout = time.Hour
+	assert.Equal(t, time.Minute, CurrentConfig().DefaultTimeout, "CurrentConfig returns a copy")
+
+	p := newPromise()
+	done := make(chan interface{}, 1)
+	p.Then(func(v interface{}) interface{} { done <- v; return v }, nil)
+	p.Resolve(1)
+	assert.Equal(t, 0, len(done), "callbacks wait for the scheduler")
+	(<-tasks)()
+	assert.Equal(t, 1, <-done)
+
+	// Promises keep the scheduler they were created with.
+	Configure(Config{})
+	for len(tasks) > 0 {
+		(<-tasks)() // settling the child
+	}
+	p.Then(func(v interface{}) interface{} { done <- v; return v }, nil)
+	assert.Equal(t, 1, len(tasks))
+	(<-tasks)()
+	assert.Equal(t, 1, <-done)
+}
+
+func TestConfigResolved(t *testing.T) {
+	defer Configure(Config{})
+	Configure(Config{PanicPolicy: PanicCrash, DefaultTimeout: time.Second})
+
+	c := Config{DefaultTimeout: time.Minute}.resolved()
+	assert.Equal(t, PanicCrash, c.PanicPolicy)
+	assert.Equal(t, time.Minute, c.DefaultTimeout)
+	assert.NotNil(t, c.Scheduler)
+	assert.Equal(t, "promise: timed out", c.ErrorSerializer(ErrTimeout))
+
+	Configure(Config{})
+	assert.Equal(t, PanicReject, Config{}.resolved().PanicPolicy)
+}
+
+func TestConfigureConcurrently(t *testing.T) {
+	defer time.AfterFunc(time.Second, t.FailNow).Stop() // limit test to 1 second running time.
+	defer Configure(Config{})
+
+	done := make(chan bool)
+	go func() {
+		for i := 0; i < 100; i++ {
+			Configure(Config{DefaultTimeout: time.Duration(i)})
+		}
+		close(done)
+	}()
+	for i := 0; i < 100; i++ {
+		newPromise()
+	}
+	<-done
+	assert.Equal(t, 99*time.Nanosecond, CurrentConfig().DefaultTimeout)
+}
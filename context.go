@@ -0,0 +1,34 @@
+package promise
+
+import "context"
+
+// WithContext attaches ctx to p and returns p for chaining.  Promises returned
+// by Then inherit the context, so that request metadata such as a request ID
+// or an auth token attached at the start of a chain is available to every
+// step through ContextOf, without closing over it at each step:
+//
+//	p := fetchUser(id).WithContext(ctx)
+//	p.Then(func(user interface{}) interface{} {
+//		return loadProfile(promise.ContextOf(p), user)
+//	}, nil)
+//
+// Promisified functions with a context.Context parameter receive the context
+// of the promise of their call, see Method.
+func (p *Promise) WithContext(ctx context.Context) *Promise {
+	p.mu.Lock()
+	p.ctx = ctx
+	p.mu.Unlock()
+	return p
+}
+
+// ContextOf returns the context attached to p or inherited from its parent,
+// or context.Background() if there is none.
+func ContextOf(p *Promise) context.Context {
+	p.mu.Lock()
+	ctx := p.ctx
+	p.mu.Unlock()
+	if ctx == nil {
+		return context.Background()
+	}
+	return ctx
+}
@@ -0,0 +1,52 @@
+package promise
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type requestIDKey struct{}
+
+func TestWithContext(t *testing.T) {
+	defer time.AfterFunc(time.Second, t.FailNow).Stop() // limit test to 1 second running time.
+
+	assert.Equal(t, context.Background(), ContextOf(newPromise()))
+
+	ctx := context.WithValue(context.Background(), requestIDKey{}, "req-1")
+	root := newPromise().WithContext(ctx)
+	child := root.Then(nil, nil).Then(nil, nil)
+	assert.Equal(t, "req-1", ContextOf(child).Value(requestIDKey{}))
+
+	// Methods called with a context receive it and pass it on.
+	lookup := Method(func(ctx context.Context, key string) string {
+		return ctx.Value(requestIDKey{}).(string) + ":" + key
+	})
+	p := lookup(ContextOf(child), "user")
+	assert.Equal(t, ctx, ContextOf(p))
+	val, ok := settled(p)
+	assert.True(t, ok)
+	assert.Equal(t, "req-1:user", val)
+
+	// The injected context is canceled with the promise.
+	done := make(chan error, 1)
+	wait := Method(func(ctx context.Context) { <-ctx.Done(); done <- ctx.Err() })
+	wait().Cancel("stop")
+	assert.Equal(t, context.Canceled, <-done)
+
+	// So is an injected token, with the reason.
+	reasons := make(chan interface{}, 1)
+	watch := Method(func(token *CancelToken) {
+		stopped := make(chan bool)
+		token.OnCancel(func(r interface{}) { reasons <- r; close(stopped) })
+		<-stopped
+	})
+	watch().Cancel("stop")
+	assert.Equal(t, "stop", <-reasons)
+
+	val, _ = settled(Chain(func() *Promise { return Resolved(1) }).Context(ctx).Run())
+	assert.Equal(t, 1, val)
+	assert.Equal(t, "req-1", ContextOf(Chain(newPromise).Context(ctx).Then(nil).Run()).Value(requestIDKey{}))
+}
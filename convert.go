@@ -0,0 +1,720 @@
+package promise
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"github.com/gopherjs/gopherjs/js"
+)
+
+var (
+	readerType = reflect.TypeOf((*io.Reader)(nil)).Elem()
+	bytesType  = reflect.TypeOf([]byte(nil))
+)
+
+// ConversionError is the rejection reason for a call to a promisified
+// function with an argument that can't be converted to the type of its
+// parameter in Strict conversion mode, see Config.Conversion.
+type ConversionError struct {
+	Path  string       // where the value was found, e.g. "arguments[0].name"
+	Type  reflect.Type // the Go type it should have been converted to
+	Value interface{}  // the value, as converted by gopherjs
+}
+
+func (e *ConversionError) Error() string {
+	return fmt.Sprintf("promise: %s: cannot convert %T to %s", e.Path, e.Value, e.Type)
+}
+
+// ArityError is the rejection reason for a call to a promisified function
+// with the wrong number of arguments in Strict conversion mode.
+type ArityError struct {
+	Min, Max int // the accepted number of arguments; Max is -1 if unlimited
+	Got      int
+}
+
+func (e *ArityError) Error() string {
+	switch {
+	case e.Max < 0:
+		return fmt.Sprintf("promise: expected at least %d arguments, got %d", e.Min, e.Got)
+	case e.Min == e.Max:
+		return fmt.Sprintf("promise: expected %d arguments, got %d", e.Min, e.Got)
+	}
+	return fmt.Sprintf("promise: expected %d to %d arguments, got %d", e.Min, e.Max, e.Got)
+}
+
+// arity returns the number of JS arguments accepted by a promisified function
+// of type t.
+func arity(t reflect.Type) (min, max int) {
+	for i := 0; i < t.NumIn(); i++ {
+		if !injected(t.In(i)) {
+			min++
+		}
+	}
+	if t.IsVariadic() {
+		return min - 1, -1
+	}
+	return min, min
+}
+
+// maxSafeInteger is the largest integer that JS numbers represent exactly,
+// Number.MAX_SAFE_INTEGER.
+const maxSafeInteger = 1<<53 - 1
+
+// PrecisionError is the rejection reason for a call to a promisified function
+// with a number for an integer parameter that is outside the range of
+// integers that JS numbers represent exactly, and so may have been rounded.
+// Such values must be passed as a BigInt (or a string).
+type PrecisionError struct {
+	Path  string       // where the value was found, e.g. "arguments[0].id"
+	Type  reflect.Type // the integer type it should have been converted to
+	Value float64
+}
+
+func (e *PrecisionError) Error() string {
+	return fmt.Sprintf("promise: %s: %v exceeds the safe integer range for %s, pass it as a BigInt", e.Path, e.Value, e.Type)
+}
+
+// isInteger reports whether k is an integer kind.
+func isInteger(k reflect.Kind) bool {
+	return k >= reflect.Int && k <= reflect.Uintptr
+}
+
+// unsafeInteger returns v as a *big.Int if it is an integer outside the range
+// of integers that JS numbers represent exactly.
+func unsafeInteger(v interface{}) (*big.Int, bool) {
+	rv := reflect.ValueOf(v)
+	switch {
+	case rv.Kind() >= reflect.Int && rv.Kind() <= reflect.Int64:
+		if n := rv.Int(); n > maxSafeInteger || n < -maxSafeInteger {
+			return big.NewInt(n), true
+		}
+	case rv.Kind() >= reflect.Uint && rv.Kind() <= reflect.Uintptr:
+		if n := rv.Uint(); n > maxSafeInteger {
+			return new(big.Int).SetUint64(n), true
+		}
+	}
+	return nil, false
+}
+
+// converter converts JS values for the parameters of promisified functions.
+type converter struct {
+	strict bool         // see Strict
+	naming NamingPolicy // see Config.FieldNaming; nil means LowerCamelCase
+
+	nilUndefined bool // see NilAsUndefined
+	strictNull   bool // see NullOnlyForNillable
+
+	// externalize converts nested values back to JS for registered
+	// converters; nil means jsValue.
+	externalize func(v interface{}) *js.Object
+}
+
+// jsValue converts v, a JS value as converted by gopherjs, back to a JS value.
+func jsValue(v interface{}) *js.Object {
+	holder := js.Global.Get("Object").New()
+	holder.Set("value", v) // externalized by gopherjs
+	return holder.Get("value")
+}
+
+// newConverter returns the converter for the configuration c.
+func newConverter(c Config) converter {
+	return converter{
+		strict:       c.Conversion == Strict,
+		naming:       c.FieldNaming,
+		nilUndefined: c.NilResults == NilAsUndefined,
+		strictNull:   c.NullArgs == NullOnlyForNillable,
+	}
+}
+
+// convertArgs converts the JS arguments of a call to a promisified function of
+// type t into Go values, inserting the injected parameters for the call
+// producing p.  It runs on the goroutine of the call and may block, e.g. to
+// read the contents of a Blob.
+func (c converter) convertArgs(t reflect.Type, p *Promise, args []*js.Object) ([]reflect.Value, error) {
+	if min, max := arity(t); c.strict && (len(args) < min || max >= 0 && len(args) > max) {
+		return nil, &ArityError{min, max, len(args)}
+	}
+	in := make([]reflect.Value, 0, len(args)+1)
+	n := 0 // index of the next JS argument
+	for i := 0; i < t.NumIn(); i++ {
+		param := t.In(i)
+		if injected(param) {
+			in = append(in, injectedValue(param, p))
+			continue
+		}
+		if t.IsVariadic() && i == t.NumIn()-1 {
+			for ; n < len(args); n++ {
+				v, err := c.convertArg(args[n], param.Elem(), fmt.Sprintf("arguments[%d]", n))
+				if err != nil {
+					return nil, err
+				}
+				in = append(in, v)
+			}
+			break
+		}
+		if n >= len(args) {
+			if param.Kind() == reflect.Struct && hasRequiredOrDefaults(param) {
+				// Apply the defaults as if an empty object was passed.
+				v, err := c.convertValue(map[string]interface{}{}, param, fmt.Sprintf("arguments[%d]", n))
+				if err != nil {
+					return nil, err
+				}
+				in = append(in, v)
+				continue
+			}
+			in = append(in, reflect.Zero(param))
+			continue
+		}
+		v, err := c.convertArg(args[n], param, fmt.Sprintf("arguments[%d]", n))
+		if err != nil {
+			return nil, err
+		}
+		in = append(in, v)
+		n++
+	}
+	return in, nil
+}
+
+// convertArg converts a single JS argument to a Go value for a parameter of
+// type t:
+//
+//   - The Converters of registered plugins are tried first, followed by
+//     the converter registered for t with RegisterConverter.
+//   - *js.Object parameters receive the argument as is, and interface{}
+//     parameters receive it as converted by gopherjs, without any checks
+//     even in strict mode.
+//   - A BigInt is converted by convertBigInt.
+//   - A Blob or File is read into memory for []byte and io.Reader parameters.
+//   - Anything else is converted by convertValue.
+func (c converter) convertArg(arg *js.Object, t reflect.Type, path string) (reflect.Value, error) {
+	if v, ok, err := pluginsConvert(arg, t); ok {
+		return v, err
+	}
+	if v, ok, err := registeredFromJS(arg, t, path); ok {
+		return v, err
+	}
+	if t == jsObjectType {
+		return reflect.ValueOf(arg), nil
+	}
+	if arg == js.Undefined {
+		return reflect.Zero(t), nil
+	}
+	if isEmptyInterface(t) {
+		if v := arg.Interface(); v != nil {
+			return reflect.ValueOf(v), nil
+		}
+		return reflect.Zero(t), nil
+	}
+	if isBigInt(arg) {
+		return c.convertBigInt(arg.Call("toString").String(), t, path)
+	}
+	if (t == bytesType || t == readerType) && isBlob(arg) {
+		data, err := await(ReadFileAsBytes(arg))
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		if t == readerType {
+			return reflect.ValueOf(bytes.NewReader(data.([]byte))), nil
+		}
+		return reflect.ValueOf(data), nil
+	}
+	return c.convertValue(arg.Interface(), t, path)
+}
+
+var bigIntType = reflect.TypeOf(big.Int{})
+
+// convertBigInt converts the decimal representation of a JS BigInt to type t,
+// which may be big.Int, *big.Int, any integer type (if the value fits, or
+// always if not strict) or interface{} (yielding a *big.Int).
+func (c converter) convertBigInt(s string, t reflect.Type, path string) (reflect.Value, error) {
+	n, ok := new(big.Int).SetString(s, 10)
+	if !ok {
+		return reflect.Value{}, &ConversionError{path, t, s}
+	}
+	switch {
+	case t == bigIntType:
+		return reflect.ValueOf(n).Elem(), nil
+	case t == reflect.PtrTo(bigIntType), isEmptyInterface(t):
+		return reflect.ValueOf(n), nil
+	case t.Kind() >= reflect.Int && t.Kind() <= reflect.Int64:
+		v := reflect.New(t).Elem()
+		if n.IsInt64() {
+			v.SetInt(n.Int64())
+		}
+		if c.strict && (!n.IsInt64() || v.Int() != n.Int64()) {
+			return reflect.Value{}, &ConversionError{path, t, n}
+		}
+		return v, nil
+	case t.Kind() >= reflect.Uint && t.Kind() <= reflect.Uintptr:
+		v := reflect.New(t).Elem()
+		if n.IsUint64() {
+			v.SetUint(n.Uint64())
+		}
+		if c.strict && (!n.IsUint64() || v.Uint() != n.Uint64()) {
+			return reflect.Value{}, &ConversionError{path, t, n}
+		}
+		return v, nil
+	case t.Kind() == reflect.Float32 || t.Kind() == reflect.Float64:
+		f, _ := new(big.Float).SetInt(n).Float64()
+		return reflect.ValueOf(f).Convert(t), nil
+	case t.Kind() == reflect.String:
+		return reflect.ValueOf(s).Convert(t), nil
+	}
+	return reflect.Value{}, &ConversionError{path, t, n}
+}
+
+// jsBigInt converts n to a JS BigInt, or to its decimal string if BigInt
+// isn't supported.
+func jsBigInt(n *big.Int) interface{} {
+	if ctor := js.Global.Get("BigInt"); ctor != js.Undefined {
+		return ctor.Invoke(n.String())
+	}
+	return n.String()
+}
+
+// nillable reports whether nil is a valid value of type t.
+func nillable(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Ptr, reflect.Slice, reflect.Map, reflect.Interface, reflect.Func, reflect.Chan:
+		return true
+	}
+	return false
+}
+
+func isEmptyInterface(t reflect.Type) bool {
+	return t.Kind() == reflect.Interface && t.NumMethod() == 0
+}
+
+// convertValue converts v, a JS value as converted by gopherjs (nil, bool,
+// float64, string, []interface{}, map[string]interface{}, ...), to type t.
+// Numbers, strings and booleans convert to any type of the same kind (e.g. a
+// named string type), and arrays and objects convert element by element to
+// slices and maps.  Values that can't be converted are an error in strict
+// mode; otherwise they are coerced if possible (see coerce) or passed as is.
+//
+// Objects also convert to structs (see convertFields) and pointers to
+// them, recursively, with null converting to a nil pointer.
+//
+// Values of a type with a registered converter (see RegisterConverter) are
+// converted by it, at any depth.
+//
+// Numbers beyond Number.MAX_SAFE_INTEGER may already have been rounded by JS,
+// so they are a *PrecisionError for integer types in any mode.
+func (c converter) convertValue(v interface{}, t reflect.Type, path string) (reflect.Value, error) {
+	if v == nil {
+		if c.strictNull && !nillable(t) {
+			return reflect.Value{}, &ConversionError{path, t, nil}
+		}
+		return reflect.Zero(t), nil
+	}
+	if e, ok := lookupEnum(t); ok {
+		rv, err := e.parse(v)
+		if err != nil {
+			return reflect.Value{}, fmt.Errorf("promise: %s: %v", path, err)
+		}
+		return rv, nil
+	}
+	if tc, ok := lookupConverter(t); ok && tc.fromJS != nil {
+		externalize := c.externalize
+		if externalize == nil {
+			externalize = jsValue
+		}
+		return tc.convert(externalize(v), t, path)
+	}
+	rv := reflect.ValueOf(v)
+	if isEmptyInterface(t) || rv.Type() == t {
+		return rv, nil
+	}
+	switch {
+	case isNumber(rv.Kind()) && isNumber(t.Kind()):
+		if f := rv.Convert(reflect.TypeOf(0.0)).Float(); isInteger(t.Kind()) && math.Abs(f) > maxSafeInteger {
+			return reflect.Value{}, &PrecisionError{path, t, f}
+		}
+		converted := rv.Convert(t)
+		if c.strict && !reflect.DeepEqual(converted.Convert(rv.Type()).Interface(), v) {
+			return reflect.Value{}, &ConversionError{path, t, v}
+		}
+		return converted, nil
+	case rv.Kind() == reflect.String && t.Kind() == reflect.String,
+		rv.Kind() == reflect.Bool && t.Kind() == reflect.Bool:
+		return rv.Convert(t), nil
+	case rv.Kind() == reflect.Slice && (t.Kind() == reflect.Slice || t.Kind() == reflect.Array):
+		out := reflect.New(t).Elem()
+		if t.Kind() == reflect.Slice {
+			out = reflect.MakeSlice(t, rv.Len(), rv.Len())
+		} else if rv.Len() > t.Len() && c.strict {
+			return reflect.Value{}, &ConversionError{path, t, v}
+		}
+		for i := 0; i < rv.Len() && i < out.Len(); i++ {
+			elem, err := c.convertValue(rv.Index(i).Interface(), t.Elem(), fmt.Sprintf("%s[%d]", path, i))
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			out.Index(i).Set(elem)
+		}
+		return out, nil
+	case t.Kind() == reflect.Ptr:
+		elem, err := c.convertValue(v, t.Elem(), path)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		ptr := reflect.New(t.Elem())
+		ptr.Elem().Set(elem)
+		return ptr, nil
+	case rv.Kind() == reflect.Map && t.Kind() == reflect.Struct:
+		out := reflect.New(t).Elem()
+		if err := c.convertFields(v.(map[string]interface{}), out, path); err != nil {
+			return reflect.Value{}, err
+		}
+		return out, nil
+	case rv.Kind() == reflect.Map && t.Kind() == reflect.Map && t.Key().Kind() == reflect.String:
+		out := reflect.MakeMapWithSize(t, rv.Len())
+		for _, key := range rv.MapKeys() {
+			elem, err := c.convertValue(rv.MapIndex(key).Interface(), t.Elem(), path+"."+key.String())
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			out.SetMapIndex(key.Convert(t.Key()), elem)
+		}
+		return out, nil
+	}
+	if rv.Type().AssignableTo(t) {
+		return rv, nil
+	}
+	if c.strict {
+		return reflect.Value{}, &ConversionError{path, t, v}
+	}
+	if coerced, ok := coerce(rv, t); ok {
+		return coerced, nil
+	}
+	return rv, nil
+}
+
+// coerce makes a best effort to convert rv to a type t of a different kind for
+// Lenient conversion: numbers and booleans format as strings, numeric strings
+// parse as numbers, and numbers convert to booleans (non-zero is true).
+func coerce(rv reflect.Value, t reflect.Type) (reflect.Value, bool) {
+	switch {
+	case t.Kind() == reflect.String && (isNumber(rv.Kind()) || rv.Kind() == reflect.Bool):
+		return reflect.ValueOf(fmt.Sprint(rv.Interface())).Convert(t), true
+	case isNumber(t.Kind()) && rv.Kind() == reflect.String:
+		// Parse integers exactly, beyond the precision of float64, if they fit.
+		if out, err := (converter{strict: true}).convertBigInt(strings.TrimSpace(rv.String()), t, ""); err == nil {
+			return out, true
+		}
+		f, err := strconv.ParseFloat(strings.TrimSpace(rv.String()), 64)
+		if err != nil {
+			return reflect.Value{}, false
+		}
+		return reflect.ValueOf(f).Convert(t), true
+	case t.Kind() == reflect.Bool && isNumber(rv.Kind()):
+		return reflect.ValueOf(rv.Convert(reflect.TypeOf(0.0)).Float() != 0).Convert(t), true
+	}
+	return reflect.Value{}, false
+}
+
+// convertFields sets the exported fields of the struct out from the properties
+// of obj.  A field is set from the property named by its json tag, if it has
+// one, or else its name according to the naming policy (e.g. "userID" for
+// UserID) or its Go name.
+// The fields of embedded structs are set from obj as well, as if they were
+// fields of out.
+//
+// A missing (or null) property is an error for a field tagged
+// `required:"true"`, and takes the value of the field's default tag, if any:
+//
+//	type SearchOptions struct {
+//		Query string `required:"true"`
+//		Limit int    `default:"20"`
+//	}
+func (c converter) convertFields(obj map[string]interface{}, out reflect.Value, path string) error {
+	t := out.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, tagged := c.fieldName(f)
+		if name == "-" {
+			continue
+		}
+		if f.Anonymous && !tagged {
+			embedded := out.Field(i)
+			if f.Type.Kind() == reflect.Ptr && f.Type.Elem().Kind() == reflect.Struct {
+				if !embedded.CanSet() {
+					continue // can't allocate a pointer to an unexported type
+				}
+				embedded.Set(reflect.New(f.Type.Elem()))
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				if err := c.convertFields(obj, embedded, path); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+		if f.PkgPath != "" {
+			continue // unexported
+		}
+		val, ok := obj[name]
+		if !ok && !tagged {
+			val, ok = obj[f.Name]
+		}
+		if !ok || val == nil {
+			if f.Tag.Get("required") == "true" {
+				return &RequiredError{path + "." + name}
+			}
+			def, hasDefault := f.Tag.Lookup("default")
+			if !hasDefault {
+				if ok && c.strictNull && !nillable(f.Type) {
+					return &ConversionError{path + "." + name, f.Type, nil}
+				}
+				continue
+			}
+			val = defaultValue(def, f.Type)
+		}
+		fv, err := c.convertValue(val, f.Type, path+"."+name)
+		if err != nil {
+			return err
+		}
+		out.Field(i).Set(fv)
+	}
+	return nil
+}
+
+// RequiredError is the rejection reason for a call to a promisified function
+// with an object that lacks a property for a struct field tagged
+// `required:"true"`.
+type RequiredError struct {
+	Path string // the missing property, e.g. "arguments[0].name"
+}
+
+func (e *RequiredError) Error() string {
+	return fmt.Sprintf("promise: %s is required", e.Path)
+}
+
+// defaultValue returns the value of a `default:"..."` tag on a field of type
+// t, as if it came from JS: the tag is taken as JSON (e.g. `default:"10"` or
+// `default:"[1, 2]"`), unless t is a string type or the tag isn't valid JSON,
+// in which case it is a string.
+func defaultValue(tag string, t reflect.Type) interface{} {
+	var v interface{} = tag
+	if t.Kind() != reflect.String {
+		if err := json.Unmarshal([]byte(tag), &v); err != nil {
+			return tag
+		}
+	}
+	return v
+}
+
+// hasRequiredOrDefaults reports whether any field of the struct type t, or of
+// the structs embedded in it, has a required or default tag.
+func hasRequiredOrDefaults(t reflect.Type) bool {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if _, ok := f.Tag.Lookup("default"); ok || f.Tag.Get("required") == "true" {
+			return true
+		}
+		if f.Anonymous && f.Type.Kind() == reflect.Struct && hasRequiredOrDefaults(f.Type) {
+			return true
+		}
+	}
+	return false
+}
+
+// fieldName returns the JS property name for the struct field f, and whether
+// it comes from a json tag.
+func (c converter) fieldName(f reflect.StructField) (string, bool) {
+	if tag := strings.Split(f.Tag.Get("json"), ",")[0]; tag != "" {
+		return tag, true
+	}
+	if c.naming == nil {
+		return jsName(f.Name), false
+	}
+	return c.naming(f.Name), false
+}
+
+func isNumber(k reflect.Kind) bool {
+	return k >= reflect.Int && k <= reflect.Float64
+}
+
+// isBigInt reports whether arg is a JS BigInt.
+func isBigInt(arg *js.Object) bool {
+	if arg == nil || arg == js.Undefined {
+		return false
+	}
+	toString := js.Global.Get("Object").Get("prototype").Get("toString")
+	return toString.Call("call", arg).String() == "[object BigInt]"
+}
+
+// isBlob reports whether arg is a JS Blob (which includes Files).
+func isBlob(arg *js.Object) bool {
+	blob := js.Global.Get("Blob")
+	if arg == nil || arg == js.Undefined || blob == js.Undefined {
+		return false
+	}
+	return blob.Get("prototype").Call("isPrototypeOf", arg).Bool()
+}
+
+// convertResult converts the value that a promisified function resolves with
+// for JS: values with a converter registered with RegisterConverter are
+// converted by it, big.Ints and integers beyond Number.MAX_SAFE_INTEGER become
+// BigInts, io.Readers become ReadableStreams of Uint8Array chunks, and other
+// pointers are dereferenced.  nil values (including nil pointers, slices and
+// maps) become null, or undefined under NilAsUndefined.  Structs become
+// objects with properties named like their fields (see convertFields), and
+// the elements of multiple results, slices, maps and structs are converted
+// individually.
+func (c converter) convertResult(value interface{}) interface{} {
+	if converted, ok := registeredToJS(value); ok {
+		return converted
+	}
+	switch v := value.(type) {
+	case nil:
+		return c.nilResult()
+	case *js.Object:
+		return v
+	case *big.Int:
+		if v == nil {
+			return c.nilResult()
+		}
+		return jsBigInt(v)
+	case big.Int:
+		return jsBigInt(&v)
+	case io.Reader:
+		return readableStream(v)
+	case []interface{}:
+		converted := make([]interface{}, len(v))
+		for i := range v {
+			converted[i] = c.convertResult(v[i])
+		}
+		return converted
+	}
+	if n, ok := unsafeInteger(value); ok {
+		return jsBigInt(n)
+	}
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Ptr:
+		if rv.IsNil() {
+			return c.nilResult()
+		}
+		return c.convertResult(rv.Elem().Interface())
+	case reflect.Struct:
+		if rv.Type() == timeType || rv.NumField() > 0 && rv.Type().Field(0).Type == jsObjectType {
+			return value // gopherjs converts it to a Date, or it wraps a JS object
+		}
+		obj := map[string]interface{}{}
+		c.resultFields(rv, obj)
+		return obj
+	case reflect.Slice, reflect.Array:
+		if rv.Kind() == reflect.Slice && rv.IsNil() {
+			return c.nilResult()
+		}
+		if rv.Type().Elem().Kind() == reflect.Uint8 {
+			return value
+		}
+		converted := make([]interface{}, rv.Len())
+		for i := range converted {
+			converted[i] = c.convertResult(rv.Index(i).Interface())
+		}
+		return converted
+	case reflect.Map:
+		if rv.IsNil() {
+			return c.nilResult()
+		}
+		if rv.Type().Key().Kind() != reflect.String {
+			return value
+		}
+		converted := make(map[string]interface{}, rv.Len())
+		for _, key := range rv.MapKeys() {
+			converted[key.String()] = c.convertResult(rv.MapIndex(key).Interface())
+		}
+		return converted
+	}
+	return value
+}
+
+// nilResult returns the JS value for a nil result.
+func (c converter) nilResult() interface{} {
+	if c.nilUndefined {
+		return js.Undefined
+	}
+	return nil
+}
+
+// resultFields sets the properties of obj from the exported fields of the
+// struct rv, the reverse of convertFields.  The fields of embedded structs are
+// set as if they were fields of rv.
+func (c converter) resultFields(rv reflect.Value, obj map[string]interface{}) {
+	t := rv.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, tagged := c.fieldName(f)
+		if name == "-" {
+			continue
+		}
+		fv := rv.Field(i)
+		if f.Anonymous && !tagged {
+			if fv.Kind() == reflect.Ptr && fv.Type().Elem().Kind() == reflect.Struct {
+				if fv.IsNil() {
+					continue
+				}
+				fv = fv.Elem()
+			}
+			if fv.Kind() == reflect.Struct {
+				c.resultFields(fv, obj)
+				continue
+			}
+		}
+		if f.PkgPath != "" {
+			continue // unexported
+		}
+		obj[name] = c.convertResult(fv.Interface())
+	}
+}
+
+// streamChunkSize is the maximum size of the chunks read by readableStream.
+const streamChunkSize = 64 << 10
+
+// readableStream returns a JS ReadableStream that reads r one chunk at a time
+// as the stream is consumed.  The stream is closed at EOF or errored with the
+// message of any other error, and r is closed afterwards (or if the stream is
+// canceled) if it is an io.Closer.
+func readableStream(r io.Reader) *js.Object {
+	source := js.Global.Get("Object").New()
+	source.Set("pull", func(controller *js.Object) *js.Object {
+		p := newPromise()
+		atomic.AddInt64(&counters.Goroutines, 1)
+		go func() {
+			chunk := make([]byte, streamChunkSize)
+			n, err := r.Read(chunk)
+			if n > 0 {
+				controller.Call("enqueue", chunk[:n])
+			}
+			if err == io.EOF {
+				closeReader(r)
+				controller.Call("close")
+			} else if err != nil {
+				closeReader(r)
+				controller.Call("error", js.Global.Get("Error").New(err.Error()))
+			}
+			p.Resolve(nil)
+		}()
+		return p.Js()
+	})
+	source.Set("cancel", func() { closeReader(r) })
+	return js.Global.Get("ReadableStream").New(source)
+}
+
+func closeReader(r io.Reader) {
+	if c, ok := r.(io.Closer); ok {
+		c.Close()
+	}
+}
@@ -0,0 +1,311 @@
+package promise
+
+import (
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/gopherjs/gopherjs/js"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConvertArgsMissing(t *testing.T) {
+	var p Promise
+	fn := func(name string, pr *Progress, rest ...int) {}
+	in, err := converter{}.convertArgs(reflect.TypeOf(fn), &p, nil)
+	assert.NoError(t, err)
+	if assert.Equal(t, 2, len(in)) {
+		assert.Equal(t, "", in[0].Interface())
+		assert.Equal(t, &Progress{&p}, in[1].Interface())
+	}
+}
+
+func TestConvertArgsArity(t *testing.T) {
+	var p Promise
+	fn := reflect.TypeOf(func(a, b string, pr *Progress) {})
+	variadic := reflect.TypeOf(func(a string, pr *Progress, rest ...int) {})
+	args := func(n int) []*js.Object { return make([]*js.Object, n) }
+	strict := converter{strict: true}
+
+	_, err := strict.convertArgs(fn, &p, args(1))
+	assert.Equal(t, &ArityError{2, 2, 1}, err)
+	assert.EqualError(t, err, "promise: expected 2 arguments, got 1")
+	_, err = strict.convertArgs(fn, &p, args(3))
+	assert.Equal(t, &ArityError{2, 2, 3}, err)
+	_, err = strict.convertArgs(variadic, &p, args(0))
+	assert.EqualError(t, err, "promise: expected at least 1 arguments, got 0")
+}
+
+type userID string
+
+func TestConvertValue(t *testing.T) {
+	convert := func(c converter, v interface{}, sample interface{}) (interface{}, error) {
+		rv, err := c.convertValue(v, reflect.TypeOf(sample), "arguments[0]")
+		if err != nil {
+			return nil, err
+		}
+		return rv.Interface(), nil
+	}
+	lenient, strict := converter{}, converter{strict: true}
+
+	for _, c := range []converter{lenient, strict} {
+		v, err := convert(c, 3.0, int(0))
+		assert.NoError(t, err)
+		assert.Equal(t, 3, v)
+		v, _ = convert(c, "u1", userID(""))
+		assert.Equal(t, userID("u1"), v)
+		v, _ = convert(c, []interface{}{1.0, 2.0}, []int8(nil))
+		assert.Equal(t, []int8{1, 2}, v)
+		v, _ = convert(c, []interface{}{1.0, 2.0}, [2]uint{})
+		assert.Equal(t, [2]uint{1, 2}, v)
+		v, _ = convert(c, map[string]interface{}{"a": []interface{}{"x"}}, map[userID][]userID(nil))
+		assert.Equal(t, map[userID][]userID{"a": {"x"}}, v)
+		v, _ = convert(c, nil, (*int)(nil))
+		assert.Equal(t, (*int)(nil), v)
+		v, _ = convert(c, true, false)
+		assert.Equal(t, true, v)
+		v, _ = convert(c, map[string]interface{}{"a": 1.0}, map[userID]interface{}(nil))
+		assert.Equal(t, map[userID]interface{}{"a": 1.0}, v)
+	}
+
+	v, err := convert(lenient, 1.5, int(0))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, v)
+	v, err = convert(lenient, "x", 0)
+	assert.NoError(t, err)
+	assert.Equal(t, "x", v)
+	v, _ = convert(lenient, " 42 ", int(0))
+	assert.Equal(t, 42, v)
+	v, _ = convert(lenient, 2.5, userID(""))
+	assert.Equal(t, userID("2.5"), v)
+	v, _ = convert(lenient, true, "")
+	assert.Equal(t, "true", v)
+	v, _ = convert(lenient, 0.0, false)
+	assert.Equal(t, false, v)
+
+	_, err = convert(strict, 1.5, int(0))
+	assert.Equal(t, &ConversionError{"arguments[0]", reflect.TypeOf(0), 1.5}, err)
+	_, err = convert(strict, "42", int(0))
+	assert.Error(t, err)
+	_, err = convert(lenient, float64(1<<60), int64(0))
+	assert.Equal(t, &PrecisionError{"arguments[0]", reflect.TypeOf(int64(0)), 1 << 60}, err)
+	_, err = convert(strict, -float64(1<<60), int(0))
+	assert.EqualError(t, err, "promise: arguments[0]: -1.152921504606847e+18 exceeds the safe integer range for int, pass it as a BigInt")
+	v, _ = convert(lenient, float64(1<<60), 0.0)
+	assert.Equal(t, float64(1<<60), v)
+	v, _ = convert(lenient, "9007199254740993", uint64(0))
+	assert.Equal(t, uint64(9007199254740993), v)
+	_, err = convert(strict, 300.0, int8(0))
+	assert.Error(t, err)
+	_, err = convert(strict, []interface{}{"a", 1.0}, []string(nil))
+	assert.EqualError(t, err, "promise: arguments[0][1]: cannot convert float64 to string")
+	_, err = convert(strict, []interface{}{1.0, 2.0, 3.0}, [2]int{})
+	assert.Error(t, err)
+}
+
+type address struct {
+	City string
+	Zip  string `json:"postcode"`
+}
+
+type audit struct {
+	CreatedBy string
+}
+
+// Geo is exported so that it can be embedded by pointer.
+type Geo struct {
+	Lat float64
+}
+
+type account struct {
+	audit
+	*Geo
+	UserID   userID
+	Home     *address
+	Work     *address
+	Previous []address
+	Ignored  string `json:"-"`
+	internal string
+}
+
+func TestConvertStruct(t *testing.T) {
+	payload := map[string]interface{}{
+		"createdBy": "admin",
+		"lat":       59.9,
+		"userID":    "u1",
+		"home":      map[string]interface{}{"city": "Bergen", "postcode": "5003"},
+		"work":      nil,
+		"previous":  []interface{}{map[string]interface{}{"city": "Paris"}},
+		"Ignored":   "x",
+		"internal":  "x",
+	}
+	for _, c := range []converter{{}, {strict: true}} {
+		v, err := c.convertValue(payload, reflect.TypeOf(&account{}), "arguments[0]")
+		assert.NoError(t, err)
+		assert.Equal(t, &account{
+			audit:    audit{"admin"},
+			Geo:      &Geo{59.9},
+			UserID:   "u1",
+			Home:     &address{"Bergen", "5003"},
+			Previous: []address{{City: "Paris"}},
+		}, v.Interface())
+	}
+
+	_, err := converter{strict: true}.convertValue(
+		map[string]interface{}{"previous": []interface{}{map[string]interface{}{"postcode": 5003.0}}},
+		reflect.TypeOf(account{}), "arguments[0]")
+	assert.EqualError(t, err, "promise: arguments[0].previous[0].postcode: cannot convert float64 to string")
+}
+
+func TestConvertBigInt(t *testing.T) {
+	huge, _ := new(big.Int).SetString("123456789012345678901234567890", 10)
+	convert := func(c converter, s string, typ reflect.Type) (interface{}, error) {
+		v, err := c.convertBigInt(s, typ, "arguments[0]")
+		if err != nil {
+			return nil, err
+		}
+		return v.Interface(), nil
+	}
+	strict := converter{strict: true}
+
+	v, err := convert(strict, huge.String(), reflect.TypeOf(huge))
+	assert.NoError(t, err)
+	assert.Equal(t, huge, v)
+	v, _ = convert(strict, "42", reflect.TypeOf(big.Int{}))
+	assert.Equal(t, *big.NewInt(42), v)
+	v, _ = convert(strict, "-42", reflect.TypeOf(int64(0)))
+	assert.Equal(t, int64(-42), v)
+	v, _ = convert(strict, "42", reflect.TypeOf(uint8(0)))
+	assert.Equal(t, uint8(42), v)
+	v, _ = convert(strict, "42", reflect.TypeOf(userID("")))
+	assert.Equal(t, userID("42"), v)
+
+	_, err = convert(strict, huge.String(), reflect.TypeOf(int64(0)))
+	assert.Error(t, err)
+	_, err = convert(strict, "-1", reflect.TypeOf(uint(0)))
+	assert.Error(t, err)
+	_, err = convert(strict, "300", reflect.TypeOf(int8(0)))
+	assert.Error(t, err)
+	_, err = convert(converter{}, "300", reflect.TypeOf(int8(0)))
+	assert.NoError(t, err)
+
+	assert.Equal(t, "bigint | null", tsType(reflect.TypeOf(huge), nil))
+}
+
+func TestUnsafeInteger(t *testing.T) {
+	n, ok := unsafeInteger(int64(1 << 60))
+	assert.True(t, ok)
+	assert.Equal(t, big.NewInt(1<<60), n)
+	n, ok = unsafeInteger(uint64(1<<64 - 1))
+	assert.True(t, ok)
+	assert.Equal(t, "18446744073709551615", n.String())
+	_, ok = unsafeInteger(int64(-maxSafeInteger))
+	assert.False(t, ok)
+	_, ok = unsafeInteger(float64(1 << 60))
+	assert.False(t, ok)
+	assert.Equal(t, int64(42), converter{}.convertResult(int64(42)))
+}
+
+func TestConvertStructResult(t *testing.T) {
+	acct := &account{
+		audit:    audit{"admin"},
+		UserID:   "u1",
+		Home:     &address{"Oslo", "0150"},
+		Previous: []address{{City: "Bergen"}},
+		Ignored:  "x",
+		internal: "y",
+	}
+	assert.Equal(t, map[string]interface{}{
+		"createdBy": "admin",
+		"userID":    userID("u1"),
+		"home":      map[string]interface{}{"city": "Oslo", "postcode": "0150"},
+		"work":      nil,
+		"previous":  []interface{}{map[string]interface{}{"city": "Bergen", "postcode": ""}},
+	}, converter{}.convertResult(acct))
+
+	assert.Equal(t, map[string]interface{}{"City": "Oslo", "postcode": ""},
+		converter{naming: GoNames}.convertResult(address{City: "Oslo"}))
+	assert.Equal(t, map[string]interface{}{"a": []interface{}{1, 2}},
+		converter{}.convertResult(map[string][]int{"a": {1, 2}}))
+	assert.Equal(t, []byte("raw"), converter{}.convertResult([]byte("raw")))
+}
+
+type searchOptions struct {
+	Query  string    `required:"true"`
+	Limit  int       `default:"20"`
+	Order  string    `default:"asc"`
+	Fields []string  `default:"[\"id\", \"name\"]"`
+	Exact  bool      `json:"exact" default:"true"`
+	Owner  *userID   `default:"me"`
+	Extra  sortOrder // no default
+}
+
+func TestConvertDefaults(t *testing.T) {
+	me := userID("me")
+	v, err := converter{}.convertValue(map[string]interface{}{"query": "go", "order": "desc", "exact": nil},
+		reflect.TypeOf(searchOptions{}), "arguments[0]")
+	assert.NoError(t, err)
+	assert.Equal(t, searchOptions{
+		Query:  "go",
+		Limit:  20,
+		Order:  "desc",
+		Fields: []string{"id", "name"},
+		Exact:  true,
+		Owner:  &me,
+	}, v.Interface())
+
+	_, err = converter{strict: true}.convertValue(map[string]interface{}{"limit": 5.0},
+		reflect.TypeOf(searchOptions{}), "arguments[0]")
+	assert.Equal(t, &RequiredError{"arguments[0].query"}, err)
+	assert.EqualError(t, err, "promise: arguments[0].query is required")
+
+	var p Promise
+	_, err = converter{}.convertArgs(reflect.TypeOf(func(searchOptions) {}), &p, nil)
+	assert.Equal(t, &RequiredError{"arguments[0].query"}, err)
+	in, err := converter{}.convertArgs(reflect.TypeOf(func(address) {}), &p, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, address{}, in[0].Interface())
+}
+
+func TestNullArgs(t *testing.T) {
+	type opts struct {
+		Name  string
+		Limit *int
+	}
+	c := converter{strictNull: true}
+	_, err := c.convertValue(nil, reflect.TypeOf(0), "arguments[0]")
+	assert.Equal(t, &ConversionError{"arguments[0]", reflect.TypeOf(0), nil}, err)
+	for _, v := range []interface{}{[]int{}, map[string]int{}, (*int)(nil)} {
+		typ := reflect.TypeOf(v)
+		rv, err := c.convertValue(nil, typ, "arguments[0]")
+		assert.NoError(t, err)
+		assert.True(t, rv.IsNil(), "%v", typ)
+	}
+
+	v, err := c.convertValue(map[string]interface{}{"limit": nil}, reflect.TypeOf(opts{}), "arguments[0]")
+	assert.NoError(t, err)
+	assert.Equal(t, opts{}, v.Interface())
+	_, err = c.convertValue(map[string]interface{}{"name": nil}, reflect.TypeOf(opts{}), "arguments[0]")
+	assert.Equal(t, &ConversionError{"arguments[0].name", reflect.TypeOf(""), nil}, err)
+	_, err = c.convertValue([]interface{}{"a", nil}, reflect.TypeOf([]string{}), "arguments[0]")
+	assert.Error(t, err)
+
+	// By default, null is the zero value.
+	v, err = converter{}.convertValue([]interface{}{"a", nil}, reflect.TypeOf([]string{}), "arguments[0]")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a", ""}, v.Interface())
+}
+
+func TestNilResults(t *testing.T) {
+	var nilMap map[string]int
+	for _, v := range []interface{}{nil, (*int)(nil), []int(nil), nilMap} {
+		assert.Nil(t, converter{}.convertResult(v))
+	}
+	assert.Equal(t, []byte{}, converter{}.convertResult([]byte{}))
+	assert.True(t, js.Undefined == converter{nilUndefined: true}.convertResult((*int)(nil)))
+	assert.Equal(t, map[string]interface{}{"city": js.Undefined, "zip": ""},
+		converter{nilUndefined: true}.convertResult(struct {
+			City *string
+			Zip  string
+		}{}))
+}
@@ -0,0 +1,86 @@
+package promise
+
+import (
+	"fmt"
+	"sync/atomic"
+)
+
+// RejectionError is the error used to represent a rejection reason that is not
+// itself an error, for example when a promise rejected with a string is
+// awaited in a Coroutine.
+type RejectionError struct {
+	Reason interface{}
+}
+
+func (e RejectionError) Error() string { return fmt.Sprint(e.Reason) }
+
+// asError converts a rejection reason into an error.
+func asError(reason interface{}) error {
+	if err, ok := reason.(error); ok {
+		return err
+	}
+	return RejectionError{reason}
+}
+
+// reasonOf is the inverse of asError.
+func reasonOf(err error) interface{} {
+	if re, ok := err.(RejectionError); ok {
+		return re.Reason
+	}
+	return err
+}
+
+// Coroutine runs body in a new goroutine and returns a promise for its result,
+// allowing promise-based code to be written linearly instead of as nested
+// Then callbacks:
+//
+//	promise.Coroutine(func(await func(*promise.Promise) (interface{}, error)) (interface{}, error) {
+//		user, err := await(fetchUser(id))
+//		if err != nil {
+//			return nil, err
+//		}
+//		return await(fetchAvatar(user))
+//	})
+//
+// The await function blocks until the provided promise settles and returns
+// either its fulfilled value or its rejection reason as an error.  Reasons
+// that are not errors are wrapped in a RejectionError.
+//
+// The returned promise is resolved with the value returned by body, or
+// rejected with the returned error or the panic value if body panics.  A
+// RejectionError returned by body is unwrapped, so returning the error from
+// await rejects with the same reason as the awaited promise.
+func Coroutine(body func(await func(*Promise) (interface{}, error)) (interface{}, error)) *Promise {
+	p := newPromise()
+	atomic.AddInt64(&counters.Goroutines, 1)
+	go func() {
+		defer func() {
+			if x := recover(); x != nil {
+				p.Reject(x)
+			}
+		}()
+		if value, err := body(await); err != nil {
+			p.Reject(reasonOf(err))
+		} else {
+			p.Resolve(value)
+		}
+	}()
+	return p
+}
+
+// await blocks until p settles and returns its value or rejection reason.
+func await(p *Promise) (interface{}, error) {
+	if p == nil {
+		return nil, errNilPromise
+	}
+	type result struct {
+		value interface{}
+		err   error
+	}
+	done := make(chan result, 1)
+	p.Then(
+		func(val interface{}) interface{} { done <- result{val, nil}; return val },
+		func(err interface{}) interface{} { done <- result{nil, asError(err)}; return err })
+	r := <-done
+	return r.value, r.err
+}
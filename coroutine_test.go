@@ -0,0 +1,50 @@
+package promise
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCoroutine(t *testing.T) {
+	defer time.AfterFunc(time.Second, t.FailNow).Stop() // limit test to 1 second running time.
+
+	double := Method(func(x int) int { return 2 * x })
+	val, ok := settled(Coroutine(func(await func(*Promise) (interface{}, error)) (interface{}, error) {
+		a, err := await(double(1))
+		if err != nil {
+			return nil, err
+		}
+		return await(double(a.(int) + 1))
+	}))
+	assert.True(t, ok)
+	assert.Equal(t, 6, val)
+
+	// Non-error rejections are wrapped for await and unwrapped again when
+	// returned from the coroutine.
+	var failed Promise
+	failed.Reject("nope")
+	val, ok = settled(Coroutine(func(await func(*Promise) (interface{}, error)) (interface{}, error) {
+		_, err := await(&failed)
+		assert.Equal(t, RejectionError{"nope"}, err)
+		assert.EqualError(t, err, "nope")
+		return nil, err
+	}))
+	assert.False(t, ok)
+	assert.Equal(t, "nope", val)
+
+	failure := errors.New("failed")
+	val, ok = settled(Coroutine(func(await func(*Promise) (interface{}, error)) (interface{}, error) {
+		return nil, failure
+	}))
+	assert.False(t, ok)
+	assert.Equal(t, failure, val)
+
+	val, ok = settled(Coroutine(func(await func(*Promise) (interface{}, error)) (interface{}, error) {
+		panic("oops")
+	}))
+	assert.False(t, ok)
+	assert.Equal(t, "oops", val)
+}
@@ -0,0 +1,126 @@
+package promise
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrDeadlineExceeded is the rejection reason for promises attached to a
+// Deadline that passed before they settled.
+var ErrDeadlineExceeded = errors.New("promise: deadline exceeded")
+
+// Deadline is a single time budget shared by several related promises, for
+// example all of the steps of a multi-step operation.  Unlike per-call
+// timeouts, the time spent in earlier steps counts against the later ones.
+// Promises are attached to it with Attach, and calls of promisified functions
+// with CallOptions.Deadline.
+type Deadline struct {
+	at time.Time
+
+	mu      sync.Mutex
+	timer   *time.Timer    // runs only while there are watchers
+	nextID  int            // of the next watcher
+	waiting map[int]func() // called when the deadline passes, see watch
+}
+
+// NewDeadline returns a deadline that passes after d.
+func NewDeadline(d time.Duration) *Deadline {
+	return DeadlineAt(time.Now().Add(d))
+}
+
+// DeadlineAt returns a deadline that passes at t.
+func DeadlineAt(t time.Time) *Deadline {
+	return &Deadline{at: t}
+}
+
+// Time returns the time at which the deadline passes.
+func (d *Deadline) Time() time.Time { return d.at }
+
+// Remaining returns the time left before the deadline passes, or 0 if it has
+// already passed.
+func (d *Deadline) Remaining() time.Duration {
+	if r := time.Until(d.at); r > 0 {
+		return r
+	}
+	return 0
+}
+
+// Expired returns whether the deadline has passed.
+func (d *Deadline) Expired() bool { return d.Remaining() == 0 }
+
+// Attach returns a promise that settles the same way as p, unless the deadline
+// passes first, in which case it is rejected with ErrDeadlineExceeded and p is
+// canceled with ErrDeadlineExceeded as the reason, which stops the work
+// behind it (see Cancel).  Any number of promises may be attached to the same
+// deadline, including the promises of combinators such as All, which cancel
+// their pending members in turn.
+func (d *Deadline) Attach(p *Promise) *Promise {
+	result := newPromise()
+	graphEdge(p, result, EdgeMember)
+	var done int32
+	first := func() bool { return atomic.CompareAndSwapInt32(&done, 0, 1) }
+	p.onSettle(d.watch(func() {
+		if first() {
+			result.Reject(ErrDeadlineExceeded)
+		}
+		p.Cancel(ErrDeadlineExceeded)
+	}))
+	p.Then(
+		func(val interface{}) interface{} {
+			if first() {
+				result.Resolve(val)
+			}
+			return val
+		},
+		func(err interface{}) interface{} {
+			if first() {
+				result.Reject(err)
+			}
+			return err
+		})
+	return result
+}
+
+// watch calls fn when the deadline passes, or right away if it has, unless
+// unwatch is called first.  The timer of d only runs while there are
+// watchers, so watchers that are removed don't accumulate.
+func (d *Deadline) watch(fn func()) (unwatch func()) {
+	d.mu.Lock()
+	if d.Expired() {
+		d.mu.Unlock()
+		fn()
+		return func() {}
+	}
+	d.nextID++
+	id := d.nextID
+	if d.waiting == nil {
+		d.waiting = map[int]func(){}
+	}
+	d.waiting[id] = fn
+	if d.timer == nil {
+		d.timer = time.AfterFunc(d.Remaining(), d.expire)
+	}
+	d.mu.Unlock()
+	return func() {
+		d.mu.Lock()
+		defer d.mu.Unlock()
+		delete(d.waiting, id)
+		if len(d.waiting) == 0 && d.timer != nil {
+			d.timer.Stop()
+			d.timer = nil
+		}
+	}
+}
+
+// expire calls the watchers of d once it has passed.
+func (d *Deadline) expire() {
+	d.mu.Lock()
+	waiting := d.waiting
+	d.waiting, d.timer = nil, nil
+	d.mu.Unlock()
+	for _, fn := range waiting {
+		fn()
+	}
+}
@@ -0,0 +1,74 @@
+package promise
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeadline(t *testing.T) {
+	defer time.AfterFunc(time.Second, t.FailNow).Stop() // limit test to 1 second running time.
+
+	d := NewDeadline(30 * time.Millisecond)
+	assert.False(t, d.Expired())
+	assert.True(t, d.Remaining() > 0 && d.Remaining() <= 30*time.Millisecond)
+
+	var g gauge
+	val, ok := settled(d.Attach(g.task("fast", false)()))
+	assert.True(t, ok)
+	assert.Equal(t, "fast", val)
+
+	var never Promise
+	slow1, slow2 := d.Attach(&never), d.Attach(never.Then(nil, nil))
+	val, ok = settled(slow1)
+	assert.False(t, ok)
+	assert.Equal(t, ErrDeadlineExceeded, val)
+	val, ok = settled(slow2)
+	assert.False(t, ok)
+	assert.Equal(t, ErrDeadlineExceeded, val)
+	assert.True(t, d.Expired())
+	assert.Equal(t, time.Duration(0), d.Remaining())
+
+	// Attaching after the deadline rejects right away.
+	_, ok = settled(d.Attach(&never))
+	assert.False(t, ok)
+}
+
+func TestDeadlineCancels(t *testing.T) {
+	defer time.AfterFunc(time.Second, t.FailNow).Stop() // limit test to 1 second running time.
+
+	stopped := make(chan interface{}, 3)
+	stop := func(reason interface{}) { stopped <- reason }
+	d := NewDeadline(20 * time.Millisecond)
+
+	// The attached promise is canceled, stopping its producer.
+	work := NewCancelable(stop)
+	val, ok := settled(d.Attach(work.Promise))
+	assert.False(t, ok)
+	assert.Equal(t, ErrDeadlineExceeded, val)
+	assert.Equal(t, ErrDeadlineExceeded, <-stopped)
+	assert.Equal(t, &CancelError{ErrDeadlineExceeded}, work.Reason())
+
+	// Combinators cancel their members.
+	d = NewDeadline(20 * time.Millisecond)
+	a, b := NewCancelable(stop), NewCancelable(stop)
+	val, _ = settled(d.Attach(All(a.Promise, b.Promise)))
+	assert.Equal(t, ErrDeadlineExceeded, val)
+	assert.Equal(t, ErrDeadlineExceeded, <-stopped)
+	assert.Equal(t, ErrDeadlineExceeded, <-stopped)
+}
+
+func TestDeadlineReleasesWatchers(t *testing.T) {
+	defer time.AfterFunc(time.Second, t.FailNow).Stop() // limit test to 1 second running time.
+
+	d := NewDeadline(time.Hour)
+	for i := 0; i < 3; i++ {
+		val, _ := settled(d.Attach(Resolved(i)))
+		assert.Equal(t, i, val)
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	assert.Empty(t, d.waiting)
+	assert.Nil(t, d.timer)
+}
@@ -0,0 +1,50 @@
+package promise
+
+import "errors"
+
+// ErrReadOnly is the panic value of Resolve and Reject when they are called on
+// the promise of a Deferred, which only the Deferred may settle.
+var ErrReadOnly = errors.New("promise: the promise of a Deferred can only be settled through the Deferred")
+
+// Deferred separates settling a promise from consuming it: the code that
+// produces the result keeps the Deferred, and hands out its Promise, which
+// can't be settled by its holders.
+//
+//	type Loader struct{ ready *promise.Deferred }
+//
+//	func (l *Loader) Ready() *promise.Promise { return l.ready.Promise() }
+//
+//	func (l *Loader) load() {
+//		if err := l.fetch(); err != nil {
+//			l.ready.Reject(err)
+//			return
+//		}
+//		l.ready.Resolve(l)
+//	}
+type Deferred struct {
+	p *Promise
+}
+
+// NewDeferred returns a Deferred whose promise is pending.
+func NewDeferred() *Deferred {
+	p := newPromise()
+	p.readOnly = true
+	return &Deferred{p}
+}
+
+// Promise returns the promise settled by d.  Its Resolve and Reject methods
+// panic with ErrReadOnly, but it can be canceled by its consumers as usual.
+func (d *Deferred) Promise() *Promise { return d.p }
+
+// Resolve resolves the promise with value, as Promise.Resolve does.
+func (d *Deferred) Resolve(value interface{}) { d.p.resolve(value) }
+
+// Reject rejects the promise with reason, as Promise.Reject does.
+func (d *Deferred) Reject(reason interface{}) { d.p.rejectReason(reason) }
+
+// checkWritable panics with ErrReadOnly if p belongs to a Deferred.
+func (p *Promise) checkWritable() {
+	if p.readOnly {
+		panic(ErrReadOnly)
+	}
+}
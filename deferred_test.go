@@ -0,0 +1,41 @@
+package promise
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeferred(t *testing.T) {
+	defer time.AfterFunc(time.Second, t.FailNow).Stop() // limit test to 1 second running time.
+
+	d := NewDeferred()
+	p := d.Promise()
+	assert.PanicsWithValue(t, ErrReadOnly, func() { p.Resolve("hijacked") })
+	assert.PanicsWithValue(t, ErrReadOnly, func() { p.Reject("hijacked") })
+	assert.True(t, p.IsPending())
+
+	// Derived promises are not read-only, and adoption still works.
+	child := p.Then(func(val interface{}) interface{} { return val.(string) + "!" }, nil)
+	other := newPromise()
+	d.Resolve(other)
+	other.Resolve("done")
+	val, ok := settled(child)
+	assert.True(t, ok)
+	assert.Equal(t, "done!", val)
+
+	d = NewDeferred()
+	d.Reject(errors.New("failed"))
+	val, ok = settled(d.Promise())
+	assert.False(t, ok)
+	assert.EqualError(t, val.(error), "failed")
+
+	// Consumers can still cancel it.
+	d = NewDeferred()
+	d.Promise().Cancel("unmounted")
+	assert.NotPanics(t, func() { d.Resolve("late") })
+	val, _ = settled(d.Promise())
+	assert.Equal(t, &CancelError{"unmounted"}, val)
+}
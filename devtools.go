@@ -0,0 +1,176 @@
+package promise
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/gopherjs/gopherjs/js"
+)
+
+var devtools struct {
+	sync.Mutex
+	formatter *js.Object
+}
+
+// EnableDevtoolsFormatter registers (or unregisters) a custom formatter for
+// Chrome DevTools, so that logging a Promise, or the JS object returned by
+// Js, in the console shows its state, label and a preview of its value, and
+// expands to its metadata, its parent and the promises that depend on it.
+// Custom formatters must also be enabled in the DevTools settings ("Enable
+// custom formatters").
+//
+// The dependents are the callbacks still waiting for the promise, or, while
+// EnableGraph is recording, every promise derived from it.  The formatter is
+// meant for debugging and is off by default.
+func EnableDevtoolsFormatter(enabled bool) {
+	devtools.Lock()
+	defer devtools.Unlock()
+	formatters := js.Global.Get("devtoolsFormatters")
+	if enabled && devtools.formatter == nil {
+		if formatters == js.Undefined {
+			formatters = js.Global.Get("Array").New()
+			js.Global.Set("devtoolsFormatters", formatters)
+		}
+		devtools.formatter = newDevtoolsFormatter()
+		formatters.Call("push", devtools.formatter)
+	} else if !enabled && devtools.formatter != nil {
+		if formatters != js.Undefined {
+			if i := formatters.Call("indexOf", devtools.formatter).Int(); i >= 0 {
+				formatters.Call("splice", i, 1)
+			}
+		}
+		devtools.formatter = nil
+	}
+}
+
+// newDevtoolsFormatter returns a formatter for the DevTools custom formatter
+// protocol, whose methods return JsonML for the objects that it formats and
+// null otherwise.
+func newDevtoolsFormatter() *js.Object {
+	f := js.Global.Get("Object").New()
+	f.Set("header", func(o *js.Object) interface{} {
+		if p := formattedPromise(o); p != nil {
+			return devtoolsHeader(p)
+		}
+		return nil
+	})
+	f.Set("hasBody", func(o *js.Object) bool { return formattedPromise(o) != nil })
+	f.Set("body", func(o *js.Object) interface{} {
+		if p := formattedPromise(o); p != nil {
+			return devtoolsBody(p)
+		}
+		return nil
+	})
+	return f
+}
+
+// formattedPromise returns the promise wrapped by o, or nil if o doesn't wrap
+// one.
+func formattedPromise(o *js.Object) (p *Promise) {
+	if !isObject(o) || o.Get("__internal_object__") == js.Undefined {
+		return nil
+	}
+	defer func() {
+		if recover() != nil {
+			p = nil
+		}
+	}()
+	switch v := o.Interface().(type) {
+	case *Promise:
+		return v
+	case *CancelablePromise:
+		return v.Promise
+	}
+	return nil
+}
+
+// devtoolsStateStyles color the state of promises in the console.
+var devtoolsStateStyles = map[State]string{
+	StatePending:   "color: #b58900",
+	StateFulfilled: "color: #2aa198",
+	StateRejected:  "color: #dc322f",
+}
+
+// devtoolsHeader returns the JsonML of the one-line summary of p, e.g.
+// Promise "load user" <fulfilled>: {Ann 42}.
+func devtoolsHeader(p *Promise) []interface{} {
+	s, value := p.current()
+	header := []interface{}{"span", map[string]interface{}{}, "Promise"}
+	if label := p.Label(); label != "" {
+		header = append(header, fmt.Sprintf(" %q", label))
+	}
+	header = append(header, " ", []interface{}{"span",
+		map[string]interface{}{"style": devtoolsStateStyles[s]},
+		"<" + s.String() + ">"})
+	if s != StatePending {
+		header = append(header, ": ", devtoolsPreview(value))
+	}
+	return header
+}
+
+// devtoolsBody returns the JsonML of the expanded view of p.
+func devtoolsBody(p *Promise) []interface{} {
+	body := []interface{}{"ol", map[string]interface{}{
+		"style": "list-style-type: none; padding-left: 12px; margin: 0"}}
+	item := func(name string, value interface{}) {
+		body = append(body, []interface{}{"li", map[string]interface{}{},
+			[]interface{}{"span", map[string]interface{}{"style": "color: #881391"}, name},
+			": ", value})
+	}
+	switch s, value := p.current(); s {
+	case StateFulfilled:
+		item("value", devtoolsPreview(value))
+	case StateRejected:
+		item("reason", devtoolsPreview(value))
+	}
+	for _, line := range metaLines(p.AllMeta()) {
+		item("meta", line)
+	}
+	if p.parent != nil {
+		item("parent", devtoolsRef(p.parent))
+	}
+	for _, child := range dependents(p) {
+		item("child", devtoolsRef(child))
+	}
+	return body
+}
+
+// devtoolsRef returns JsonML that DevTools renders as p, formatted again.
+func devtoolsRef(p *Promise) []interface{} {
+	return []interface{}{"object", map[string]interface{}{"object": js.MakeWrapper(p)}}
+}
+
+// devtoolsPreview returns the JsonML of a short preview of v.  JS values are
+// rendered by DevTools itself.
+func devtoolsPreview(v interface{}) interface{} {
+	if o, ok := v.(*js.Object); ok {
+		return []interface{}{"object", map[string]interface{}{"object": o}}
+	}
+	preview := fmt.Sprintf("%v", v)
+	if len(preview) > 60 {
+		preview = preview[:57] + "..."
+	}
+	return preview
+}
+
+// dependents returns the promises that depend on p: the children recorded in
+// the graph if recording is enabled, and the children whose callbacks haven't
+// run yet otherwise.
+func dependents(p *Promise) []*Promise {
+	graph.Lock()
+	defer graph.Unlock()
+	if graph.enabled == 0 {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		return append([]*Promise(nil), p.next...)
+	}
+	var children []*Promise
+	if id, ok := graph.ids[p]; ok {
+		for _, e := range graph.edges {
+			if e.From == id {
+				children = append(children, graph.nodes[e.To-1])
+			}
+		}
+	}
+	return children
+}
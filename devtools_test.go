@@ -0,0 +1,44 @@
+package promise
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDevtoolsHeader(t *testing.T) {
+	p := newPromise()
+	p.SetLabel("load user")
+	assert.Equal(t, []interface{}{"span", map[string]interface{}{}, "Promise", ` "load user"`, " ",
+		[]interface{}{"span", map[string]interface{}{"style": "color: #b58900"}, "<pending>"}}, devtoolsHeader(p))
+
+	p = newPromise()
+	p.Resolve(strings.Repeat("x", 100))
+	header := devtoolsHeader(p)
+	assert.Equal(t, []interface{}{"span", map[string]interface{}{"style": "color: #2aa198"}, "<fulfilled>"}, header[4])
+	assert.Equal(t, strings.Repeat("x", 57)+"...", header[6])
+}
+
+func TestDevtoolsBody(t *testing.T) {
+	p := newPromise().WithMeta("user", 42)
+	p.Reject(errors.New("not found"))
+	style := map[string]interface{}{"style": "color: #881391"}
+	assert.Equal(t, []interface{}{"ol", map[string]interface{}{"style": "list-style-type: none; padding-left: 12px; margin: 0"},
+		[]interface{}{"li", map[string]interface{}{}, []interface{}{"span", style, "reason"}, ": ", "not found"},
+		[]interface{}{"li", map[string]interface{}{}, []interface{}{"span", style, "meta"}, ": ", "user=42"},
+	}, devtoolsBody(p))
+}
+
+func TestDependents(t *testing.T) {
+	p := newPromise()
+	a, b := p.Then(nil, nil), p.Then(nil, nil)
+	assert.Equal(t, []*Promise{a, b}, dependents(p))
+
+	EnableGraph(true)
+	defer EnableGraph(false)
+	c := a.Then(nil, nil)
+	assert.Equal(t, []*Promise{c}, dependents(a))
+	assert.Empty(t, dependents(c))
+}
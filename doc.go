@@ -0,0 +1,170 @@
+package promise
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/gopherjs/gopherjs/js"
+)
+
+// FuncDoc documents a function exported to JS.  Go doesn't retain parameter
+// names or doc comments at runtime, so they must be provided explicitly (for
+// example by a generator that reads the Go source) to show up in JS tooling.
+type FuncDoc struct {
+	Summary string   // description of the function
+	Params  []string // names of the parameters, in order
+	Returns string   // description of the resolved value
+}
+
+type documented struct {
+	fn  interface{}
+	doc FuncDoc
+}
+
+// Document attaches doc to fn.  The result can be passed to Promisify,
+// ExportAll and TypeScript in place of fn:
+//
+//	promise.ExportAll(map[string]interface{}{
+//		"getUser": promise.Document(getUser, promise.FuncDoc{
+//			Summary: "Fetches a user by ID.",
+//			Params:  []string{"id"},
+//		}),
+//	})
+func Document(fn interface{}, doc FuncDoc) interface{} {
+	return documented{fn, doc}
+}
+
+// undocument splits a value returned by Document into the function and its
+// documentation.  Other values are returned as is.
+func undocument(fn interface{}) (interface{}, FuncDoc) {
+	if d, ok := fn.(documented); ok {
+		return d.fn, d.doc
+	}
+	return fn, FuncDoc{}
+}
+
+// paramName returns the name of the i'th parameter.
+func (doc FuncDoc) paramName(i int) string {
+	if i < len(doc.Params) && doc.Params[i] != "" {
+		return doc.Params[i]
+	}
+	return fmt.Sprintf("arg%d", i)
+}
+
+// jsDoc returns a JSDoc comment for a promisified function of type t,
+// ignoring the parameters before from.
+func jsDoc(t reflect.Type, from int, doc FuncDoc) string {
+	var buf bytes.Buffer
+	buf.WriteString("/**\n")
+	for _, line := range strings.Split(doc.Summary, "\n") {
+		if line != "" {
+			fmt.Fprintf(&buf, " * %s\n", line)
+		}
+	}
+	for i := from; i < t.NumIn(); i++ {
+		if injected(t.In(i)) {
+			continue
+		}
+		typ := tsType(t.In(i), nil)
+		if t.IsVariadic() && i == t.NumIn()-1 {
+			typ = "..." + tsType(t.In(i).Elem(), nil)
+		}
+		fmt.Fprintf(&buf, " * @param {%s} %s\n", typ, doc.paramName(i-from))
+	}
+	fmt.Fprintf(&buf, " * @returns {Promise<%s>}", tsResult(t))
+	if doc.Returns != "" {
+		fmt.Fprintf(&buf, " %s", doc.Returns)
+	}
+	buf.WriteString("\n */")
+	return buf.String()
+}
+
+// jsFunction creates a JS function that calls call with its arguments.  The
+// function carries JSDoc metadata for fn in its "jsdoc" property so that
+// editors and documentation tools can pick it up, and its Signature in its
+// "__signature" property.
+func jsFunction(fn reflect.Value, doc FuncDoc, call func(args []*js.Object) *js.Object) *js.Object {
+	f := js.MakeFunc(func(this *js.Object, arguments []*js.Object) interface{} {
+		return call(arguments)
+	})
+	f.Set("jsdoc", jsDoc(fn.Type(), 0, doc))
+	f.Set("__signature", Describe(Document(fn.Interface(), doc)).js())
+	return f
+}
+
+// Signature is a machine-readable description of a promisified function, for
+// JS-side wrappers, form generators, and debugging consoles.  It is available
+// from JS in the "__signature" property of functions returned by Promisify.
+type Signature struct {
+	Params  []Param
+	Returns string // TS type of the resolved value
+	// Cancellable is set if the function accepts a *CancelToken or a
+	// context.Context through which it can observe cancellation.
+	Cancellable bool
+	// Streaming is set if the function produces a stream of values rather
+	// than a single result.
+	Streaming bool
+}
+
+// Param describes a parameter of a promisified function.
+type Param struct {
+	Name string
+	Type string // TS type
+}
+
+var (
+	cancelTokenType = reflect.TypeOf((*CancelToken)(nil))
+	contextType     = reflect.TypeOf((*context.Context)(nil)).Elem()
+)
+
+// Describe returns the signature of fn as seen from JS once promisified.  fn
+// may be the result of Document, in which case the documented parameter names
+// are used.  Parameters supplied by Promisify, such as *Progress, are omitted.
+func Describe(fn interface{}) Signature {
+	fn, doc := undocument(fn)
+	t := reflect.TypeOf(fn)
+	sig := Signature{Returns: tsResult(t)}
+	for i := 0; i < t.NumIn(); i++ {
+		in := t.In(i)
+		if in == cancelTokenType || in == contextType {
+			sig.Cancellable = true
+		}
+		if in == writerType {
+			sig.Streaming = true
+		}
+		if injected(in) {
+			continue
+		}
+		typ := tsType(in, nil)
+		if t.IsVariadic() && i == t.NumIn()-1 {
+			typ = "..." + tsType(in.Elem(), nil)
+		}
+		sig.Params = append(sig.Params, Param{doc.paramName(i), typ})
+	}
+	for i := 0; i < t.NumOut(); i++ {
+		if out := t.Out(i); out.Kind() == reflect.Chan || out.Kind() == reflect.Interface && out.Implements(readerType) {
+			sig.Streaming = true
+		}
+	}
+	return sig
+}
+
+// js converts the signature into a plain JS object.
+func (sig Signature) js() *js.Object {
+	params := js.Global.Get("Array").New()
+	for _, p := range sig.Params {
+		param := js.Global.Get("Object").New()
+		param.Set("name", p.Name)
+		param.Set("type", p.Type)
+		params.Call("push", param)
+	}
+	o := js.Global.Get("Object").New()
+	o.Set("params", params)
+	o.Set("returns", sig.Returns)
+	o.Set("cancellable", sig.Cancellable)
+	o.Set("streaming", sig.Streaming)
+	return o
+}
@@ -0,0 +1,50 @@
+package promise
+
+import (
+	"context"
+	"io"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJsDoc(t *testing.T) {
+	fn, doc := undocument(Document(func(id int, tags ...string) (*tsUser, error) { return nil, nil }, FuncDoc{
+		Summary: "Finds a user.\nTags narrow the search.",
+		Params:  []string{"id"},
+		Returns: "the user, or null",
+	}))
+	assert.Equal(t, `/**
+ * Finds a user.
+ * Tags narrow the search.
+ * @param {number} id
+ * @param {...string} arg1
+ * @returns {Promise<{name: string; age: number; tags: string[]; friends: (any | null)[]; created: Date} | null>} the user, or null
+ */`, jsDoc(reflect.TypeOf(fn), 0, doc))
+
+	fn, doc = undocument(func() {})
+	assert.Equal(t, "/**\n * @returns {Promise<null>}\n */", jsDoc(reflect.TypeOf(fn), 0, doc))
+}
+
+func TestDescribe(t *testing.T) {
+	assert.Equal(t, Signature{
+		Params: []Param{
+			{"id", "number"},
+			{"arg1", "...string"},
+		},
+		Returns: "boolean",
+	}, Describe(Document(func(id int, tags ...string) (bool, error) { return false, nil },
+		FuncDoc{Params: []string{"id"}})))
+
+	assert.Equal(t, Signature{
+		Params:      []Param{{"arg1", "number"}},
+		Returns:     "any",
+		Cancellable: true,
+		Streaming:   true,
+	}, Describe(func(*CancelToken, int) <-chan int { return nil }))
+
+	assert.True(t, Describe(func(context.Context) {}).Cancellable)
+	assert.True(t, Describe(func() (io.ReadCloser, error) { return nil, nil }).Streaming)
+	assert.False(t, Describe(func() (interface{}, error) { return nil, nil }).Streaming)
+}
@@ -0,0 +1,262 @@
+package promise
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gopherjs/gopherjs/js"
+)
+
+// ErrNoHandler is the rejection reason for a task of a DurableQueue whose
+// kind has no handler.  The task stays in the store, so that a later page
+// load that registers the handler can resume it.
+var ErrNoHandler = errors.New("promise: no handler for task kind")
+
+// StoredTask is a task of a DurableQueue as persisted in a TaskStore.
+type StoredTask struct {
+	ID       string          `json:"id"`
+	Kind     string          `json:"kind"`    // selects the handler
+	Payload  json.RawMessage `json:"payload"` // the argument of the handler
+	Attempts int             `json:"attempts"`
+}
+
+// TaskStore persists the tasks of a DurableQueue, e.g. in localStorage (see
+// LocalStorageStore) or IndexedDB.
+type TaskStore interface {
+	// Load returns the stored tasks, in the order in which they were saved
+	// first.
+	Load() ([]StoredTask, error)
+	// Save stores task, replacing any stored task with the same ID.
+	Save(task StoredTask) error
+	// Delete removes the task with the given ID, if any.
+	Delete(id string) error
+}
+
+// DurableQueueOptions configures a DurableQueue.
+type DurableQueueOptions struct {
+	// Backoff determines the delay between the attempts of a task.
+	// Defaults to exponential backoff from 1s to 5 minutes, with jitter.
+	Backoff Backoff
+	// MaxAttempts gives up on a task, removing it from the store and
+	// rejecting its promise, after that many failed attempts.  Zero means
+	// retrying until the task succeeds.
+	MaxAttempts int
+}
+
+// DurableQueue is a queue of operations that survives page reloads: every
+// task is persisted in a TaskStore when it is enqueued, and stays there,
+// being retried with backoff, until its handler acknowledges it by resolving.
+// Offline-tolerant apps use it for writes that must eventually reach the
+// server:
+//
+//	q := promise.NewDurableQueue(promise.LocalStorageStore("outbox:"), promise.DurableQueueOptions{})
+//	q.Handle("comment", func(payload []byte) *promise.Promise {
+//		return postComment(payload)
+//	})
+//	q.Resume() // tasks left over from earlier page loads
+//	q.Enqueue("comment", Comment{Post: 7, Text: "..."}).Then(...)
+//
+// Tasks run one at a time, in the order in which they were enqueued, like a
+// Queue; a task that is waiting to be retried holds up the ones after it.
+type DurableQueue struct {
+	store TaskStore
+	opts  DurableQueueOptions
+	queue Queue
+
+	mu        sync.Mutex
+	handlers  map[string]func(payload []byte) *Promise
+	scheduled map[string]*Promise // the tasks that haven't finished, by ID
+	seq       int64
+}
+
+// NewDurableQueue returns a queue that persists its tasks in store.
+func NewDurableQueue(store TaskStore, opts DurableQueueOptions) *DurableQueue {
+	if opts.Backoff == nil {
+		opts.Backoff = ExponentialBackoff{Initial: time.Second, Max: 5 * time.Minute, Jitter: true}
+	}
+	return &DurableQueue{store: store, opts: opts,
+		handlers: map[string]func([]byte) *Promise{}, scheduled: map[string]*Promise{}}
+}
+
+// Handle registers the handler for tasks of the given kind.  The handler is
+// called with the JSON payload of a task and acknowledges it by resolving the
+// returned promise; a rejection (or panic) is retried.  If the store fails to
+// save the attempts of a task that is to be retried, the promise of the task
+// is rejected with the error of the store instead.
+func (q *DurableQueue) Handle(kind string, handler func(payload []byte) *Promise) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.handlers[kind] = handler
+}
+
+// Enqueue persists a task of the given kind with payload, which is encoded as
+// JSON, and returns a promise for the value that its handler eventually
+// resolves with.
+func (q *DurableQueue) Enqueue(kind string, payload interface{}) *Promise {
+	data, err := json.Marshal(payload)
+	if err == nil {
+		task := StoredTask{ID: q.newID(), Kind: kind, Payload: data}
+		if err = q.store.Save(task); err == nil {
+			return q.schedule(task)
+		}
+	}
+	return Rejected(err)
+}
+
+// Resume schedules the tasks found in the store, such as those left over when
+// the page was closed.  It should be called once, after registering the
+// handlers.  The returned promise resolves with the promises of the resumed
+// tasks, as a []*Promise, or rejects if the store can't be read.  Tasks that
+// q is already running or waiting to run aren't scheduled again; their
+// promises are returned as they are.
+func (q *DurableQueue) Resume() *Promise {
+	p := newPromise()
+	tasks, err := q.store.Load()
+	if err != nil {
+		p.Reject(err)
+		return p
+	}
+	resumed := make([]*Promise, len(tasks))
+	for i, task := range tasks {
+		resumed[i] = q.schedule(task)
+	}
+	p.Resolve(resumed)
+	return p
+}
+
+// Len returns the number of tasks waiting to run, not including the task that
+// is currently running.
+func (q *DurableQueue) Len() int { return q.queue.Len() }
+
+func (q *DurableQueue) newID() string {
+	return fmt.Sprintf("%d-%d", time.Now().UnixNano(), atomic.AddInt64(&q.seq, 1))
+}
+
+// schedule runs task after the tasks scheduled before it, unless it is
+// scheduled already, and returns its promise.
+func (q *DurableQueue) schedule(task StoredTask) *Promise {
+	q.mu.Lock()
+	if p, ok := q.scheduled[task.ID]; ok {
+		q.mu.Unlock()
+		return p
+	}
+	p := newPromise()
+	q.scheduled[task.ID] = p
+	q.mu.Unlock()
+	p.onSettle(func() {
+		q.mu.Lock()
+		delete(q.scheduled, task.ID)
+		q.mu.Unlock()
+	})
+	p.Resolve(q.queue.Push(func() *Promise { return q.run(task) }))
+	return p
+}
+
+// run attempts task until it succeeds or runs out of attempts.
+func (q *DurableQueue) run(task StoredTask) *Promise {
+	result := newPromise()
+	var delay time.Duration
+	var attempt func()
+	attempt = func() {
+		q.mu.Lock()
+		handler := q.handlers[task.Kind]
+		q.mu.Unlock()
+		if handler == nil {
+			result.Reject(ErrNoHandler)
+			return
+		}
+		call(func() *Promise { return handler(task.Payload) }).Then(func(val interface{}) interface{} {
+			q.store.Delete(task.ID)
+			return result.Resolve(val)
+		}, func(reason interface{}) interface{} {
+			task.Attempts++
+			if q.opts.MaxAttempts > 0 && task.Attempts >= q.opts.MaxAttempts {
+				q.store.Delete(task.ID)
+				return result.Reject(reason)
+			}
+			if err := q.store.Save(task); err != nil {
+				return result.Reject(err)
+			}
+			delay = q.opts.Backoff.Delay(task.Attempts, delay)
+			time.AfterFunc(delay, attempt)
+			return nil
+		})
+	}
+	attempt()
+	return result
+}
+
+// LocalStorageStore returns a TaskStore that keeps each task as JSON in its
+// own localStorage entry, under the given key prefix.
+func LocalStorageStore(prefix string) TaskStore {
+	return localStorageStore{prefix}
+}
+
+type localStorageStore struct {
+	prefix string
+}
+
+func (s localStorageStore) storage() *js.Object { return js.Global.Get("localStorage") }
+
+func (s localStorageStore) Load() (tasks []StoredTask, err error) {
+	defer recoverJSError(&err)
+	storage := s.storage()
+	for i := 0; i < storage.Get("length").Int(); i++ {
+		key := storage.Call("key", i).String()
+		if !strings.HasPrefix(key, s.prefix) {
+			continue
+		}
+		var task StoredTask
+		if err := json.Unmarshal([]byte(storage.Call("getItem", key).String()), &task); err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, task)
+	}
+	sortTasks(tasks)
+	return tasks, nil
+}
+
+func (s localStorageStore) Save(task StoredTask) (err error) {
+	defer recoverJSError(&err)
+	data, err := json.Marshal(task)
+	if err != nil {
+		return err
+	}
+	s.storage().Call("setItem", s.prefix+task.ID, string(data))
+	return nil
+}
+
+func (s localStorageStore) Delete(id string) (err error) {
+	defer recoverJSError(&err)
+	s.storage().Call("removeItem", s.prefix+id)
+	return nil
+}
+
+// recoverJSError sets *err to a JS exception thrown by the calling function,
+// such as a QuotaExceededError from localStorage.
+func recoverJSError(err *error) {
+	if x := recover(); x != nil {
+		jsErr, ok := x.(*js.Error)
+		if !ok {
+			panic(x)
+		}
+		*err = jsErr
+	}
+}
+
+// sortTasks sorts tasks in the order of their IDs as generated by a
+// DurableQueue, which is the order in which they were enqueued.
+func sortTasks(tasks []StoredTask) {
+	sort.Slice(tasks, func(i, j int) bool {
+		var ti, si, tj, sj int64
+		fmt.Sscanf(tasks[i].ID, "%d-%d", &ti, &si)
+		fmt.Sscanf(tasks[j].ID, "%d-%d", &tj, &sj)
+		return ti < tj || ti == tj && si < sj
+	})
+}
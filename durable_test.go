@@ -0,0 +1,157 @@
+package promise
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// memoryStore is a TaskStore that keeps the tasks in memory, simulating a
+// page reload when shared by two queues.
+type memoryStore struct {
+	mu    sync.Mutex
+	tasks []StoredTask
+}
+
+func (s *memoryStore) Load() ([]StoredTask, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]StoredTask(nil), s.tasks...), nil
+}
+
+func (s *memoryStore) Save(task StoredTask) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.tasks {
+		if s.tasks[i].ID == task.ID {
+			s.tasks[i] = task
+			return nil
+		}
+	}
+	s.tasks = append(s.tasks, task)
+	return nil
+}
+
+func (s *memoryStore) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.tasks {
+		if s.tasks[i].ID == id {
+			s.tasks = append(s.tasks[:i], s.tasks[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+// failingStore fails to save tasks that were attempted.
+type failingStore struct {
+	*memoryStore
+	err error
+}
+
+func (s failingStore) Save(task StoredTask) error {
+	if task.Attempts > 0 {
+		return s.err
+	}
+	return s.memoryStore.Save(task)
+}
+
+func TestDurableQueue(t *testing.T) {
+	defer time.AfterFunc(time.Second, t.FailNow).Stop() // limit test to 1 second running time.
+
+	store := new(memoryStore)
+	opts := DurableQueueOptions{Backoff: ConstantBackoff{time.Millisecond}}
+
+	// Before the "reload", the handler fails twice and then hangs, as if the
+	// page was unloaded.
+	q := NewDurableQueue(store, opts)
+	attempts, n := make(chan string, 10), int32(0)
+	q.Handle("send", func(payload []byte) *Promise {
+		p := newPromise()
+		if atomic.AddInt32(&n, 1) <= 2 {
+			p.Reject(errors.New("offline"))
+		}
+		attempts <- string(payload)
+		return p
+	})
+	pending := q.Enqueue("send", map[string]int{"n": 1})
+	assert.Equal(t, `{"n":1}`, <-attempts)
+	assert.Equal(t, `{"n":1}`, <-attempts) // retried
+	assert.Equal(t, `{"n":1}`, <-attempts) // hangs
+	assert.True(t, pending.IsPending())
+
+	// Resuming doesn't run the task a second time.
+	val, _ := settled(q.Resume())
+	assert.Equal(t, []*Promise{pending}, val)
+	select {
+	case payload := <-attempts:
+		t.Fatalf("Ran %s again", payload)
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	tasks, _ := store.Load()
+	if assert.Len(t, tasks, 1) {
+		assert.Equal(t, "send", tasks[0].Kind)
+		assert.True(t, tasks[0].Attempts > 0)
+	}
+
+	// After the "reload", the task is resumed and acknowledged.
+	q = NewDurableQueue(store, opts)
+	q.Handle("send", func(payload []byte) *Promise { return Resolved("sent " + string(payload)) })
+	val, ok := settled(q.Resume())
+	assert.True(t, ok)
+	resumed := val.([]*Promise)
+	if assert.Len(t, resumed, 1) {
+		val, ok = settled(resumed[0])
+		assert.True(t, ok)
+		assert.Equal(t, `sent {"n":1}`, val)
+	}
+	tasks, _ = store.Load()
+	assert.Empty(t, tasks)
+
+	val, ok = settled(q.Enqueue("send", "hi"))
+	assert.True(t, ok)
+	assert.Equal(t, `sent "hi"`, val)
+}
+
+func TestDurableQueueGivesUp(t *testing.T) {
+	defer time.AfterFunc(time.Second, t.FailNow).Stop() // limit test to 1 second running time.
+
+	store := new(memoryStore)
+	q := NewDurableQueue(store, DurableQueueOptions{Backoff: ConstantBackoff{time.Millisecond}, MaxAttempts: 3})
+	attempts := 0
+	q.Handle("flaky", func(payload []byte) *Promise {
+		attempts++
+		panic("boom")
+	})
+	val, ok := settled(q.Enqueue("flaky", nil))
+	assert.False(t, ok)
+	assert.Equal(t, "boom", val)
+	assert.Equal(t, 3, attempts)
+	tasks, _ := store.Load()
+	assert.Empty(t, tasks)
+
+	// Tasks without a handler stay stored.
+	val, ok = settled(q.Enqueue("unknown", 1))
+	assert.False(t, ok)
+	assert.Equal(t, ErrNoHandler, val)
+	tasks, _ = store.Load()
+	assert.Len(t, tasks, 1)
+
+	// So are tasks whose attempts can't be saved.
+	failure := errors.New("quota exceeded")
+	q = NewDurableQueue(failingStore{store, failure}, DurableQueueOptions{Backoff: ConstantBackoff{time.Millisecond}})
+	q.Handle("flaky", func(payload []byte) *Promise { return Rejected("offline") })
+	val, ok = settled(q.Enqueue("flaky", 2))
+	assert.False(t, ok)
+	assert.Equal(t, failure, val)
+
+	// Payloads that can't be encoded are rejected right away.
+	_, ok = settled(q.Enqueue("flaky", func() {}))
+	assert.False(t, ok)
+}
@@ -0,0 +1,126 @@
+package promise
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/gopherjs/gopherjs/js"
+)
+
+// Listener is called with the arguments of each emitted event it is
+// registered for.
+type Listener func(args ...interface{})
+
+// Emitter dispatches named events to registered listeners, both in Go and, via
+// Js, in JS.  The zero value is an emitter with no listeners, ready to use.
+//
+// For example:
+//
+//	var changes promise.Emitter
+//	js.Global.Set("store", map[string]interface{}{
+//		"events": changes.Js(),
+//	})
+//	...
+//	changes.Emit("saved", key, value)
+//
+// JS code can then listen with store.events.on("saved", function(key, value)
+// {...}).
+type Emitter struct {
+	mu        sync.Mutex
+	listeners map[string]map[int]Listener
+	nextID    int
+}
+
+// On registers fn to be called for every event with the given name and
+// returns an id that removes it again when passed to Off.
+func (e *Emitter) On(event string, fn Listener) int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.listeners == nil {
+		e.listeners = map[string]map[int]Listener{}
+	}
+	if e.listeners[event] == nil {
+		e.listeners[event] = map[int]Listener{}
+	}
+	e.nextID++
+	e.listeners[event][e.nextID] = fn
+	return e.nextID
+}
+
+// Off removes the listener with the given id from event.  Removing a listener
+// that is not registered has no effect.
+func (e *Emitter) Off(event string, id int) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	delete(e.listeners[event], id)
+	if len(e.listeners[event]) == 0 {
+		delete(e.listeners, event)
+	}
+}
+
+// Emit synchronously calls every listener registered for event with args, in
+// the order they were registered.  Listeners may call On and Off; changes take
+// effect from the next Emit.
+func (e *Emitter) Emit(event string, args ...interface{}) {
+	for _, fn := range e.snapshot(event) {
+		fn(args...)
+	}
+}
+
+// Next returns a promise that is resolved with the arguments of the next
+// occurrence of event, using the same rules as Promisify for the results of a
+// function: no arguments resolve with nil, one with that value, and more with
+// a slice of them.
+func (e *Emitter) Next(event string) *Promise {
+	p := newPromise()
+	var mu sync.Mutex
+	mu.Lock()
+	defer mu.Unlock()
+	var id int
+	id = e.On(event, func(args ...interface{}) {
+		mu.Lock()
+		defer mu.Unlock()
+		if p.IsPending() {
+			e.Off(event, id)
+			p.Resolve(desliceOne(args))
+		}
+	})
+	return p
+}
+
+// ListenerCount returns how many listeners are registered for event.
+func (e *Emitter) ListenerCount(event string) int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return len(e.listeners[event])
+}
+
+// snapshot returns the listeners of event in registration order.
+func (e *Emitter) snapshot(event string) []Listener {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	ids := make([]int, 0, len(e.listeners[event]))
+	for id := range e.listeners[event] {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	fns := make([]Listener, len(ids))
+	for i, id := range ids {
+		fns[i] = e.listeners[event][id]
+	}
+	return fns
+}
+
+// Js creates a JS object for e with on(event, fn), off(event, id), once(event)
+// and emit(event, ...args) methods.  on returns the id to pass to off, and
+// once returns a promise for the next occurrence of the event.
+func (e *Emitter) Js() *js.Object {
+	o := js.Global.Get("Object").New()
+	o.Set("on", func(event string, fn *js.Object) int {
+		return e.On(event, func(args ...interface{}) { fn.Invoke(args...) })
+	})
+	o.Set("off", func(event string, id int) { e.Off(event, id) })
+	o.Set("once", func(event string) *js.Object { return e.Next(event).Js() })
+	o.Set("emit", func(event string, args ...interface{}) { e.Emit(event, args...) })
+	return o
+}
@@ -0,0 +1,48 @@
+package promise
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEmitter(t *testing.T) {
+	var e Emitter
+	var got []interface{}
+	first := e.On("change", func(args ...interface{}) { got = append(got, "first", args) })
+	e.On("change", func(args ...interface{}) { got = append(got, "second", len(args)) })
+	e.On("other", func(args ...interface{}) { got = append(got, "other") })
+	assert.Equal(t, 2, e.ListenerCount("change"))
+
+	e.Emit("change", 1, "a")
+	assert.Equal(t, []interface{}{"first", []interface{}{1, "a"}, "second", 2}, got)
+
+	got = nil
+	e.Off("change", first)
+	e.Off("change", first)
+	e.Emit("change")
+	e.Emit("missing")
+	assert.Equal(t, []interface{}{"second", 0}, got)
+	assert.Equal(t, 1, e.ListenerCount("change"))
+}
+
+func TestEmitterNext(t *testing.T) {
+	defer time.AfterFunc(time.Second, t.FailNow).Stop() // limit test to 1 second running time.
+
+	var e Emitter
+	one, many, none := e.Next("one"), e.Next("many"), e.Next("none")
+	e.Emit("one", 1)
+	e.Emit("one", 2)
+	e.Emit("many", 1, 2)
+	e.Emit("none")
+
+	val, ok := settled(one)
+	assert.True(t, ok)
+	assert.Equal(t, 1, val)
+	val, _ = settled(many)
+	assert.Equal(t, []interface{}{1, 2}, val)
+	val, _ = settled(none)
+	assert.Nil(t, val)
+	assert.Equal(t, 0, e.ListenerCount("one"))
+}
@@ -0,0 +1,128 @@
+package promise
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gopherjs/gopherjs/js"
+)
+
+// enumTable translates between the values of an enum-like Go type and their
+// names in JS, see RegisterEnum.
+type enumTable struct {
+	typ    reflect.Type
+	values map[string]reflect.Value
+	names  map[interface{}]string
+}
+
+var enums struct {
+	sync.RWMutex
+	byType map[reflect.Type]*enumTable
+}
+
+// RegisterEnum registers the JS names of the constants of an enum-like Go
+// type, so that JS passes and receives readable strings rather than raw
+// numbers.  All values of names must be of the same type:
+//
+//	promise.RegisterEnum(map[string]interface{}{
+//		"ascending":  Ascending,
+//		"descending": Descending,
+//	})
+//
+// Arguments for parameters of that type may then be given as one of the names
+// (or, for compatibility, as the underlying value), also within arrays and
+// objects, and results of that type are converted to their names.  Values
+// without a name are converted as usual.  In TypeScript declarations the type
+// becomes a union of the names.
+//
+// RegisterEnum panics if names is empty or its values have different types.
+// Registering a type again replaces its previous names.
+func RegisterEnum(names map[string]interface{}) {
+	e := newEnumTable(names)
+	enums.Lock()
+	if enums.byType == nil {
+		enums.byType = map[reflect.Type]*enumTable{}
+	}
+	enums.byType[e.typ] = e
+	enums.Unlock()
+
+	RegisterConverter(e.typ,
+		func(v interface{}) *js.Object { return js.InternalObject(e.name(v)) },
+		func(o *js.Object) (interface{}, error) {
+			if o == nil || o == js.Undefined {
+				return reflect.Zero(e.typ).Interface(), nil
+			}
+			rv, err := e.parse(o.Interface())
+			if err != nil {
+				return nil, err
+			}
+			return rv.Interface(), nil
+		})
+}
+
+func newEnumTable(names map[string]interface{}) *enumTable {
+	if len(names) == 0 {
+		panic("promise: RegisterEnum called without names")
+	}
+	e := &enumTable{values: map[string]reflect.Value{}, names: map[interface{}]string{}}
+	for name, v := range names {
+		rv := reflect.ValueOf(v)
+		if e.typ == nil {
+			e.typ = rv.Type()
+		} else if rv.Type() != e.typ {
+			panic(fmt.Sprintf("promise: RegisterEnum called with values of types %s and %s", e.typ, rv.Type()))
+		}
+		e.values[name] = rv
+		e.names[v] = name
+	}
+	return e
+}
+
+func lookupEnum(t reflect.Type) (*enumTable, bool) {
+	enums.RLock()
+	defer enums.RUnlock()
+	e, ok := enums.byType[t]
+	return e, ok
+}
+
+// name returns the JS name of v, or v itself if it has none.
+func (e *enumTable) name(v interface{}) interface{} {
+	if name, ok := e.names[v]; ok {
+		return name
+	}
+	return v
+}
+
+// parse converts v, a name or a number from JS, to a value of the enum type.
+func (e *enumTable) parse(v interface{}) (reflect.Value, error) {
+	switch v := v.(type) {
+	case string:
+		if rv, ok := e.values[v]; ok {
+			return rv, nil
+		}
+		return reflect.Value{}, fmt.Errorf("unknown %s %s, expected one of %s", e.typ, strconv.Quote(v), strings.Join(e.sortedNames(), ", "))
+	case float64:
+		if isNumber(e.typ.Kind()) {
+			return reflect.ValueOf(v).Convert(e.typ), nil
+		}
+	}
+	return reflect.Value{}, fmt.Errorf("cannot convert %T to %s", v, e.typ)
+}
+
+func (e *enumTable) sortedNames() []string {
+	names := make([]string, 0, len(e.values))
+	for name := range e.values {
+		names = append(names, strconv.Quote(name))
+	}
+	sort.Strings(names)
+	return names
+}
+
+// tsType returns the TS union of the names of the enum.
+func (e *enumTable) tsType() string {
+	return strings.Join(e.sortedNames(), " | ")
+}
@@ -0,0 +1,52 @@
+package promise
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type sortOrder int
+
+const (
+	ascending sortOrder = iota + 1
+	descending
+)
+
+type query struct {
+	Field string
+	Order sortOrder
+}
+
+func TestRegisterEnum(t *testing.T) {
+	typ := reflect.TypeOf(sortOrder(0))
+	defer func() {
+		enums.Lock()
+		delete(enums.byType, typ)
+		enums.Unlock()
+		converters.Lock()
+		delete(converters.byType, typ)
+		converters.Unlock()
+	}()
+	RegisterEnum(map[string]interface{}{"ascending": ascending, "descending": descending})
+
+	v, err := converter{}.convertValue(map[string]interface{}{"Field": "name", "Order": "descending"}, reflect.TypeOf(query{}), "arguments[0]")
+	assert.NoError(t, err)
+	assert.Equal(t, query{"name", descending}, v.Interface())
+
+	v, err = converter{}.convertValue(1.0, typ, "arguments[0]")
+	assert.NoError(t, err)
+	assert.Equal(t, ascending, v.Interface())
+
+	_, err = converter{}.convertValue("up", typ, "arguments[0]")
+	assert.EqualError(t, err, `promise: arguments[0]: unknown promise.sortOrder "up", expected one of "ascending", "descending"`)
+
+	e, _ := lookupEnum(typ)
+	assert.Equal(t, "descending", e.name(descending))
+	assert.Equal(t, sortOrder(7), e.name(sortOrder(7)))
+	assert.Equal(t, `{field: string; order: "ascending" | "descending"}`, tsType(reflect.TypeOf(query{}), nil))
+
+	assert.Panics(t, func() { RegisterEnum(nil) })
+	assert.Panics(t, func() { RegisterEnum(map[string]interface{}{"a": ascending, "b": 2}) })
+}
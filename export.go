@@ -0,0 +1,217 @@
+package promise
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/gopherjs/gopherjs/js"
+)
+
+// ExportStruct installs a JS constructor called name in the global scope that
+// wraps a Go object.  constructor must be a function that returns the Go
+// object, optionally followed by an error.  JS code calling the constructor
+// (with or without new) gets an object whose methods are the exported methods
+// of the Go object, promisified with Promisify and named in lowerCamelCase:
+//
+//	type Counter struct{ n int }
+//	func NewCounter(start int) *Counter          { return &Counter{start} }
+//	func (c *Counter) Add(delta int) int        { c.n += delta; return c.n }
+//
+//	promise.ExportStruct("Counter", NewCounter)
+//
+//	// In JS:
+//	var c = new Counter(5);
+//	c.add(2).then(function(n) { console.log(n); });  // 7
+//
+// The JS arguments are converted for the parameters of the constructor as for
+// Promisify.  If they can't be, or the constructor returns a non-nil error,
+// the error is thrown to the JS caller.  ExportStruct panics if constructor
+// doesn't have a suitable signature.
+func ExportStruct(name string, constructor interface{}) {
+	ctor := reflect.ValueOf(constructor)
+	if err := checkConstructor(ctor); err != nil {
+		panic(fmt.Errorf("ExportStruct(%q): %v", name, err))
+	}
+	registerClass(name, ctor.Type())
+	js.Global.Set(name, func(args ...*js.Object) *js.Object {
+		obj, err := construct(ctor, new(Promise), args)
+		if err != nil {
+			panic(&js.Error{Object: js.Global.Get("Error").New(err.Error())})
+		}
+		return wrapObject(reflect.ValueOf(obj))
+	})
+}
+
+// PromisifyFactory converts a Go factory function into a JS function that
+// returns a promise for a wrapped object, for objects that need asynchronous
+// initialization (opening a database, performing a handshake, ...).  factory
+// must return the Go object, optionally followed by an error, as for
+// ExportStruct.  It is run asynchronously like Promisify, with the JS arguments
+// converted in the same way, and the promise is resolved with an object
+// exposing the Go object's promisified methods:
+//
+//	js.Global.Set("openStore", promise.PromisifyFactory(OpenStore))
+//
+//	// In JS:
+//	openStore("main").then(function(store) { return store.get("key"); });
+//
+// PromisifyFactory panics if factory doesn't have a suitable signature.
+func PromisifyFactory(factory interface{}) interface{} {
+	ctor := reflect.ValueOf(factory)
+	if err := checkConstructor(ctor); err != nil {
+		panic(fmt.Errorf("PromisifyFactory: %v", err))
+	}
+	return func(args ...*js.Object) *js.Object {
+		p := newPromise()
+		p.run(func() (interface{}, error) { return construct(ctor, p, args) })
+		return p.Then(
+			func(obj interface{}) interface{} { return wrapObject(reflect.ValueOf(obj)) },
+			jsReason,
+		).Js()
+	}
+}
+
+// jsReason converts a rejection reason from Go code into one suitable for JS,
+// serializing errors as Promisify does.
+func jsReason(reason interface{}) interface{} {
+	if err, ok := reason.(error); ok {
+		return serializeError(err)
+	}
+	return reason
+}
+
+// ExportAll returns a JS object with every function in module promisified with
+// Promisify, ready to be installed with js.Global.Set.  Values that are nested
+// maps of type map[string]interface{} become nested objects:
+//
+//	js.Global.Set("api", promise.ExportAll(map[string]interface{}{
+//		"whoami": whoami,
+//		"users": map[string]interface{}{
+//			"get":  getUser,
+//			"list": listUsers,
+//		},
+//	}))
+//
+// All names and values are validated before anything is exported: ExportAll
+// panics, listing every problem, if a name isn't a valid JS identifier or a
+// value isn't a non-nil function (possibly passed through Document) or nested
+// map.
+func ExportAll(module map[string]interface{}) *js.Object {
+	if err := validateExports(module); err != nil {
+		panic(fmt.Errorf("ExportAll: %v", err))
+	}
+	return exportAll(module)
+}
+
+func exportAll(module map[string]interface{}) *js.Object {
+	o := js.Global.Get("Object").New()
+	for name, val := range module {
+		if nested, ok := val.(map[string]interface{}); ok {
+			o.Set(name, exportAll(nested))
+		} else {
+			o.Set(name, Promisify(val))
+		}
+	}
+	return o
+}
+
+var jsIdentifier = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*$`)
+
+// validateExports checks module for ExportAll, returning an error describing
+// every invalid entry.
+func validateExports(module map[string]interface{}) error {
+	var problems []string
+	var check func(module map[string]interface{}, prefix string)
+	check = func(module map[string]interface{}, prefix string) {
+		for name, val := range module {
+			path := prefix + name
+			if !jsIdentifier.MatchString(name) {
+				problems = append(problems, fmt.Sprintf("%q is not a valid JS identifier", path))
+			}
+			if nested, ok := val.(map[string]interface{}); ok {
+				check(nested, path+".")
+				continue
+			}
+			val, _ := undocument(val)
+			if v := reflect.ValueOf(val); v.Kind() != reflect.Func {
+				problems = append(problems, fmt.Sprintf("%q is a %T, not a function", path, val))
+			} else if v.IsNil() {
+				problems = append(problems, fmt.Sprintf("%q is a nil function", path))
+			}
+		}
+	}
+	check(module, "")
+	if len(problems) == 0 {
+		return nil
+	}
+	sort.Strings(problems)
+	return fmt.Errorf("%s", strings.Join(problems, "; "))
+}
+
+// construct calls the constructor ctor with the JS arguments args, converted
+// as for Promisify, and returns the object that it creates.  Injected
+// parameters are supplied for the call producing p.
+func construct(ctor reflect.Value, p *Promise, args []*js.Object) (interface{}, error) {
+	in, err := newConverter(CurrentConfig()).convertArgs(ctor.Type(), p, args)
+	if err != nil {
+		return nil, err
+	}
+	return splitResults(ctor.Call(in), hasLastError(ctor.Type()))
+}
+
+// checkConstructor verifies that ctor is a function returning a value,
+// optionally followed by an error.
+func checkConstructor(ctor reflect.Value) error {
+	if ctor.Kind() != reflect.Func {
+		return fmt.Errorf("constructor must be a function, not %v", ctor.Kind())
+	}
+	t := ctor.Type()
+	switch {
+	case t.NumOut() == 1 && t.Out(0) != errorType:
+	case t.NumOut() == 2 && t.Out(0) != errorType && t.Out(1) == errorType:
+	default:
+		return fmt.Errorf("constructor must return a value and optionally an error, not %v", t)
+	}
+	return nil
+}
+
+// wrapObject returns a JS object exposing the promisified methods of v.
+func wrapObject(v reflect.Value) *js.Object {
+	o := js.Global.Get("Object").New()
+	for name, method := range exportedMethods(v) {
+		o.Set(name, Promisify(method))
+	}
+	return o
+}
+
+// exportedMethods returns the exported methods of v keyed by their JS names.
+func exportedMethods(v reflect.Value) map[string]interface{} {
+	methods := map[string]interface{}{}
+	t := v.Type()
+	for i := 0; i < t.NumMethod(); i++ {
+		methods[jsName(t.Method(i).Name)] = v.Method(i).Interface()
+	}
+	return methods
+}
+
+// jsName converts an exported Go identifier into lowerCamelCase, treating a
+// leading initialism as a single word: Name -> name, ID -> id, HTTPGet ->
+// httpGet.
+func jsName(name string) string {
+	runes := []rune(name)
+	for i := range runes {
+		if !unicode.IsUpper(runes[i]) {
+			break
+		}
+		// Keep the last capital of an initialism if it starts the next word.
+		if i > 0 && i+1 < len(runes) && unicode.IsLower(runes[i+1]) {
+			break
+		}
+		runes[i] = unicode.ToLower(runes[i])
+	}
+	return string(runes)
+}
@@ -0,0 +1,126 @@
+package promise
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type counter struct{ n int }
+
+func (c *counter) Add(delta int) int { c.n += delta; return c.n }
+func (c *counter) ID() string        { return "c" }
+func (c *counter) private()          {}
+
+func TestJsName(t *testing.T) {
+	for name, expected := range map[string]string{
+		"Name":       "name",
+		"ID":         "id",
+		"HTTPGet":    "httpGet",
+		"GetHTTP":    "getHTTP",
+		"A":          "a",
+		"already":    "already",
+		"URLForUser": "urlForUser",
+	} {
+		assert.Equal(t, expected, jsName(name), name)
+	}
+}
+
+func TestExportedMethods(t *testing.T) {
+	methods := exportedMethods(reflect.ValueOf(&counter{}))
+	var names []string
+	for name := range methods {
+		names = append(names, name)
+	}
+	assert.ElementsMatch(t, []string{"add", "id"}, names)
+}
+
+func TestCheckConstructor(t *testing.T) {
+	assert.NoError(t, checkConstructor(reflect.ValueOf(func() *counter { return nil })))
+	assert.NoError(t, checkConstructor(reflect.ValueOf(func(int) (*counter, error) { return nil, nil })))
+	assert.Error(t, checkConstructor(reflect.ValueOf(counter{})))
+	assert.Error(t, checkConstructor(reflect.ValueOf(func() {})))
+	assert.Error(t, checkConstructor(reflect.ValueOf(func() error { return errors.New("x") })))
+	assert.Error(t, checkConstructor(reflect.ValueOf(func() (*counter, int) { return nil, 0 })))
+}
+
+func TestConstruct(t *testing.T) {
+	defer Configure(Config{})
+	var p Promise
+	newCounter := reflect.ValueOf(func(pr *Progress, start int) *counter { return &counter{start} })
+
+	// Arguments go through the converter, which supplies the injected
+	// parameters and zero values for missing arguments.
+	obj, err := construct(newCounter, &p, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, &counter{0}, obj)
+
+	failure := errors.New("bad start")
+	_, err = construct(reflect.ValueOf(func() (*counter, error) { return nil, failure }), &p, nil)
+	assert.Equal(t, failure, err)
+
+	Configure(Config{Conversion: Strict})
+	_, err = construct(newCounter, &p, nil)
+	assert.Equal(t, &ArityError{1, 1, 0}, err)
+}
+
+func TestConstructAsync(t *testing.T) {
+	defer time.AfterFunc(time.Second, t.FailNow).Stop() // limit test to 1 second running time.
+
+	// As run by PromisifyFactory.
+	factory := reflect.ValueOf(func(start int) (*counter, error) { return &counter{start}, nil })
+	p := newPromise()
+	p.run(func() (interface{}, error) { return construct(factory, p, nil) })
+	val, ok := settled(p)
+	assert.True(t, ok)
+	assert.Equal(t, &counter{0}, val)
+
+	p = newPromise()
+	p.run(func() (interface{}, error) { return nil, errors.New("no store") })
+	val, ok = settled(p)
+	assert.False(t, ok)
+	assert.EqualError(t, val.(error), "no store")
+
+	p = newPromise()
+	p.run(func() (interface{}, error) { panic("handshake failed") })
+	val, ok = settled(p)
+	assert.False(t, ok)
+	assert.Equal(t, "handshake failed", val)
+}
+
+func TestValidateExports(t *testing.T) {
+	assert.NoError(t, validateExports(map[string]interface{}{
+		"whoami": func() string { return "me" },
+		"$users": map[string]interface{}{
+			"get_1": Document(func(id int) {}, FuncDoc{Params: []string{"id"}}),
+		},
+	}))
+
+	var nilFunc func()
+	err := validateExports(map[string]interface{}{
+		"ok":       func() {},
+		"bad-name": func() {},
+		"version":  "1.0",
+		"nested": map[string]interface{}{
+			"1st": func() {},
+			"nil": nilFunc,
+		},
+	})
+	assert.EqualError(t, err, `"bad-name" is not a valid JS identifier; `+
+		`"nested.1st" is not a valid JS identifier; `+
+		`"nested.nil" is a nil function; `+
+		`"version" is a string, not a function`)
+}
+
+func TestPromisifyFactoryChecksSignature(t *testing.T) {
+	assert.Panics(t, func() { PromisifyFactory(func() {}) })
+	assert.NotPanics(t, func() { PromisifyFactory(func(name string) (*counter, error) { return nil, nil }) })
+}
+
+func TestJsReason(t *testing.T) {
+	assert.Equal(t, "failed", jsReason(errors.New("failed")))
+	assert.Equal(t, 3, jsReason(3))
+}
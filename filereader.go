@@ -0,0 +1,48 @@
+package promise
+
+import (
+	"github.com/gopherjs/gopherjs/js"
+)
+
+// ReadFileAsBytes reads a Blob or File with a FileReader and returns a promise
+// for its contents as a []byte.  The promise is rejected with a *js.Error if
+// reading fails or is aborted.
+func ReadFileAsBytes(file *js.Object) *Promise {
+	return readFile(file, "readAsArrayBuffer", func(result *js.Object) interface{} {
+		return js.Global.Get("Uint8Array").New(result).Interface().([]byte)
+	})
+}
+
+// ReadFileAsText reads a Blob or File with a FileReader and returns a promise
+// for its contents decoded as text in the given encoding, or UTF-8 if encoding
+// is empty.
+func ReadFileAsText(file *js.Object, encoding string) *Promise {
+	if encoding == "" {
+		encoding = "utf-8"
+	}
+	return readFile(file, "readAsText", func(result *js.Object) interface{} {
+		return result.String()
+	}, encoding)
+}
+
+// ReadFileAsDataURL reads a Blob or File with a FileReader and returns a
+// promise for a data: URL of its contents, e.g. to preview an image upload.
+func ReadFileAsDataURL(file *js.Object) *Promise {
+	return readFile(file, "readAsDataURL", func(result *js.Object) interface{} {
+		return result.String()
+	})
+}
+
+// readFile starts reading file with the given FileReader method and returns a
+// promise for the result, converted by convert.
+func readFile(file *js.Object, method string, convert func(result *js.Object) interface{}, args ...interface{}) *Promise {
+	p := newPromise()
+	reader := js.Global.Get("FileReader").New()
+	reader.Set("onload", func() { p.Resolve(convert(reader.Get("result"))) })
+	reader.Set("onerror", func() { p.Reject(&js.Error{Object: reader.Get("error")}) })
+	reader.Set("onabort", func() {
+		p.Reject(&js.Error{Object: js.Global.Get("Error").New("file read aborted")})
+	})
+	reader.Call(method, append([]interface{}{file}, args...)...)
+	return p
+}
@@ -0,0 +1,407 @@
+package promise
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gopherjs/gopherjs/js"
+)
+
+// ErrHandshake is the rejection reason for FrameClient requests when the other
+// window doesn't answer the handshake within FrameOptions.HandshakeTimeout.
+var ErrHandshake = errors.New("promise: frame handshake failed")
+
+// frameChannel marks the messages of the frame protocol, so that other
+// messages posted between the windows are ignored.
+const frameChannel = "promise/frame"
+
+// frameMessage is the JSON message exchanged between the windows.  The client
+// sends "hello" until the server answers "ready" with its methods, and then
+// "call" messages that the server answers with "result".
+type frameMessage struct {
+	Channel string            `json:"channel"`
+	Type    string            `json:"type"`
+	ID      int64             `json:"id,omitempty"`
+	Method  string            `json:"method,omitempty"`
+	Params  []json.RawMessage `json:"params,omitempty"`
+	Result  json.RawMessage   `json:"result,omitempty"`
+	Error   *string           `json:"error,omitempty"`
+	Methods []string          `json:"methods,omitempty"`
+}
+
+func (m frameMessage) encode() string {
+	m.Channel = frameChannel
+	b, _ := json.Marshal(m)
+	return string(b)
+}
+
+func decodeFrameMessage(data string) (m frameMessage, ok bool) {
+	if json.Unmarshal([]byte(data), &m) != nil || m.Channel != frameChannel {
+		return m, false
+	}
+	return m, true
+}
+
+// originAllowed reports whether messages from origin are accepted by the
+// allowed list, in which "*" matches any origin.
+func originAllowed(origin string, allowed []string) bool {
+	for _, a := range allowed {
+		if a == "*" || a == origin {
+			return true
+		}
+	}
+	return false
+}
+
+// ServeFrame exposes Go functions to other windows, such as the page that
+// embeds this one in an iframe or the window that opened it, for FrameClients
+// connected with ConnectFrame.  Only messages from the allowed origins (e.g.
+// "https://app.example.com", or "*" for any, which should only be used for
+// functions that are safe to call from any site) are answered, and the
+// answers are only posted to the origin of the request.
+//
+// The functions are called as with Method, after decoding the JSON arguments
+// into their parameter types.  Calling stop removes the listener.
+func ServeFrame(exports map[string]interface{}, origins ...string) (stop func()) {
+	s := newFrameServer(exports, origins)
+	listener := func(event *js.Object) {
+		origin, source := event.Get("origin").String(), event.Get("source")
+		data, ok := frameData(event)
+		if !ok || source == nil || source == js.Undefined {
+			return
+		}
+		s.handle(origin, data, func(msg string) {
+			source.Call("postMessage", msg, origin)
+		})
+	}
+	js.Global.Call("addEventListener", "message", listener)
+	return func() { js.Global.Call("removeEventListener", "message", listener) }
+}
+
+// frameData returns the data of a message event if it is a string, as the
+// messages of the frame protocol are.
+func frameData(event *js.Object) (string, bool) {
+	data := event.Get("data")
+	toString := js.Global.Get("Object").Get("prototype").Get("toString")
+	if toString.Call("call", data).String() != "[object String]" {
+		return "", false
+	}
+	return data.String(), true
+}
+
+type frameServer struct {
+	origins []string
+	methods []string
+	exports map[string]interface{}
+}
+
+func newFrameServer(exports map[string]interface{}, origins []string) *frameServer {
+	s := &frameServer{origins: origins, exports: map[string]interface{}{}}
+	for name, fn := range exports {
+		if reflect.TypeOf(fn).Kind() != reflect.Func {
+			panic(fmt.Sprintf("promise: frame export %q is not a function", name))
+		}
+		s.exports[name] = fn
+		s.methods = append(s.methods, name)
+	}
+	sort.Strings(s.methods)
+	return s
+}
+
+// handle answers the message data from origin with reply.
+func (s *frameServer) handle(origin, data string, reply func(msg string)) {
+	m, ok := decodeFrameMessage(data)
+	if !ok || !originAllowed(origin, s.origins) {
+		return
+	}
+	switch m.Type {
+	case "hello":
+		reply(frameMessage{Type: "ready", Methods: s.methods}.encode())
+	case "call":
+		s.call(m).Then(func(value interface{}) interface{} {
+			result, err := json.Marshal(value)
+			if err != nil {
+				reply(frameError(m.ID, err))
+			} else {
+				reply(frameMessage{Type: "result", ID: m.ID, Result: result}.encode())
+			}
+			return nil
+		}, func(reason interface{}) interface{} {
+			reply(frameError(m.ID, reason))
+			return nil
+		})
+	}
+}
+
+func (s *frameServer) call(m frameMessage) *Promise {
+	fn, ok := s.exports[m.Method]
+	if !ok {
+		return Rejected(fmt.Errorf("promise: frame method %q not found", m.Method))
+	}
+	args, err := decodeFrameParams(reflect.TypeOf(fn), m.Params)
+	if err != nil {
+		return Rejected(err)
+	}
+	return Method(fn)(args...)
+}
+
+func frameError(id int64, reason interface{}) string {
+	msg := fmt.Sprint(reason)
+	if err, ok := reason.(error); ok {
+		msg = err.Error()
+	}
+	return frameMessage{Type: "result", ID: id, Error: &msg}.encode()
+}
+
+// decodeFrameParams decodes params into the parameter types of t, skipping
+// the injected ones.  Missing arguments are zero values.
+func decodeFrameParams(t reflect.Type, params []json.RawMessage) ([]interface{}, error) {
+	args, variadic := []interface{}{}, t.IsVariadic()
+	for i := 0; i < t.NumIn(); i++ {
+		in := t.In(i)
+		if injected(in) {
+			continue
+		}
+		if variadic && i == t.NumIn()-1 {
+			for _, param := range params {
+				v := reflect.New(in.Elem())
+				if err := json.Unmarshal(param, v.Interface()); err != nil {
+					return nil, err
+				}
+				args = append(args, v.Elem().Interface())
+			}
+			return args, nil
+		}
+		v := reflect.New(in)
+		if len(params) > 0 {
+			if err := json.Unmarshal(params[0], v.Interface()); err != nil {
+				return nil, err
+			}
+			params = params[1:]
+		}
+		args = append(args, v.Elem().Interface())
+	}
+	if len(params) > 0 {
+		return nil, &ArityError{Max: len(args), Got: len(args) + len(params)}
+	}
+	return args, nil
+}
+
+// FrameOptions configures ConnectFrame.
+type FrameOptions struct {
+	// Timeout rejects requests with ErrTimeout if no response arrives within
+	// the duration, counted from the call.  Zero means no timeout.
+	Timeout time.Duration
+	// HandshakeTimeout rejects Ready and all requests with ErrHandshake if the
+	// other window doesn't answer the handshake in time.  Defaults to 10s.
+	HandshakeTimeout time.Duration
+}
+
+// FrameClient calls the functions that another window exposes with
+// ServeFrame, see ConnectFrame.
+type FrameClient struct {
+	opts  FrameOptions
+	post  func(msg string)
+	stop  func()
+	ready *Promise
+
+	mu        sync.Mutex
+	pending   []func()
+	connected bool
+	closed    bool
+	nextID    int64
+	inflight  map[int64]*Promise
+}
+
+// ConnectFrame connects to the functions served with ServeFrame by the window
+// target at origin, e.g. iframe.Get("contentWindow") or
+// js.Global.Get("parent").  Messages are only posted to origin, and only
+// messages from target at origin are accepted, so origin must not be "*".
+//
+// The client repeats the handshake until target answers, which lets it
+// connect to an iframe that is still loading.  Requests made before that are
+// sent once the handshake completes.
+func ConnectFrame(target *js.Object, origin string, opts FrameOptions) *FrameClient {
+	if origin == "*" || origin == "" {
+		panic("promise: ConnectFrame needs an explicit origin")
+	}
+	c := newFrameClient(opts, func(msg string) { target.Call("postMessage", msg, origin) })
+	listener := func(event *js.Object) {
+		if event.Get("source") == target && event.Get("origin").String() == origin &&
+			event.Get("data").Get("constructor") == js.Global.Get("String") {
+			c.received(event.Get("data").String())
+		}
+	}
+	js.Global.Call("addEventListener", "message", listener)
+	c.stop = func() { js.Global.Call("removeEventListener", "message", listener) }
+	go c.handshake(100 * time.Millisecond)
+	return c
+}
+
+func newFrameClient(opts FrameOptions, post func(string)) *FrameClient {
+	if opts.HandshakeTimeout == 0 {
+		opts.HandshakeTimeout = 10 * time.Second
+	}
+	return &FrameClient{opts: opts, post: post, ready: newPromise(), inflight: map[int64]*Promise{}}
+}
+
+// Ready returns a promise for the names of the functions that the other
+// window serves, which resolves when the handshake completes.
+func (c *FrameClient) Ready() *Promise { return c.ready }
+
+// Call calls method in the other window with args, which must be encodable as
+// JSON, and returns a promise for the decoded result.  Errors of the remote
+// function reject it with a RemoteError.
+func (c *FrameClient) Call(method string, args ...interface{}) *Promise {
+	p := newPromise()
+	params := make([]json.RawMessage, len(args))
+	for i, arg := range args {
+		param, err := json.Marshal(arg)
+		if err != nil {
+			p.Reject(err)
+			return p
+		}
+		params[i] = param
+	}
+
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		p.Reject(ErrClientClosed)
+		return p
+	}
+	c.nextID++
+	id := c.nextID
+	c.inflight[id] = p
+	msg := frameMessage{Type: "call", ID: id, Method: method, Params: params}.encode()
+	send := func() { c.post(msg) }
+	if !c.connected {
+		c.pending = append(c.pending, send)
+		send = nil
+	}
+	c.mu.Unlock()
+
+	if c.opts.Timeout > 0 {
+		time.AfterFunc(c.opts.Timeout, func() {
+			if p := c.take(id); p != nil {
+				p.Reject(ErrTimeout)
+			}
+		})
+	}
+	if send != nil {
+		send()
+	}
+	return p
+}
+
+// Stub returns a function that calls method, for use as a local stand-in for
+// the remote function.
+func (c *FrameClient) Stub(method string) func(args ...interface{}) *Promise {
+	return func(args ...interface{}) *Promise { return c.Call(method, args...) }
+}
+
+// Close stops listening for messages and rejects all in-flight requests with
+// ErrClientClosed.  Calling Close more than once has no effect.
+func (c *FrameClient) Close() { c.shutdown(ErrClientClosed) }
+
+// shutdown closes the client, rejecting Ready (unless the handshake
+// completed) and the in-flight requests with reason.
+func (c *FrameClient) shutdown(reason error) {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return
+	}
+	c.closed = true
+	stop, connected := c.stop, c.connected
+	c.pending = nil
+	c.mu.Unlock()
+	if stop != nil {
+		stop()
+	}
+	if !connected {
+		c.ready.Reject(reason)
+	}
+	c.rejectAll(reason)
+}
+
+// handshake sends hello every interval until the other window answers, the
+// client is closed, or the handshake times out.
+func (c *FrameClient) handshake(interval time.Duration) {
+	deadline := time.Now().Add(c.opts.HandshakeTimeout)
+	for {
+		c.mu.Lock()
+		done := c.connected || c.closed
+		c.mu.Unlock()
+		if done {
+			return
+		}
+		if time.Now().After(deadline) {
+			c.shutdown(ErrHandshake)
+			return
+		}
+		c.post(frameMessage{Type: "hello"}.encode())
+		time.Sleep(interval)
+	}
+}
+
+func (c *FrameClient) take(id int64) *Promise {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	p := c.inflight[id]
+	delete(c.inflight, id)
+	return p
+}
+
+func (c *FrameClient) rejectAll(reason error) {
+	c.mu.Lock()
+	inflight := c.inflight
+	c.inflight = map[int64]*Promise{}
+	c.mu.Unlock()
+	for _, p := range inflight {
+		p.Reject(reason)
+	}
+}
+
+func (c *FrameClient) received(data string) {
+	m, ok := decodeFrameMessage(data)
+	if !ok {
+		return
+	}
+	switch m.Type {
+	case "ready":
+		c.mu.Lock()
+		if c.connected || c.closed {
+			c.mu.Unlock()
+			return
+		}
+		c.connected = true
+		pending := c.pending
+		c.pending = nil
+		c.mu.Unlock()
+		c.ready.Resolve(m.Methods)
+		for _, send := range pending {
+			send()
+		}
+	case "result":
+		p := c.take(m.ID)
+		if p == nil {
+			return
+		}
+		if m.Error != nil {
+			p.Reject(RemoteError{*m.Error})
+			return
+		}
+		var result interface{}
+		if err := json.Unmarshal(m.Result, &result); err != nil && len(m.Result) > 0 {
+			p.Reject(err)
+			return
+		}
+		p.Resolve(result)
+	}
+}
@@ -0,0 +1,111 @@
+package promise
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// connectFrames connects a FrameClient to s as if it posted from origin.
+func connectFrames(s *frameServer, origin string, opts FrameOptions) *FrameClient {
+	var c *FrameClient
+	c = newFrameClient(opts, func(msg string) {
+		s.handle(origin, msg, func(reply string) { c.received(reply) })
+	})
+	return c
+}
+
+func TestFrameRPC(t *testing.T) {
+	defer time.AfterFunc(time.Second, t.FailNow).Stop() // limit test to 1 second running time.
+
+	s := newFrameServer(map[string]interface{}{
+		"add": func(a, b int) int { return a + b },
+		"sum": func(xs ...float64) (total float64) {
+			for _, x := range xs {
+				total += x
+			}
+			return
+		},
+		"fail": func() error { return errors.New("nope") },
+	}, []string{"https://app.example.com"})
+	assert.Panics(t, func() { newFrameServer(map[string]interface{}{"x": 1}, nil) })
+
+	c := connectFrames(s, "https://app.example.com", FrameOptions{})
+	add := c.Stub("add")
+	early := add(1, 2) // queued until the handshake completes
+	go c.handshake(time.Millisecond)
+
+	val, ok := settled(c.Ready())
+	assert.True(t, ok)
+	assert.Equal(t, []string{"add", "fail", "sum"}, val)
+	val, ok = settled(early)
+	assert.True(t, ok)
+	assert.Equal(t, 3.0, val)
+
+	val, _ = settled(c.Call("sum", 1, 2.5, 3))
+	assert.Equal(t, 6.5, val)
+	val, ok = settled(c.Call("fail"))
+	assert.False(t, ok)
+	assert.Equal(t, RemoteError{"nope"}, val)
+	val, _ = settled(c.Call("missing"))
+	assert.Equal(t, RemoteError{`promise: frame method "missing" not found`}, val)
+	val, _ = settled(c.Call("add", "one", 2))
+	assert.IsType(t, RemoteError{}, val)
+	val, _ = settled(c.Call("add", 1, 2, 3))
+	assert.IsType(t, RemoteError{}, val)
+
+	c.Close()
+	val, _ = settled(c.Call("add", 1, 2))
+	assert.Equal(t, ErrClientClosed, val)
+}
+
+func TestFrameOrigins(t *testing.T) {
+	defer time.AfterFunc(time.Second, t.FailNow).Stop() // limit test to 1 second running time.
+
+	assert.True(t, originAllowed("https://a.com", []string{"https://b.com", "https://a.com"}))
+	assert.True(t, originAllowed("https://evil.com", []string{"*"}))
+	assert.False(t, originAllowed("https://a.com.evil.com", []string{"https://a.com"}))
+	assert.False(t, originAllowed("https://a.com", nil))
+
+	s := newFrameServer(map[string]interface{}{"secret": func() string { return "s3cr3t" }},
+		[]string{"https://app.example.com"})
+	var replies []string
+	reply := func(msg string) { replies = append(replies, msg) }
+	s.handle("https://evil.com", frameMessage{Type: "hello"}.encode(), reply)
+	s.handle("https://evil.com", frameMessage{Type: "call", ID: 1, Method: "secret"}.encode(), reply)
+	s.handle("https://app.example.com", `{"type": "hello"}`, reply) // not the frame protocol
+	assert.Empty(t, replies)
+
+	// A client whose handshake is never answered gives up.
+	c := connectFrames(s, "https://evil.com", FrameOptions{HandshakeTimeout: 20 * time.Millisecond})
+	call := c.Call("secret")
+	go c.handshake(time.Millisecond)
+	val, _ := settled(c.Ready())
+	assert.Equal(t, ErrHandshake, val)
+	val, _ = settled(call)
+	assert.Equal(t, ErrHandshake, val)
+}
+
+func TestFrameTimeout(t *testing.T) {
+	defer time.AfterFunc(time.Second, t.FailNow).Stop() // limit test to 1 second running time.
+
+	var sent []frameMessage
+	c := newFrameClient(FrameOptions{Timeout: 10 * time.Millisecond}, func(msg string) {
+		m, _ := decodeFrameMessage(msg)
+		sent = append(sent, m)
+	})
+	c.received(frameMessage{Type: "ready", Methods: []string{"slow"}}.encode())
+	slow := c.Call("slow", map[string]int{"n": 1})
+	val, _ := settled(slow)
+	assert.Equal(t, ErrTimeout, val)
+	assert.Equal(t, "slow", sent[0].Method)
+	assert.Equal(t, []json.RawMessage{json.RawMessage(`{"n":1}`)}, sent[0].Params)
+
+	// A late result is ignored.
+	c.received(frameMessage{Type: "result", ID: sent[0].ID, Result: json.RawMessage("1")}.encode())
+	val, _ = settled(slow)
+	assert.Equal(t, ErrTimeout, val)
+}
@@ -0,0 +1,142 @@
+package promise
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Edge kinds recorded in the dependency graph.
+const (
+	EdgeThen   = "then"   // the child promise returned by Then
+	EdgeAdopt  = "adopt"  // a promise adopting the state of another
+	EdgeMember = "member" // an input of a combinator, such as AllLimit
+)
+
+// GraphNode is a promise in the dependency graph, see EnableGraph.
+type GraphNode struct {
+	ID    int    `json:"id"`
+	Label string `json:"label,omitempty"`
+	State string `json:"state"`
+	Never bool   `json:"never,omitempty"` // created by Never, so pending on purpose
+	// Meta is the metadata of the promise, see WithMeta.
+	Meta map[string]interface{} `json:"meta,omitempty"`
+}
+
+// GraphEdge is a dependency between two promises: To settles based on From.
+type GraphEdge struct {
+	From int    `json:"from"`
+	To   int    `json:"to"`
+	Kind string `json:"kind"`
+}
+
+type graphState struct {
+	sync.Mutex
+	enabled int32 // set atomically, so that it is checked without locking
+	ids     map[*Promise]int
+	nodes   []*Promise
+	edges   []GraphEdge
+}
+
+var graph graphState
+
+// EnableGraph starts (or stops) recording the relationships between promises:
+// Then edges, adoption edges and combinator membership.  Enabling discards
+// anything recorded before.  Recording keeps every promise involved alive, so
+// it is meant for debugging only, e.g. to find out why a whole subtree is
+// stuck pending with GraphDOT.
+func EnableGraph(enabled bool) {
+	graph.Lock()
+	defer graph.Unlock()
+	var v int32
+	if enabled {
+		v = 1
+		graph.ids, graph.nodes, graph.edges = map[*Promise]int{}, nil, nil
+	}
+	atomic.StoreInt32(&graph.enabled, v)
+}
+
+// graphNode records p in the graph, if recording is enabled.
+func graphNode(p *Promise) {
+	if atomic.LoadInt32(&graph.enabled) == 0 {
+		return
+	}
+	graph.Lock()
+	defer graph.Unlock()
+	if graph.enabled != 0 {
+		graph.id(p)
+	}
+}
+
+// graphEdge records that to depends on from, if recording is enabled.
+func graphEdge(from, to *Promise, kind string) {
+	if atomic.LoadInt32(&graph.enabled) == 0 {
+		return
+	}
+	graph.Lock()
+	defer graph.Unlock()
+	if graph.enabled != 0 {
+		graph.edges = append(graph.edges, GraphEdge{graph.id(from), graph.id(to), kind})
+	}
+}
+
+// id returns the id of p, adding it to the graph if necessary.  The graph
+// lock must be held.
+func (g *graphState) id(p *Promise) int {
+	id, ok := g.ids[p]
+	if !ok {
+		g.nodes = append(g.nodes, p)
+		id = len(g.nodes)
+		g.ids[p] = id
+	}
+	return id
+}
+
+// Graph returns the recorded nodes and edges, see EnableGraph.
+func Graph() ([]GraphNode, []GraphEdge) {
+	graph.Lock()
+	defer graph.Unlock()
+	nodes := make([]GraphNode, len(graph.nodes))
+	for i, p := range graph.nodes {
+		s, _ := p.current()
+		nodes[i] = GraphNode{i + 1, p.Label(), s.String(), p.never, p.AllMeta()}
+	}
+	return nodes, append([]GraphEdge(nil), graph.edges...)
+}
+
+// GraphJSON returns the recorded graph as a JSON object with "nodes" and
+// "edges" arrays.
+func GraphJSON() ([]byte, error) {
+	nodes, edges := Graph()
+	return json.Marshal(map[string]interface{}{"nodes": nodes, "edges": edges})
+}
+
+// GraphDOT returns the recorded graph in the Graphviz DOT language.  Nodes
+// show the label, state and metadata of the promises, and pending promises
+// are highlighted, except for those returned by Never.
+func GraphDOT() string {
+	nodes, edges := Graph()
+	var buf bytes.Buffer
+	buf.WriteString("digraph promises {\n")
+	for _, n := range nodes {
+		label := "#" + strconv.Itoa(n.ID)
+		if n.Label != "" {
+			label += " " + n.Label
+		}
+		style := ""
+		if n.State == StatePending.String() && !n.Never {
+			style = ", style=filled, fillcolor=yellow"
+		}
+		lines := append([]string{label, n.State}, metaLines(n.Meta)...)
+		fmt.Fprintf(&buf, "  p%d [label=%q%s];\n", n.ID, strings.Join(lines, "\n"), style)
+	}
+	for _, e := range edges {
+		fmt.Fprintf(&buf, "  p%d -> p%d [label=%q];\n", e.From, e.To, e.Kind)
+	}
+	buf.WriteString("}\n")
+	return buf.String()
+}
@@ -0,0 +1,68 @@
+package promise
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGraph(t *testing.T) {
+	EnableGraph(true)
+	defer EnableGraph(false)
+
+	var a, b Promise
+	a.SetLabel("a")
+	b.SetLabel("b")
+	child := a.Then(nil, nil).SetLabel("child")
+	Any(child, &b).SetLabel("any")
+	a.Resolve(1)
+
+	nodes, edges := Graph()
+	byID := map[int]GraphNode{}
+	for _, n := range nodes {
+		byID[n.ID] = n
+	}
+	var described []string
+	for _, e := range edges {
+		from, to := byID[e.From], byID[e.To]
+		described = append(described, from.Label+" -"+e.Kind+"-> "+to.Label)
+	}
+	assert.Subset(t, described, []string{
+		"a -then-> child",
+		"child -member-> any",
+		"b -member-> any",
+	})
+	for _, n := range nodes {
+		if n.Label == "a" {
+			assert.Equal(t, "fulfilled", n.State)
+		}
+	}
+
+	dot := GraphDOT()
+	assert.True(t, strings.HasPrefix(dot, "digraph promises {\n"), dot)
+	assert.Contains(t, dot, `[label="then"];`)
+	assert.Contains(t, dot, "fillcolor=yellow")
+
+	data, err := GraphJSON()
+	assert.NoError(t, err)
+	var decoded struct {
+		Nodes []GraphNode
+		Edges []GraphEdge
+	}
+	assert.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, len(nodes), len(decoded.Nodes))
+}
+
+func TestGraphDisabled(t *testing.T) {
+	EnableGraph(true)
+	EnableGraph(false)
+	var a Promise
+	a.Then(nil, nil)
+	graphNode(&a)
+	graph.Lock()
+	defer graph.Unlock()
+	assert.Empty(t, graph.nodes)
+	assert.Empty(t, graph.edges)
+}
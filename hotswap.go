@@ -0,0 +1,103 @@
+package promise
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/gopherjs/gopherjs/js"
+)
+
+// hotswaps holds the functions exported with Register.
+var hotswaps struct {
+	sync.Mutex
+	byName map[string]*hotswap
+}
+
+// hotswap is the current implementation of a function exported with
+// Register.  It is replaced as a whole, so calls that already started keep
+// the implementation they started with.
+type hotswap struct {
+	mu   sync.Mutex
+	impl *hotImpl
+}
+
+type hotImpl struct {
+	fn   interface{}
+	once sync.Once
+	js   *js.Object // fn promisified, created on first use
+}
+
+// promisified returns the promisified implementation, creating it once.
+func (h *hotImpl) promisified() *js.Object {
+	h.once.Do(func() { h.js = Promisify(h.fn).(*js.Object) })
+	return h.js
+}
+
+func (h *hotswap) current() *hotImpl {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.impl
+}
+
+// Register promisifies fn with Promisify and installs it in the global scope
+// under name, like js.Global.Set, behind a stable JS function whose
+// implementation can be swapped with Replace.  This is meant for live-reload
+// development setups: pages keep the function that they looked up, and
+// Replace changes what it calls.  Register returns the installed function.
+// It panics if name is already registered or fn isn't a function.
+func Register(name string, fn interface{}) *js.Object {
+	h := &hotswap{impl: newHotImpl(name, fn)}
+	hotswaps.Lock()
+	if hotswaps.byName[name] != nil {
+		hotswaps.Unlock()
+		panic(fmt.Errorf("Register: %q is already registered", name))
+	}
+	if hotswaps.byName == nil {
+		hotswaps.byName = map[string]*hotswap{}
+	}
+	hotswaps.byName[name] = h
+	hotswaps.Unlock()
+
+	f := js.MakeFunc(func(this *js.Object, args []*js.Object) interface{} {
+		return h.current().promisified().Call("apply", this, args)
+	})
+	js.Global.Set(name, f)
+	return f
+}
+
+// Replace atomically swaps the implementation of the function registered
+// under name for fn.  Calls that are in flight finish with the previous
+// implementation, and later calls use fn.  Replace panics if name isn't
+// registered or fn isn't a function.
+func Replace(name string, fn interface{}) {
+	impl := newHotImpl(name, fn)
+	hotswaps.Lock()
+	h := hotswaps.byName[name]
+	hotswaps.Unlock()
+	if h == nil {
+		panic(fmt.Errorf("Replace: %q is not registered", name))
+	}
+	h.mu.Lock()
+	h.impl = impl
+	h.mu.Unlock()
+}
+
+// Registered returns the current implementation of the function registered
+// under name, or nil if there is none.
+func Registered(name string) interface{} {
+	hotswaps.Lock()
+	h := hotswaps.byName[name]
+	hotswaps.Unlock()
+	if h == nil {
+		return nil
+	}
+	return h.current().fn
+}
+
+func newHotImpl(name string, fn interface{}) *hotImpl {
+	if f, _ := undocument(fn); reflect.ValueOf(f).Kind() != reflect.Func || reflect.ValueOf(f).IsNil() {
+		panic(fmt.Errorf("%q: %T is not a non-nil function", name, fn))
+	}
+	return &hotImpl{fn: fn}
+}
@@ -0,0 +1,30 @@
+package promise
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReplace(t *testing.T) {
+	v1 := func() string { return "v1" }
+	v2 := func() string { return "v2" }
+	// Register itself needs JS, so install the entry directly.
+	hotswaps.Lock()
+	if hotswaps.byName == nil {
+		hotswaps.byName = map[string]*hotswap{}
+	}
+	hotswaps.byName["version"] = &hotswap{impl: newHotImpl("version", v1)}
+	hotswaps.Unlock()
+
+	inFlight := hotswaps.byName["version"].current()
+	Replace("version", v2)
+	assert.Equal(t, "v2", Registered("version").(func() string)())
+	assert.Equal(t, "v1", inFlight.fn.(func() string)(), "calls in flight keep their implementation")
+
+	assert.Nil(t, Registered("unknown"))
+	assert.Panics(t, func() { Replace("unknown", v2) })
+	assert.Panics(t, func() { Replace("version", 42) })
+	assert.Panics(t, func() { Replace("version", (func())(nil)) })
+	assert.Panics(t, func() { Register("version", v1) })
+}
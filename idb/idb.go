@@ -0,0 +1,152 @@
+// Package idb wraps the event-based IndexedDB API in promises and observables
+// from github.com/augustoroman/promise.
+//
+// For example:
+//
+//	idb.Open("app", 1, func(u *idb.Upgrader) {
+//		u.CreateStore("users", "id", false)
+//	}).Then(func(v interface{}) interface{} {
+//		db := v.(*idb.DB)
+//		return db.Put("users", user, nil)
+//	}, nil)
+//
+// Requests are rejected with a *js.Error wrapping the DOMException reported by
+// IndexedDB.
+package idb
+
+import (
+	"github.com/augustoroman/promise"
+	"github.com/gopherjs/gopherjs/js"
+)
+
+// DB is an open IndexedDB database.
+type DB struct {
+	db *js.Object
+}
+
+// Entry is a record produced by a cursor.
+type Entry struct {
+	Key   interface{}
+	Value interface{}
+}
+
+// Upgrader is passed to the upgrade function of Open to change the schema of
+// the database while it is being upgraded.
+type Upgrader struct {
+	OldVersion, NewVersion int
+
+	db *js.Object
+}
+
+// CreateStore creates an object store.  If keyPath is empty, keys must be
+// provided to Put unless autoIncrement is set.
+func (u *Upgrader) CreateStore(name, keyPath string, autoIncrement bool) {
+	opts := js.Global.Get("Object").New()
+	if keyPath != "" {
+		opts.Set("keyPath", keyPath)
+	}
+	opts.Set("autoIncrement", autoIncrement)
+	u.db.Call("createObjectStore", name, opts)
+}
+
+// DeleteStore deletes an object store and all of its records.
+func (u *Upgrader) DeleteStore(name string) {
+	u.db.Call("deleteObjectStore", name)
+}
+
+// Open opens the named database at the given version and returns a promise
+// for the *DB.  If the database doesn't exist yet or has a lower version,
+// upgrade (which may be nil) is called first to update its schema.
+func Open(name string, version int, upgrade func(u *Upgrader)) *promise.Promise {
+	req := js.Global.Get("indexedDB").Call("open", name, version)
+	req.Set("onupgradeneeded", func(event *js.Object) {
+		if upgrade != nil {
+			upgrade(&Upgrader{
+				OldVersion: event.Get("oldVersion").Int(),
+				NewVersion: event.Get("newVersion").Int(),
+				db:         req.Get("result"),
+			})
+		}
+	})
+	return request(req).Then(func(db interface{}) interface{} {
+		return &DB{req.Get("result")}
+	}, nil)
+}
+
+// DeleteDatabase deletes the named database.
+func DeleteDatabase(name string) *promise.Promise {
+	return request(js.Global.Get("indexedDB").Call("deleteDatabase", name))
+}
+
+// Close closes the database.  Pending transactions are completed first.
+func (db *DB) Close() { db.db.Call("close") }
+
+// Get returns a promise for the value stored under key in store, or nil if
+// there is none.
+func (db *DB) Get(store string, key interface{}) *promise.Promise {
+	return request(db.store(store, "readonly").Call("get", key))
+}
+
+// Put stores value in store and returns a promise for its key.  key is
+// ignored (and may be nil) if the store uses a key path.
+func (db *DB) Put(store string, value, key interface{}) *promise.Promise {
+	s := db.store(store, "readwrite")
+	if key == nil {
+		return request(s.Call("put", value))
+	}
+	return request(s.Call("put", value, key))
+}
+
+// Delete deletes the record stored under key in store.
+func (db *DB) Delete(store string, key interface{}) *promise.Promise {
+	return request(db.store(store, "readwrite").Call("delete", key))
+}
+
+// Cursor returns an observable of the Entries in store, in key order.  query
+// restricts the keys (it may be a key or an IDBKeyRange) or is nil for all of
+// them.  Each subscription iterates the store in its own transaction, and
+// unsubscribing stops the iteration.
+func (db *DB) Cursor(store string, query interface{}) *promise.Observable {
+	return promise.NewObservable(func(o promise.Observer) func() {
+		stopped := false
+		var req *js.Object
+		if query == nil {
+			req = db.store(store, "readonly").Call("openCursor")
+		} else {
+			req = db.store(store, "readonly").Call("openCursor", query)
+		}
+		req.Set("onsuccess", func() {
+			cursor := req.Get("result")
+			if cursor == nil || stopped {
+				o.Complete()
+				return
+			}
+			o.Next(Entry{cursor.Get("key").Interface(), cursor.Get("value").Interface()})
+			if !stopped {
+				cursor.Call("continue")
+			}
+		})
+		req.Set("onerror", func() { o.Error(&js.Error{Object: req.Get("error")}) })
+		return func() { stopped = true }
+	})
+}
+
+func (db *DB) store(name, mode string) *js.Object {
+	return db.db.Call("transaction", name, mode).Call("objectStore", name)
+}
+
+// request returns a promise that settles when the IDBRequest req succeeds or
+// fails.
+func request(req *js.Object) *promise.Promise {
+	var p promise.Promise
+	req.Set("onsuccess", func() {
+		result := req.Get("result")
+		if result == js.Undefined || result == nil {
+			p.Resolve(nil)
+		} else {
+			p.Resolve(result.Interface())
+		}
+	})
+	req.Set("onerror", func() { p.Reject(&js.Error{Object: req.Get("error")}) })
+	return &p
+}
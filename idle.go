@@ -0,0 +1,49 @@
+package promise
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/gopherjs/gopherjs/js"
+)
+
+// IdleScheduler returns a Scheduler that runs tasks only when the main thread
+// is idle, using requestIdleCallback, for low-priority chains of promises such
+// as prefetching or cache warming.  If maxDelay is positive, a task runs after
+// at most maxDelay even if the thread never becomes idle.  Where
+// requestIdleCallback isn't available, tasks are run with setTimeout.
+//
+// Use it for individual chains with SetScheduler or Options.Scheduler; the
+// promises returned by Then inherit it:
+//
+//	idle := promise.IdleScheduler(2 * time.Second)
+//	warmCache().SetScheduler(idle).Then(...)
+//
+// As with PostTaskScheduler, each task runs on a goroutine started from the
+// idle callback, since it may block.
+func IdleScheduler(maxDelay time.Duration) Scheduler {
+	return SchedulerFunc(func(task func()) {
+		run := func() {
+			atomic.AddInt64(&counters.Goroutines, 1)
+			go task()
+		}
+		if ric := js.Global.Get("requestIdleCallback"); ric != js.Undefined {
+			opts := js.Global.Get("Object").New()
+			if maxDelay > 0 {
+				opts.Set("timeout", maxDelay.Seconds()*1000)
+			}
+			ric.Invoke(run, opts)
+			return
+		}
+		js.Global.Call("setTimeout", run, 0)
+	})
+}
+
+// SetScheduler makes s run the callbacks of p, and returns p for chaining.
+// Promises returned by Then inherit the scheduler.
+func (p *Promise) SetScheduler(s Scheduler) *Promise {
+	p.mu.Lock()
+	p.scheduler = s
+	p.mu.Unlock()
+	return p
+}
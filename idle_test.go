@@ -0,0 +1,22 @@
+package promise
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetScheduler(t *testing.T) {
+	var queue []func()
+	sched := SchedulerFunc(func(task func()) { queue = append(queue, task) })
+	p := newPromise().SetScheduler(sched)
+	child := p.Then(nil, nil)
+	grandchild := child.Then(nil, nil)
+	p.Resolve(1)
+	assert.Equal(t, 1, len(queue))
+
+	// Callbacks registered later on settled children use the inherited scheduler.
+	queue[0]()
+	grandchild.Then(nil, nil)
+	assert.Equal(t, 2, len(queue))
+}
@@ -0,0 +1,104 @@
+package promise
+
+import (
+	"reflect"
+	"sync"
+	"sync/atomic"
+
+	"github.com/gopherjs/gopherjs/js"
+)
+
+// ExportChannel returns a JS async iterator over the values received from ch,
+// which must be a channel that can be received from, so that JS can consume it
+// with for await...of:
+//
+//	js.Global.Set("updates", func() *js.Object {
+//		return promise.ExportChannel(updates)
+//	})
+//
+//	for await (const u of updates()) { ... }
+//
+// Iteration finishes when ch is closed or when JS stops iterating early (for
+// example by breaking out of the loop); ch itself is never closed.  Values are
+// received only when JS asks for them, so a slow consumer applies backpressure
+// to the sender.  ExportChannel panics if ch isn't a channel.
+func ExportChannel(ch interface{}) *js.Object {
+	it := newChannelIterator(ch)
+	o := js.Global.Get("Object").New()
+	o.Set("next", func() *js.Object { return it.next().Js() })
+	o.Set("return", func(value *js.Object) *js.Object { return it.finish(value).Js() })
+	js.Global.Get("Object").Call("defineProperty", o,
+		js.Global.Get("Symbol").Get("asyncIterator"),
+		map[string]interface{}{"value": func() *js.Object { return o }})
+	return o
+}
+
+// channelIterator implements the async iterator protocol over a channel.
+// Receives are serialized so that concurrent calls to next see the values in
+// channel order.
+type channelIterator struct {
+	ch    reflect.Value
+	queue Queue
+
+	mu   sync.Mutex
+	done bool
+	stop chan struct{} // closed by finish, to abandon pending receives
+}
+
+func newChannelIterator(ch interface{}) *channelIterator {
+	c := reflect.ValueOf(ch)
+	if c.Kind() != reflect.Chan || c.Type().ChanDir()&reflect.RecvDir == 0 {
+		panic("ExportChannel: not a receivable channel: " + c.Type().String())
+	}
+	return &channelIterator{ch: c, stop: make(chan struct{})}
+}
+
+// iterResult is the shape of the results of an async iterator's methods.
+func iterResult(value interface{}, done bool) map[string]interface{} {
+	return map[string]interface{}{"value": value, "done": done}
+}
+
+// next returns a promise for the next value received from the channel.
+func (it *channelIterator) next() *Promise {
+	return it.queue.Push(func() *Promise {
+		if it.finished() {
+			return Resolved(iterResult(nil, true))
+		}
+		p := newPromise()
+		atomic.AddInt64(&counters.Goroutines, 1)
+		go func() {
+			chosen, val, ok := reflect.Select([]reflect.SelectCase{
+				{Dir: reflect.SelectRecv, Chan: it.ch},
+				{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(it.stop)},
+			})
+			switch {
+			case chosen == 1:
+				// Iteration was abandoned while waiting, nothing is received.
+				p.Resolve(iterResult(nil, true))
+			case !ok:
+				it.finish(nil)
+				p.Resolve(iterResult(nil, true))
+			default:
+				p.Resolve(iterResult(val.Interface(), false))
+			}
+		}()
+		return p
+	})
+}
+
+// finish ends the iteration, resolving with value as the final result.
+func (it *channelIterator) finish(value interface{}) *Promise {
+	it.mu.Lock()
+	if !it.done {
+		it.done = true
+		close(it.stop)
+	}
+	it.mu.Unlock()
+	return Resolved(iterResult(value, true))
+}
+
+func (it *channelIterator) finished() bool {
+	it.mu.Lock()
+	defer it.mu.Unlock()
+	return it.done
+}
@@ -0,0 +1,62 @@
+package promise
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChannelIterator(t *testing.T) {
+	defer time.AfterFunc(time.Second, t.FailNow).Stop() // limit test to 1 second running time.
+
+	ch := make(chan int)
+	it := newChannelIterator(ch)
+	a, b, c := it.next(), it.next(), it.next()
+
+	// Values are only received when asked for, in order.
+	ch <- 1
+	ch <- 2
+	close(ch)
+
+	val, _ := settled(a)
+	assert.Equal(t, iterResult(1, false), val)
+	val, _ = settled(b)
+	assert.Equal(t, iterResult(2, false), val)
+	val, _ = settled(c)
+	assert.Equal(t, iterResult(nil, true), val)
+	val, _ = settled(it.next())
+	assert.Equal(t, iterResult(nil, true), val)
+
+	assert.Panics(t, func() { newChannelIterator(make(chan<- int)) })
+}
+
+func TestChannelIteratorReturn(t *testing.T) {
+	defer time.AfterFunc(time.Second, t.FailNow).Stop() // limit test to 1 second running time.
+
+	ch := make(chan int, 1)
+	it := newChannelIterator(ch)
+	val, _ := settled(it.finish("bye"))
+	assert.Equal(t, iterResult("bye", true), val)
+
+	ch <- 1
+	val, _ = settled(it.next())
+	assert.Equal(t, iterResult(nil, true), val)
+	assert.Equal(t, 1, len(ch), "nothing is received after return")
+}
+
+func TestChannelIteratorReturnWhileWaiting(t *testing.T) {
+	defer time.AfterFunc(time.Second, t.FailNow).Stop() // limit test to 1 second running time.
+
+	ch := make(chan int, 1)
+	it := newChannelIterator(ch)
+	waiting := it.next()
+	time.Sleep(10 * time.Millisecond) // let it start receiving
+	it.finish(nil)
+
+	// The pending receive is abandoned rather than taking a later value.
+	val, _ := settled(waiting)
+	assert.Equal(t, iterResult(nil, true), val)
+	ch <- 1
+	assert.Equal(t, 1, len(ch), "nothing is received after return")
+}
@@ -0,0 +1,107 @@
+package promise
+
+import "github.com/gopherjs/gopherjs/js"
+
+// JQueryDeferred returns a JS object shaped like a jQuery Deferred that is
+// backed by p, for legacy front ends written against that API rather than
+// standard promises:
+//
+//	done(fns...), fail(fns...), always(fns...) and progress(fns...) register
+//	    callbacks (functions or arrays of functions) and return the object
+//	then(done, fail, progress) and catch(fail) return a new jQuery promise
+//	    for the result of the callbacks
+//	state() returns "pending", "resolved" or "rejected"
+//	promise() returns a read-only view without the methods below
+//	resolve(value), reject(reason) and notify(value) settle p or report its
+//	    progress, and return the object
+//
+// Callbacks run asynchronously like those registered with Then, and receive a
+// single argument.  Progress callbacks receive the values passed to Notify.
+func (p *Promise) JQueryDeferred() *js.Object {
+	// The deferred inherits the read-only methods, so that promise() returns
+	// an object without the ones below.
+	d := js.Global.Get("Object").Call("create", jqueryPromise(p))
+	d.Set("resolve", func(value *js.Object) *js.Object {
+		if p.IsPending() {
+			p.Resolve(value)
+		}
+		return d
+	})
+	d.Set("reject", func(reason *js.Object) *js.Object {
+		if p.IsPending() {
+			p.Reject(reason)
+		}
+		return d
+	})
+	d.Set("notify", func(value *js.Object) *js.Object {
+		p.Notify(value)
+		return d
+	})
+	return d
+}
+
+// jqueryPromise returns the read-only jQuery promise object for p.
+func jqueryPromise(p *Promise) *js.Object {
+	o := js.Global.Get("Object").New()
+	// register calls add with each of the functions in args, which jQuery
+	// allows to be nested in arrays.
+	register := func(args []*js.Object, add func(f *js.Object)) *js.Object {
+		for _, arg := range args {
+			if js.Global.Get("Array").Call("isArray", arg).Bool() {
+				for i := 0; i < arg.Length(); i++ {
+					add(arg.Index(i))
+				}
+			} else if isFunction(arg) {
+				add(arg)
+			}
+		}
+		return o
+	}
+	listen := func(success, failure Callback) {
+		// The failure callback marks the rejection as handled for the
+		// listeners' throwaway promise.
+		p.Then(success, func(reason interface{}) interface{} {
+			if failure != nil {
+				failure(reason)
+			}
+			return nil
+		})
+	}
+	o.Set("done", func(fns ...*js.Object) *js.Object {
+		return register(fns, func(f *js.Object) { listen(jsCallback(f), nil) })
+	})
+	o.Set("fail", func(fns ...*js.Object) *js.Object {
+		return register(fns, func(f *js.Object) { listen(nil, jsCallback(f)) })
+	})
+	o.Set("always", func(fns ...*js.Object) *js.Object {
+		return register(fns, func(f *js.Object) { listen(jsCallback(f), jsCallback(f)) })
+	})
+	o.Set("progress", func(fns ...*js.Object) *js.Object {
+		return register(fns, func(f *js.Object) {
+			p.OnProgress(func(val interface{}) { f.Invoke(jsProgress(val)) })
+		})
+	})
+	o.Set("then", func(success, failure, progress *js.Object) *js.Object {
+		if isFunction(progress) {
+			p.OnProgress(func(val interface{}) { progress.Invoke(jsProgress(val)) })
+		}
+		return jqueryPromise(p.Then(jsCallback(success), jsCallback(failure)))
+	})
+	o.Set("catch", func(failure *js.Object) *js.Object {
+		return jqueryPromise(p.Then(nil, jsCallback(failure)))
+	})
+	o.Set("state", func() string {
+		s, _ := p.current()
+		return jqueryState(s)
+	})
+	o.Set("promise", func() *js.Object { return o })
+	return o
+}
+
+// jqueryState returns the jQuery name of s.
+func jqueryState(s State) string {
+	if s == StateFulfilled {
+		return "resolved"
+	}
+	return s.String()
+}
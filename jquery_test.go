@@ -0,0 +1,13 @@
+package promise
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJQueryState(t *testing.T) {
+	assert.Equal(t, "pending", jqueryState(StatePending))
+	assert.Equal(t, "resolved", jqueryState(StateFulfilled))
+	assert.Equal(t, "rejected", jqueryState(StateRejected))
+}
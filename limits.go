@@ -0,0 +1,213 @@
+package promise
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gopherjs/gopherjs/js"
+)
+
+// ArgLimits guards promisified functions against oversized or malformed
+// arguments, since exported functions are reachable by any script on the
+// page.  The limits are checked on the JS values before the arguments are
+// converted, walking them no further than needed, and a call that exceeds
+// them is rejected with a *LimitError.  Zero fields impose no limit.
+type ArgLimits struct {
+	// MaxPayload limits the approximate size in bytes of all of the
+	// arguments of a call, as if they were encoded as JSON.
+	MaxPayload int
+	// MaxString limits the length of every string, including object keys.
+	MaxString int
+	// MaxArray limits the length of every array, and the number of
+	// properties of every object.
+	MaxArray int
+	// MaxDepth limits how deeply arrays and objects are nested.
+	MaxDepth int
+	// Sanitize, if set, is called with the Go name of the function and the
+	// arguments of each call that is within the limits.  A non-nil error
+	// rejects the call.
+	Sanitize func(fn string, args []*js.Object) error
+}
+
+// LimitError is the rejection reason for a call whose arguments exceed its
+// ArgLimits.
+type LimitError struct {
+	Path  string // e.g. "arguments[0].name", or "arguments" for the payload
+	Limit string // "payload", "string", "array" or "depth"
+	Max   int
+	Got   int
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("promise: %s: %s size %d exceeds the limit of %d", e.Path, e.Limit, e.Got, e.Max)
+}
+
+// merge returns l with its unset fields taken from defaults.
+func (l ArgLimits) merge(defaults ArgLimits) ArgLimits {
+	if l.MaxPayload == 0 {
+		l.MaxPayload = defaults.MaxPayload
+	}
+	if l.MaxString == 0 {
+		l.MaxString = defaults.MaxString
+	}
+	if l.MaxArray == 0 {
+		l.MaxArray = defaults.MaxArray
+	}
+	if l.MaxDepth == 0 {
+		l.MaxDepth = defaults.MaxDepth
+	}
+	if l.Sanitize == nil {
+		l.Sanitize = defaults.Sanitize
+	}
+	return l
+}
+
+// check applies l to the arguments of a call of fn.
+func (l ArgLimits) check(fn string, args []*js.Object) error {
+	if l.MaxPayload > 0 || l.MaxString > 0 || l.MaxArray > 0 || l.MaxDepth > 0 {
+		values := make([]argValue, len(args))
+		for i, arg := range args {
+			values[i] = jsArg{arg}
+		}
+		if err := l.checkValues(values); err != nil {
+			return err
+		}
+	}
+	if l.Sanitize != nil {
+		return l.Sanitize(fn, args)
+	}
+	return nil
+}
+
+// argValue is an argument as seen by the limits, which measure it in place
+// rather than converting it first, see jsArg.
+type argValue interface {
+	// class is the class of the value as reported by
+	// Object.prototype.toString, e.g. "String", "Array" or "Null".
+	class() string
+	// length is the length of a string or an array, or the size in bytes
+	// of binary data.
+	length() int
+	index(i int) argValue
+	keys() []string
+	get(key string) argValue
+}
+
+// jsArg is a JS argument of a call.
+type jsArg struct{ o *js.Object }
+
+func (a jsArg) class() string {
+	c := js.Global.Get("Object").Get("prototype").Get("toString").Call("call", a.o).String()
+	return strings.TrimSuffix(strings.TrimPrefix(c, "[object "), "]")
+}
+
+func (a jsArg) length() int {
+	if n := a.o.Get("byteLength"); n != js.Undefined {
+		return n.Int()
+	}
+	return a.o.Length()
+}
+
+func (a jsArg) index(i int) argValue    { return jsArg{a.o.Index(i)} }
+func (a jsArg) keys() []string          { return js.Keys(a.o) }
+func (a jsArg) get(key string) argValue { return jsArg{a.o.Get(key)} }
+
+// checkValues applies the limits of l to args.  The walk stops at the first
+// limit exceeded, so no more of args is visited than the limits allow.
+func (l ArgLimits) checkValues(args []argValue) error {
+	w := &limitWalk{l: l}
+	for i, arg := range args {
+		if err := w.measure(arg, fmt.Sprintf("arguments[%d]", i), 0); err != nil {
+			return err
+		}
+		w.size++
+	}
+	return nil
+}
+
+// limitWalk measures arguments against l, accumulating their approximate
+// size as JSON.
+type limitWalk struct {
+	l    ArgLimits
+	size int
+}
+
+// add counts n more bytes of payload.
+func (w *limitWalk) add(n int) error {
+	w.size += n
+	if w.l.MaxPayload > 0 && w.size > w.l.MaxPayload {
+		return &LimitError{"arguments", "payload", w.l.MaxPayload, w.size}
+	}
+	return nil
+}
+
+// measure checks v, found at path and nested depth levels deep, and adds its
+// size.
+func (w *limitWalk) measure(v argValue, path string, depth int) error {
+	l := w.l
+	switch v.class() {
+	case "Null", "Undefined":
+		return w.add(4)
+	case "String":
+		n := v.length()
+		if l.MaxString > 0 && n > l.MaxString {
+			return &LimitError{path, "string", l.MaxString, n}
+		}
+		return w.add(n + 2)
+	case "Array", "Object":
+		if l.MaxDepth > 0 && depth >= l.MaxDepth {
+			return &LimitError{path, "depth", l.MaxDepth, depth + 1}
+		}
+		if v.class() == "Array" {
+			return w.measureArray(v, path, depth)
+		}
+		return w.measureObject(v, path, depth)
+	case "Number", "Boolean", "BigInt", "Function", "Symbol":
+		return w.add(8)
+	}
+	if v.class() == "ArrayBuffer" || strings.HasSuffix(v.class(), "Array") {
+		return w.add(v.length()) // typed arrays
+	}
+	return w.add(8) // other JS objects, e.g. Dates and Blobs
+}
+
+func (w *limitWalk) measureArray(v argValue, path string, depth int) error {
+	n := v.length()
+	if w.l.MaxArray > 0 && n > w.l.MaxArray {
+		return &LimitError{path, "array", w.l.MaxArray, n}
+	}
+	if err := w.add(2); err != nil {
+		return err
+	}
+	for i := 0; i < n; i++ {
+		if err := w.measure(v.index(i), fmt.Sprintf("%s[%d]", path, i), depth+1); err != nil {
+			return err
+		}
+		if err := w.add(1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *limitWalk) measureObject(v argValue, path string, depth int) error {
+	keys := v.keys()
+	if w.l.MaxArray > 0 && len(keys) > w.l.MaxArray {
+		return &LimitError{path, "array", w.l.MaxArray, len(keys)}
+	}
+	if err := w.add(2); err != nil {
+		return err
+	}
+	for _, key := range keys {
+		if w.l.MaxString > 0 && len(key) > w.l.MaxString {
+			return &LimitError{path, "string", w.l.MaxString, len(key)}
+		}
+		if err := w.measure(v.get(key), path+"."+key, depth+1); err != nil {
+			return err
+		}
+		if err := w.add(len(key) + 4); err != nil {
+			return err
+		}
+	}
+	return nil
+}
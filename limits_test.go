@@ -0,0 +1,109 @@
+package promise
+
+import (
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// goArg is an argValue for a JS value as gopherjs converts it to Go.
+type goArg struct{ v interface{} }
+
+func (a goArg) class() string {
+	switch a.v.(type) {
+	case nil:
+		return "Null"
+	case string:
+		return "String"
+	case []interface{}:
+		return "Array"
+	case map[string]interface{}:
+		return "Object"
+	case []byte:
+		return "Uint8Array"
+	}
+	return "Number"
+}
+
+func (a goArg) length() int {
+	switch v := a.v.(type) {
+	case string:
+		return len(v)
+	case []interface{}:
+		return len(v)
+	case []byte:
+		return len(v)
+	}
+	return 0
+}
+
+func (a goArg) index(i int) argValue { return goArg{a.v.([]interface{})[i]} }
+
+func (a goArg) keys() []string {
+	var keys []string
+	for key := range a.v.(map[string]interface{}) {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (a goArg) get(key string) argValue { return goArg{a.v.(map[string]interface{})[key]} }
+
+func goArgs(args ...interface{}) []argValue {
+	values := make([]argValue, len(args))
+	for i, arg := range args {
+		values[i] = goArg{arg}
+	}
+	return values
+}
+
+func TestArgLimits(t *testing.T) {
+	args := goArgs(
+		"short",
+		map[string]interface{}{"tags": []interface{}{"a", "b", "c"}, "note": strings.Repeat("x", 20)},
+	)
+	assert.NoError(t, ArgLimits{}.checkValues(args))
+	assert.NoError(t, ArgLimits{MaxPayload: 100, MaxString: 20, MaxArray: 3, MaxDepth: 2}.checkValues(args))
+
+	assert.Equal(t, &LimitError{"arguments[1].note", "string", 10, 20},
+		ArgLimits{MaxString: 10}.checkValues(args))
+	assert.Equal(t, &LimitError{"arguments[1].tags", "array", 2, 3},
+		ArgLimits{MaxArray: 2}.checkValues(args))
+	assert.Equal(t, &LimitError{"arguments[1].tags", "depth", 1, 2},
+		ArgLimits{MaxDepth: 1}.checkValues(args))
+	err := ArgLimits{MaxPayload: 30}.checkValues(args)
+	if assert.IsType(t, &LimitError{}, err) {
+		assert.Equal(t, "arguments", err.(*LimitError).Path)
+		assert.Contains(t, err.Error(), "payload size")
+	}
+
+	// Limits merge field by field.
+	l := ArgLimits{MaxString: 5}.merge(ArgLimits{MaxString: 10, MaxArray: 2, MaxDepth: 3})
+	assert.Equal(t, 5, l.MaxString)
+	assert.Equal(t, 2, l.MaxArray)
+	assert.Equal(t, 3, l.MaxDepth)
+}
+
+// visits counts the elements of an array that are visited.
+type visits struct {
+	goArg
+	n *int
+}
+
+func (v visits) index(i int) argValue { *v.n++; return v.goArg.index(i) }
+
+func TestArgLimitsStopEarly(t *testing.T) {
+	// The walk stops as soon as the payload is exceeded, so a huge argument
+	// isn't visited in full.
+	huge := make([]interface{}, 100000)
+	for i := range huge {
+		huge[i] = "item"
+	}
+	n := 0
+	err := ArgLimits{MaxPayload: 100}.checkValues([]argValue{visits{goArg{huge}, &n}})
+	assert.IsType(t, &LimitError{}, err)
+	assert.True(t, n < 20, "visited %d elements", n)
+}
@@ -0,0 +1,170 @@
+package promise
+
+import (
+	"errors"
+	"sync"
+)
+
+// ErrBatchLength is the rejection reason for the keys of a batch whose
+// BatchFunc returned a different number of values than it was given keys.
+var ErrBatchLength = errors.New("promise: batch function returned the wrong number of values")
+
+// BatchFunc loads the values for keys at once, e.g. with a single request
+// through the bridge.  It must return one value per key, in the same order.
+// A value that is an error rejects the promise for its key only, while a
+// non-nil error rejects the promises for all of the keys.
+type BatchFunc func(keys []interface{}) ([]interface{}, error)
+
+// LoaderOptions configures a Loader.  The zero value batches every key
+// requested before the batch runs and caches all values.
+type LoaderOptions struct {
+	// MaxBatchSize splits larger batches into several calls of the
+	// BatchFunc.  Zero means no limit.
+	MaxBatchSize int
+	// NoCache makes every Load request its key again, instead of returning
+	// the promise from an earlier Load of the same key.
+	NoCache bool
+	// Scheduler runs the batches.  The keys requested until a batch runs are
+	// included in it.  Defaults to the configured scheduler.
+	Scheduler Scheduler
+}
+
+// Loader coalesces individual loads of keys into calls of a BatchFunc, in the
+// manner of the DataLoader JS library: the keys passed to Load before the
+// pending batch runs are collected and loaded together, and each caller gets a
+// promise for its own value.  Values are cached by key, so that loading a key
+// again returns the same promise.  This avoids N+1 round trips when many parts
+// of a page load related data independently:
+//
+//	users := promise.NewLoader(func(ids []interface{}) ([]interface{}, error) {
+//		return api.GetUsers(ids) // one request
+//	}, promise.LoaderOptions{})
+//	for _, post := range posts {
+//		users.Load(post.AuthorID).Then(renderAuthor(post), nil)
+//	}
+//
+// Keys must be comparable.  Failed loads aren't cached, so they are retried by
+// the next Load.  A Loader is safe for concurrent use.
+type Loader struct {
+	batch BatchFunc
+	opts  LoaderOptions
+
+	mu      sync.Mutex
+	cache   map[interface{}]*Promise
+	keys    []interface{} // waiting for the next batch
+	waiting []*Promise    // the promises for keys
+}
+
+// NewLoader returns a Loader that loads keys with batch.
+func NewLoader(batch BatchFunc, opts LoaderOptions) *Loader {
+	if opts.Scheduler == nil {
+		opts.Scheduler = Config{}.resolved().Scheduler
+	}
+	return &Loader{batch: batch, opts: opts, cache: map[interface{}]*Promise{}}
+}
+
+// Load returns a promise for the value of key, which is loaded with the next
+// batch unless it is cached.
+func (l *Loader) Load(key interface{}) *Promise {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if p, ok := l.cache[key]; ok {
+		return p
+	}
+	p := newPromise()
+	if !l.opts.NoCache {
+		l.cache[key] = p
+	}
+	l.keys = append(l.keys, key)
+	l.waiting = append(l.waiting, p)
+	if len(l.keys) == 1 {
+		l.opts.Scheduler.Schedule(l.dispatch)
+	}
+	return p
+}
+
+// Prime caches value for key, unless the key is already cached, so that Load
+// doesn't request it.
+func (l *Loader) Prime(key, value interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, ok := l.cache[key]; !ok {
+		l.cache[key] = Resolved(value)
+	}
+}
+
+// Clear removes key from the cache, so that the next Load requests it again.
+func (l *Loader) Clear(key interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.cache, key)
+}
+
+// ClearAll empties the cache.
+func (l *Loader) ClearAll() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.cache = map[interface{}]*Promise{}
+}
+
+// dispatch loads the keys collected so far, in batches of at most
+// MaxBatchSize.
+func (l *Loader) dispatch() {
+	l.mu.Lock()
+	keys, waiting := l.keys, l.waiting
+	l.keys, l.waiting = nil, nil
+	l.mu.Unlock()
+
+	size := l.opts.MaxBatchSize
+	if size <= 0 {
+		size = len(keys)
+	}
+	for start := 0; start < len(keys); start += size {
+		end := start + size
+		if end > len(keys) {
+			end = len(keys)
+		}
+		l.load(keys[start:end], waiting[start:end])
+	}
+}
+
+// load calls the BatchFunc for keys and settles their promises.  A panic in
+// the BatchFunc rejects all of them with the panic value.
+func (l *Loader) load(keys []interface{}, waiting []*Promise) {
+	values, failure := l.call(keys)
+	if failure == nil && len(values) != len(keys) {
+		failure = ErrBatchLength
+	}
+	for i, p := range waiting {
+		reason := failure
+		if reason == nil {
+			if err, ok := values[i].(error); ok {
+				reason = err
+			}
+		}
+		if reason == nil {
+			p.Resolve(values[i])
+			continue
+		}
+		l.mu.Lock()
+		if l.cache[keys[i]] == p {
+			delete(l.cache, keys[i])
+		}
+		l.mu.Unlock()
+		p.Reject(reason)
+	}
+}
+
+// call calls the BatchFunc, returning its error or panic value as failure.
+func (l *Loader) call(keys []interface{}) (values []interface{}, failure interface{}) {
+	defer func() {
+		if x := recover(); x != nil {
+			failure = x
+		}
+	}()
+	values, err := l.batch(keys)
+	if err != nil {
+		return nil, err
+	}
+	return values, nil
+}
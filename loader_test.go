@@ -0,0 +1,81 @@
+package promise
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoader(t *testing.T) {
+	defer time.AfterFunc(time.Second, t.FailNow).Stop() // limit test to 1 second running time.
+
+	var queue []func()
+	sched := SchedulerFunc(func(task func()) { queue = append(queue, task) })
+	run := func() {
+		for len(queue) > 0 {
+			task := queue[0]
+			queue = queue[1:]
+			task()
+		}
+	}
+
+	var batches [][]interface{}
+	missing := errors.New("missing")
+	l := NewLoader(func(keys []interface{}) ([]interface{}, error) {
+		batches = append(batches, keys)
+		values := make([]interface{}, len(keys))
+		for i, k := range keys {
+			if k == "x" {
+				values[i] = missing
+			} else {
+				values[i] = k.(string) + "!"
+			}
+		}
+		return values, nil
+	}, LoaderOptions{Scheduler: sched, MaxBatchSize: 2})
+
+	a, b, a2, c, x := l.Load("a"), l.Load("b"), l.Load("a"), l.Load("c"), l.Load("x")
+	l.Prime("p", "primed")
+	assert.Equal(t, a, a2)
+	assert.Equal(t, 1, len(queue))
+	run()
+	assert.Equal(t, [][]interface{}{{"a", "b"}, {"c", "x"}}, batches)
+
+	for p, want := range map[*Promise]string{a: "a!", b: "b!", c: "c!", l.Load("p"): "primed"} {
+		val, ok := settled(p)
+		assert.True(t, ok)
+		assert.Equal(t, want, val)
+	}
+	val, ok := settled(x)
+	assert.False(t, ok)
+	assert.Equal(t, missing, val)
+
+	// Failures aren't cached; cleared keys are loaded again.
+	l.Load("x")
+	l.Clear("a")
+	l.Load("a")
+	l.Load("b")
+	run()
+	assert.Equal(t, []interface{}{"x", "a"}, batches[2])
+}
+
+func TestLoaderBatchErrors(t *testing.T) {
+	defer time.AfterFunc(time.Second, t.FailNow).Stop() // limit test to 1 second running time.
+
+	direct := SchedulerFunc(func(task func()) { go task() })
+	down := errors.New("down")
+	for _, test := range []struct {
+		batch BatchFunc
+		want  interface{}
+	}{
+		{func([]interface{}) ([]interface{}, error) { return nil, down }, down},
+		{func([]interface{}) ([]interface{}, error) { return []interface{}{1, 2}, nil }, ErrBatchLength},
+		{func([]interface{}) ([]interface{}, error) { panic("boom") }, "boom"},
+	} {
+		val, ok := settled(NewLoader(test.batch, LoaderOptions{Scheduler: direct, NoCache: true}).Load(1))
+		assert.False(t, ok)
+		assert.Equal(t, test.want, val)
+	}
+}
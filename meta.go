@@ -0,0 +1,56 @@
+package promise
+
+import (
+	"fmt"
+	"sort"
+)
+
+// WithMeta attaches the metadata value under key to p, and returns p for
+// chaining.  Promises returned by Then inherit their parent's metadata, so
+// correlation IDs, user IDs or feature flags attached at the start of an
+// operation reach everything that observes its promises: plugin hooks and
+// unhandled rejection reports (through Meta), and the graph (see Graph).
+// Setting metadata on a child doesn't affect its parent.
+func (p *Promise) WithMeta(key string, value interface{}) *Promise {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	meta := make(map[string]interface{}, len(p.meta)+1)
+	for k, v := range p.meta {
+		meta[k] = v
+	}
+	meta[key] = value
+	p.meta = meta
+	return p
+}
+
+// Meta returns the metadata attached to p under key, or nil if there is none.
+func (p *Promise) Meta(key string) interface{} {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.meta[key]
+}
+
+// AllMeta returns a copy of all of the metadata attached to p, or nil if there
+// is none.
+func (p *Promise) AllMeta() map[string]interface{} {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.meta) == 0 {
+		return nil
+	}
+	meta := make(map[string]interface{}, len(p.meta))
+	for k, v := range p.meta {
+		meta[k] = v
+	}
+	return meta
+}
+
+// metaLines formats meta as sorted "key=value" lines.
+func metaLines(meta map[string]interface{}) []string {
+	lines := make([]string, 0, len(meta))
+	for k, v := range meta {
+		lines = append(lines, fmt.Sprintf("%s=%v", k, v))
+	}
+	sort.Strings(lines)
+	return lines
+}
@@ -0,0 +1,35 @@
+package promise
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithMeta(t *testing.T) {
+	EnableGraph(true)
+	defer EnableGraph(false)
+
+	root := newPromise().WithMeta("requestID", "r-7").WithMeta("user", 42)
+	child := root.Then(nil, nil).WithMeta("user", 43)
+	assert.Equal(t, "r-7", child.Meta("requestID"))
+	assert.Equal(t, 43, child.Meta("user"))
+	assert.Equal(t, 42, root.Meta("user"))
+	assert.Nil(t, root.Meta("missing"))
+	assert.Nil(t, newPromise().AllMeta())
+
+	all := root.AllMeta()
+	all["user"] = 0
+	assert.Equal(t, map[string]interface{}{"requestID": "r-7", "user": 42}, root.AllMeta())
+
+	nodes, _ := Graph()
+	var found bool
+	for _, n := range nodes {
+		if n.Meta["user"] == 43 {
+			found = true
+			assert.Equal(t, "r-7", n.Meta["requestID"])
+		}
+	}
+	assert.True(t, found)
+	assert.Contains(t, GraphDOT(), `pending\nrequestID=r-7\nuser=43"`)
+}
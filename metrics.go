@@ -0,0 +1,117 @@
+package promise
+
+import (
+	"sync"
+	"time"
+
+	"github.com/gopherjs/gopherjs/js"
+)
+
+// FuncStats summarizes the calls made to a promisified function.
+type FuncStats struct {
+	Calls  int64 // completed calls
+	Errors int64 // calls that returned an error
+	Total  time.Duration
+	Max    time.Duration
+}
+
+// Mean returns the average duration of a call, or 0 if there were none.
+func (s FuncStats) Mean() time.Duration {
+	if s.Calls == 0 {
+		return 0
+	}
+	return s.Total / time.Duration(s.Calls)
+}
+
+var funcStats struct {
+	sync.Mutex
+	byName map[string]*FuncStats
+}
+
+// FunctionStats returns the statistics of each function wrapped by Promisify
+// that has been called, keyed by its Go name (e.g. "main.fetchUser"), since the
+// program started or since the last call to ResetStats.  Calls that panic are
+// not counted.
+func FunctionStats() map[string]FuncStats {
+	funcStats.Lock()
+	defer funcStats.Unlock()
+	stats := make(map[string]FuncStats, len(funcStats.byName))
+	for name, s := range funcStats.byName {
+		stats[name] = *s
+	}
+	return stats
+}
+
+func recordCall(name string, d time.Duration, failed bool) {
+	funcStats.Lock()
+	defer funcStats.Unlock()
+	if funcStats.byName == nil {
+		funcStats.byName = map[string]*FuncStats{}
+	}
+	s := funcStats.byName[name]
+	if s == nil {
+		s = &FuncStats{}
+		funcStats.byName[name] = s
+	}
+	s.Calls++
+	if failed {
+		s.Errors++
+	}
+	s.Total += d
+	if d > s.Max {
+		s.Max = d
+	}
+}
+
+func resetFuncStats() {
+	funcStats.Lock()
+	defer funcStats.Unlock()
+	funcStats.byName = nil
+}
+
+// ExportStats installs a JS object with a stats() method under the given
+// global name, so that JS monitoring can poll the health of the bridge:
+//
+//	promise.ExportStats("promiseBridge")
+//
+//	// In JS:
+//	const s = promiseBridge.stats();
+//	console.log(s.pending, s.rejectionRate, s.functions["main.fetchUser"].meanMs);
+//
+// stats() returns the Counters as lowerCamel properties, plus "pending" (the
+// promises created but not yet settled), "rejectionRate" (the fraction of
+// settled promises that were rejected) and "functions", which maps the names
+// from FunctionStats to {calls, errors, meanMs, maxMs}.
+func ExportStats(name string) {
+	o := js.Global.Get("Object").New()
+	o.Set("stats", func() map[string]interface{} { return statsReport(Stats(), FunctionStats()) })
+	js.Global.Set(name, o)
+}
+
+// statsReport builds the result of the JS stats() method.
+func statsReport(c Counters, funcs map[string]FuncStats) map[string]interface{} {
+	rate := 0.0
+	if c.Settled > 0 {
+		rate = float64(c.Rejected) / float64(c.Settled)
+	}
+	functions := map[string]interface{}{}
+	for name, s := range funcs {
+		functions[name] = map[string]interface{}{
+			"calls":  s.Calls,
+			"errors": s.Errors,
+			"meanMs": s.Mean().Seconds() * 1000,
+			"maxMs":  s.Max.Seconds() * 1000,
+		}
+	}
+	return map[string]interface{}{
+		"created":       c.Created,
+		"settled":       c.Settled,
+		"rejected":      c.Rejected,
+		"pending":       c.Created - c.Settled,
+		"handlers":      c.Handlers,
+		"adoptions":     c.Adoptions,
+		"goroutines":    c.Goroutines,
+		"rejectionRate": rate,
+		"functions":     functions,
+	}
+}
@@ -0,0 +1,24 @@
+package promise
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFunctionStats(t *testing.T) {
+	resetFuncStats()
+	recordCall("f", 10*time.Millisecond, false)
+	recordCall("f", 30*time.Millisecond, true)
+	stats := FunctionStats()
+	assert.Equal(t, FuncStats{Calls: 2, Errors: 1, Total: 40 * time.Millisecond, Max: 30 * time.Millisecond}, stats["f"])
+	assert.Equal(t, 20*time.Millisecond, stats["f"].Mean())
+	assert.Equal(t, time.Duration(0), FuncStats{}.Mean())
+
+	report := statsReport(Counters{Created: 5, Settled: 4, Rejected: 1}, stats)
+	assert.Equal(t, int64(1), report["pending"])
+	assert.Equal(t, 0.25, report["rejectionRate"])
+	assert.Equal(t, 20.0, report["functions"].(map[string]interface{})["f"].(map[string]interface{})["meanMs"])
+	resetFuncStats()
+}
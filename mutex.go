@@ -0,0 +1,80 @@
+package promise
+
+import "sync"
+
+// Mutex is a mutual exclusion lock for asynchronous critical sections, such as
+// refreshing an auth token, where the lock must be held across several steps
+// of a chain.  The zero value is an unlocked mutex ready to use.
+type Mutex struct {
+	sem Semaphore
+}
+
+// Lock returns a promise that is resolved with an unlock function (of type
+// func()) once the mutex is acquired.  Waiters acquire the mutex in FIFO
+// order.  As with Semaphore.Acquire, rejecting the returned promise abandons
+// the attempt to lock.
+func (m *Mutex) Lock() *Promise { return m.sem.Acquire() }
+
+// WithLock runs fn while holding the mutex and returns a promise that settles
+// the same way as fn's promise.  The mutex is unlocked once fn's promise
+// settles, whether it was fulfilled or rejected.
+func (m *Mutex) WithLock(fn func() *Promise) *Promise { return withLock(m.Lock(), fn) }
+
+// KeyedMutex is a set of independent mutexes identified by a key, for example
+// to serialize the updates to each record of a store.  Mutexes are created on
+// demand and discarded while unused.  The zero value is ready to use.
+type KeyedMutex struct {
+	mu    sync.Mutex
+	locks map[interface{}]*Semaphore
+}
+
+// Lock returns a promise that is resolved with an unlock function (of type
+// func()) once the mutex for key is acquired.  See Mutex.Lock.
+func (k *KeyedMutex) Lock(key interface{}) *Promise {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if k.locks == nil {
+		k.locks = map[interface{}]*Semaphore{}
+	}
+	s := k.locks[key]
+	if s == nil {
+		s = &Semaphore{}
+		s.onIdle = func() { k.discard(key, s) }
+		k.locks[key] = s
+	}
+	// Acquire while holding k.mu so that s can't be discarded in between.
+	return s.Acquire()
+}
+
+// WithLock runs fn while holding the mutex for key.  See Mutex.WithLock.
+func (k *KeyedMutex) WithLock(key interface{}, fn func() *Promise) *Promise {
+	return withLock(k.Lock(key), fn)
+}
+
+// discard removes the semaphore for key if it is still unused.
+func (k *KeyedMutex) discard(key interface{}, s *Semaphore) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if k.locks[key] == s && s.idle() {
+		delete(k.locks, key)
+	}
+}
+
+// withLock calls fn once lock is resolved and calls the resolved unlock
+// function once fn's promise settles.
+func withLock(lock *Promise, fn func() *Promise) *Promise {
+	result := newPromise()
+	lock.Then(func(unlock interface{}) interface{} {
+		call(fn).Then(
+			func(val interface{}) interface{} {
+				unlock.(func())()
+				return result.Resolve(val)
+			},
+			func(err interface{}) interface{} {
+				unlock.(func())()
+				return result.Reject(err)
+			})
+		return unlock
+	}, result.Reject)
+	return result
+}
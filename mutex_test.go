@@ -0,0 +1,61 @@
+package promise
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMutex(t *testing.T) {
+	defer time.AfterFunc(time.Second, t.FailNow).Stop() // limit test to 1 second running time.
+
+	var m Mutex
+	var g gauge
+	a := m.WithLock(g.task("a", false))
+	b := m.WithLock(g.task("b", true))
+	c := m.WithLock(func() *Promise { panic("c") })
+	d := m.WithLock(g.task("d", false))
+
+	val, ok := settled(d)
+	assert.True(t, ok)
+	assert.Equal(t, "d", val)
+	assert.Equal(t, 1, g.max)
+	val, _ = settled(a)
+	assert.Equal(t, "a", val)
+	val, ok = settled(b)
+	assert.False(t, ok)
+	assert.Equal(t, "b", val)
+	val, ok = settled(c)
+	assert.False(t, ok)
+	assert.Equal(t, "c", val)
+
+	// The mutex was unlocked after every outcome.
+	unlock, ok := settled(m.Lock())
+	assert.True(t, ok)
+	unlock.(func())()
+}
+
+func TestKeyedMutex(t *testing.T) {
+	defer time.AfterFunc(time.Second, t.FailNow).Stop() // limit test to 1 second running time.
+
+	var k KeyedMutex
+	var ga, gb gauge
+	var results []*Promise
+	for i := 0; i < 3; i++ {
+		results = append(results, k.WithLock("a", ga.task(i, false)))
+		results = append(results, k.WithLock("b", gb.task(i, false)))
+	}
+	for _, p := range results {
+		_, ok := settled(p)
+		assert.True(t, ok)
+	}
+	assert.Equal(t, 1, ga.max)
+	assert.Equal(t, 1, gb.max)
+
+	// Unused mutexes are discarded.
+	time.Sleep(10 * time.Millisecond)
+	k.mu.Lock()
+	assert.Empty(t, k.locks)
+	k.mu.Unlock()
+}
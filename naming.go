@@ -0,0 +1,21 @@
+package promise
+
+// NamingPolicy maps the Go names of struct fields to the names of the
+// corresponding JS properties, see Config.FieldNaming.  A json tag on a field
+// overrides the policy for that field.
+type NamingPolicy func(goName string) string
+
+var (
+	// LowerCamelCase names properties like JS APIs conventionally do, treating
+	// a leading initialism as a single word: Name -> name, UserID -> userID,
+	// HTTPGet -> httpGet.
+	LowerCamelCase NamingPolicy = jsName
+	// GoNames names properties exactly like the fields.
+	GoNames NamingPolicy = func(goName string) string { return goName }
+)
+
+// fieldNaming returns the naming policy of the global configuration, for
+// code that converts values outside of promisified calls.
+func fieldNaming() NamingPolicy {
+	return Config{}.resolved().FieldNaming
+}
@@ -0,0 +1,90 @@
+package promise
+
+import (
+	"time"
+
+	"github.com/gopherjs/gopherjs/js"
+)
+
+// Position is a geolocation fix, see CurrentPosition.
+type Position struct {
+	Latitude, Longitude float64  // in degrees
+	Accuracy            float64  // in meters
+	Altitude            *float64 // in meters, if available
+	Heading, Speed      *float64 // in degrees and meters per second, if available
+	Timestamp           time.Time
+}
+
+// PositionOptions configures CurrentPosition.
+type PositionOptions struct {
+	HighAccuracy bool
+	Timeout      time.Duration // zero means no timeout
+	MaxAge       time.Duration // accept a cached position up to this old
+}
+
+// CurrentPosition returns a promise for the current Position of the device
+// from navigator.geolocation.  It is rejected with a *js.Error if the user
+// denies access or the position can't be determined.
+func CurrentPosition(opts PositionOptions) *Promise {
+	p := newPromise()
+	o := js.Global.Get("Object").New()
+	o.Set("enableHighAccuracy", opts.HighAccuracy)
+	if opts.Timeout > 0 {
+		o.Set("timeout", opts.Timeout.Seconds()*1000)
+	}
+	o.Set("maximumAge", opts.MaxAge.Seconds()*1000)
+	js.Global.Get("navigator").Get("geolocation").Call("getCurrentPosition",
+		func(pos *js.Object) { p.Resolve(position(pos)) },
+		func(err *js.Object) {
+			p.Reject(&js.Error{Object: js.Global.Get("Error").New(err.Get("message"))})
+		},
+		o)
+	return p
+}
+
+func position(pos *js.Object) Position {
+	c := pos.Get("coords")
+	optional := func(name string) *float64 {
+		if v := c.Get(name); v != nil && v != js.Undefined {
+			f := v.Float()
+			return &f
+		}
+		return nil
+	}
+	ms := pos.Get("timestamp").Int64()
+	return Position{
+		Latitude:  c.Get("latitude").Float(),
+		Longitude: c.Get("longitude").Float(),
+		Accuracy:  c.Get("accuracy").Float(),
+		Altitude:  optional("altitude"),
+		Heading:   optional("heading"),
+		Speed:     optional("speed"),
+		Timestamp: time.Unix(ms/1000, ms%1000*int64(time.Millisecond)),
+	}
+}
+
+// ReadClipboard returns a promise for the text on the clipboard.  Browsers
+// only allow this with the user's permission.
+func ReadClipboard() *Promise {
+	return fromJS(clipboard().Call("readText")).Then(
+		func(text interface{}) interface{} { return text.(*js.Object).String() }, nil)
+}
+
+// WriteClipboard returns a promise that is resolved once text has been written
+// to the clipboard.
+func WriteClipboard(text string) *Promise {
+	return fromJS(clipboard().Call("writeText", text)).Then(
+		func(interface{}) interface{} { return nil }, nil)
+}
+
+func clipboard() *js.Object { return js.Global.Get("navigator").Get("clipboard") }
+
+// QueryPermission returns a promise for the state of the named permission
+// (such as "geolocation" or "clipboard-read"): "granted", "denied" or
+// "prompt".
+func QueryPermission(name string) *Promise {
+	desc := js.Global.Get("Object").New()
+	desc.Set("name", name)
+	return fromJS(js.Global.Get("navigator").Get("permissions").Call("query", desc)).Then(
+		func(status interface{}) interface{} { return status.(*js.Object).Get("state").String() }, nil)
+}
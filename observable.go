@@ -0,0 +1,370 @@
+package promise
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+	"sync/atomic"
+)
+
+// ErrNoValues is the error reported by First when the source completes without
+// producing any value.
+var ErrNoValues = errors.New("promise: observable completed without values")
+
+// Observer receives the notifications of an Observable: any number of values
+// followed by at most one error or completion.  Any of the functions may be
+// nil.
+type Observer struct {
+	Next     func(value interface{})
+	Error    func(err interface{})
+	Complete func()
+}
+
+// Observable is a stream of values over time, for modelling things that a
+// single Promise can't, such as events or the contents of a Go channel.  Each
+// subscription receives values until the observable errors or completes, or
+// until the subscriber unsubscribes.
+type Observable struct {
+	subscribe func(o Observer) (unsubscribe func())
+}
+
+// NewObservable returns an observable whose values are produced by subscribe,
+// which is called once for each subscriber.  subscribe emits values by calling
+// the observer's functions (from any goroutine) and returns a function that
+// stops producing values for that subscriber, or nil if there is nothing to
+// stop.  Notifications after an error or completion are ignored.
+func NewObservable(subscribe func(o Observer) (unsubscribe func())) *Observable {
+	return &Observable{subscribe}
+}
+
+// Subscribe registers o to receive the observable's notifications and returns
+// a function that cancels the subscription.
+func (obs *Observable) Subscribe(o Observer) (unsubscribe func()) {
+	s := &subscription{observer: o}
+	stop := obs.subscribe(Observer{Next: s.next, Error: s.error, Complete: s.complete})
+	return func() {
+		if s.close() && stop != nil {
+			stop()
+		}
+	}
+}
+
+// subscription guards an observer against notifications after it is closed.
+type subscription struct {
+	mu       sync.Mutex
+	closed   bool
+	observer Observer
+}
+
+func (s *subscription) close() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	wasOpen := !s.closed
+	s.closed = true
+	return wasOpen
+}
+
+func (s *subscription) next(val interface{}) {
+	s.mu.Lock()
+	closed := s.closed
+	s.mu.Unlock()
+	if !closed && s.observer.Next != nil {
+		s.observer.Next(val)
+	}
+}
+
+func (s *subscription) error(err interface{}) {
+	if s.close() && s.observer.Error != nil {
+		s.observer.Error(err)
+	}
+}
+
+func (s *subscription) complete() {
+	if s.close() && s.observer.Complete != nil {
+		s.observer.Complete()
+	}
+}
+
+// ToPromise subscribes to the observable and returns a promise that is
+// resolved with the last value once it completes (nil if there were no
+// values), or rejected with its error.
+func (obs *Observable) ToPromise() *Promise {
+	p := newPromise()
+	var mu sync.Mutex
+	var last interface{}
+	obs.Subscribe(Observer{
+		Next: func(val interface{}) {
+			mu.Lock()
+			last = val
+			mu.Unlock()
+		},
+		Error: func(err interface{}) { p.Reject(err) },
+		Complete: func() {
+			mu.Lock()
+			defer mu.Unlock()
+			p.Resolve(last)
+		},
+	})
+	return p
+}
+
+// FromPromise returns an observable that emits the value of p and completes,
+// or errors with its rejection reason.
+func FromPromise(p *Promise) *Observable {
+	return NewObservable(func(o Observer) func() {
+		p.Then(
+			func(val interface{}) interface{} {
+				o.Next(val)
+				o.Complete()
+				return val
+			},
+			func(err interface{}) interface{} {
+				o.Error(err)
+				return err
+			})
+		return nil
+	})
+}
+
+// FromChannel returns an observable that emits the values received from ch,
+// which must be a channel that can be received from, and completes when ch
+// is closed.  Since a channel's values can only be received once, the values
+// are shared by all of the current subscribers: the channel is read from the
+// first subscription on, and values received while there are no subscribers
+// are dropped.  FromChannel panics if ch isn't a channel.
+func FromChannel(ch interface{}) *Observable {
+	c := reflect.ValueOf(ch)
+	if c.Kind() != reflect.Chan || c.Type().ChanDir()&reflect.RecvDir == 0 {
+		panic("FromChannel: not a receivable channel: " + c.Type().String())
+	}
+	b := &broadcast{}
+	var once sync.Once
+	return NewObservable(func(o Observer) func() {
+		id := b.add(o)
+		once.Do(func() {
+			atomic.AddInt64(&counters.Goroutines, 1)
+			go func() {
+				for {
+					val, ok := c.Recv()
+					if !ok {
+						b.complete()
+						return
+					}
+					b.next(val.Interface())
+				}
+			}()
+		})
+		return func() { b.remove(id) }
+	})
+}
+
+// broadcast distributes notifications to a changing set of observers, and
+// replays the error or completion to observers that arrive late.
+type broadcast struct {
+	mu        sync.Mutex
+	observers map[int]Observer
+	nextID    int
+	done      bool
+	failed    bool
+	err       interface{}
+}
+
+func (b *broadcast) add(o Observer) int {
+	b.mu.Lock()
+	if b.done {
+		failed, err := b.failed, b.err
+		b.mu.Unlock()
+		if failed {
+			o.Error(err)
+		} else {
+			o.Complete()
+		}
+		return -1
+	}
+	defer b.mu.Unlock()
+	if b.observers == nil {
+		b.observers = map[int]Observer{}
+	}
+	b.nextID++
+	b.observers[b.nextID] = o
+	return b.nextID
+}
+
+func (b *broadcast) remove(id int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.observers, id)
+}
+
+func (b *broadcast) snapshot() []Observer {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	observers := make([]Observer, 0, len(b.observers))
+	for _, o := range b.observers {
+		observers = append(observers, o)
+	}
+	return observers
+}
+
+func (b *broadcast) next(val interface{}) {
+	for _, o := range b.snapshot() {
+		o.Next(val)
+	}
+}
+
+func (b *broadcast) complete() { b.end(false, nil) }
+
+func (b *broadcast) error(err interface{}) { b.end(true, err) }
+
+// end notifies the current observers of completion (or the error, if failed)
+// and remembers it for later ones.  Only the first call has any effect.
+func (b *broadcast) end(failed bool, err interface{}) {
+	b.mu.Lock()
+	if b.done {
+		b.mu.Unlock()
+		return
+	}
+	b.done, b.failed, b.err = true, failed, err
+	observers := make([]Observer, 0, len(b.observers))
+	for _, o := range b.observers {
+		observers = append(observers, o)
+	}
+	b.observers = nil
+	b.mu.Unlock()
+	for _, o := range observers {
+		if failed {
+			o.Error(err)
+		} else {
+			o.Complete()
+		}
+	}
+}
+
+// ToChannel subscribes to the observable and sends its values on the returned
+// channel, which is closed when the observable errors or completes.  The
+// returned promise settles at the same time: it is resolved with nil on
+// completion or rejected with the error.  Sending blocks the producer while
+// the channel's buffer is full.  Values emitted after that are dropped.
+func (obs *Observable) ToChannel(buffer int) (<-chan interface{}, *Promise) {
+	ch := make(chan interface{}, buffer)
+	done := newPromise()
+	var mu sync.Mutex // guards the sends and the close of ch
+	closed := false
+	finish := func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		if closed {
+			return false
+		}
+		closed = true
+		close(ch)
+		return true
+	}
+	obs.Subscribe(Observer{
+		Next: func(val interface{}) {
+			mu.Lock()
+			defer mu.Unlock()
+			if !closed {
+				ch <- val
+			}
+		},
+		Error: func(err interface{}) {
+			if finish() {
+				done.Reject(err)
+			}
+		},
+		Complete: func() {
+			if finish() {
+				done.Resolve(nil)
+			}
+		},
+	})
+	return ch, done
+}
+
+// Map returns an observable that emits fn(v) for each value v of obs.
+func (obs *Observable) Map(fn func(value interface{}) interface{}) *Observable {
+	return obs.lift(func(o Observer, val interface{}) { o.Next(fn(val)) })
+}
+
+// Filter returns an observable that emits the values of obs for which keep
+// returns true.
+func (obs *Observable) Filter(keep func(value interface{}) bool) *Observable {
+	return obs.lift(func(o Observer, val interface{}) {
+		if keep(val) {
+			o.Next(val)
+		}
+	})
+}
+
+// Take returns an observable that emits the first n values of obs and then
+// completes.
+func (obs *Observable) Take(n int) *Observable {
+	return NewObservable(func(o Observer) func() {
+		if n <= 0 {
+			o.Complete()
+			return nil
+		}
+		var mu sync.Mutex
+		taken := 0
+		var stop func()
+		unsubscribe := obs.Subscribe(Observer{
+			Next: func(val interface{}) {
+				mu.Lock()
+				taken++
+				count, unsubscribe := taken, stop
+				mu.Unlock()
+				if count > n {
+					return
+				}
+				o.Next(val)
+				if count == n {
+					o.Complete()
+					if unsubscribe != nil {
+						unsubscribe()
+					}
+				}
+			},
+			Error:    o.Error,
+			Complete: o.Complete,
+		})
+		mu.Lock()
+		stop = unsubscribe
+		mu.Unlock()
+		return unsubscribe
+	})
+}
+
+// First returns an observable that emits the first value of obs and then
+// completes, or errors with ErrNoValues if obs completes without any values.
+func (obs *Observable) First() *Observable {
+	return NewObservable(func(o Observer) func() {
+		var got int32
+		return obs.Take(1).Subscribe(Observer{
+			Next: func(val interface{}) {
+				atomic.StoreInt32(&got, 1)
+				o.Next(val)
+			},
+			Error: o.Error,
+			Complete: func() {
+				if atomic.LoadInt32(&got) == 0 {
+					o.Error(ErrNoValues)
+				} else {
+					o.Complete()
+				}
+			},
+		})
+	})
+}
+
+// lift returns an observable that passes each value of obs through next and
+// forwards errors and completion.
+func (obs *Observable) lift(next func(o Observer, val interface{})) *Observable {
+	return NewObservable(func(o Observer) func() {
+		return obs.Subscribe(Observer{
+			Next:     func(val interface{}) { next(o, val) },
+			Error:    o.Error,
+			Complete: o.Complete,
+		})
+	})
+}
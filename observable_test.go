@@ -0,0 +1,132 @@
+package promise
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// numbers returns an observable that synchronously emits 1..n and completes.
+func numbers(n int) *Observable {
+	return NewObservable(func(o Observer) func() {
+		for i := 1; i <= n; i++ {
+			o.Next(i)
+		}
+		o.Complete()
+		return nil
+	})
+}
+
+func collect(obs *Observable) ([]interface{}, *Promise) {
+	ch, done := obs.ToChannel(100)
+	var vals []interface{}
+	for v := range ch {
+		vals = append(vals, v)
+	}
+	return vals, done
+}
+
+func TestObservableOperators(t *testing.T) {
+	defer time.AfterFunc(time.Second, t.FailNow).Stop() // limit test to 1 second running time.
+
+	vals, _ := collect(numbers(10).
+		Filter(func(v interface{}) bool { return v.(int)%2 == 0 }).
+		Map(func(v interface{}) interface{} { return v.(int) * 10 }).
+		Take(3))
+	assert.Equal(t, []interface{}{20, 40, 60}, vals)
+
+	vals, _ = collect(numbers(3).First())
+	assert.Equal(t, []interface{}{1}, vals)
+
+	_, done := collect(numbers(0).First())
+	err, ok := settled(done)
+	assert.False(t, ok)
+	assert.Equal(t, ErrNoValues, err)
+}
+
+func TestObservableToPromise(t *testing.T) {
+	defer time.AfterFunc(time.Second, t.FailNow).Stop() // limit test to 1 second running time.
+
+	val, ok := settled(numbers(4).ToPromise())
+	assert.True(t, ok)
+	assert.Equal(t, 4, val)
+
+	failure := errors.New("boom")
+	failing := NewObservable(func(o Observer) func() {
+		o.Next(1)
+		o.Error(failure)
+		o.Next(2) // ignored
+		return nil
+	})
+	val, ok = settled(failing.ToPromise())
+	assert.False(t, ok)
+	assert.Equal(t, failure, val)
+
+	vals, _ := collect(FromPromise(Try(func() (interface{}, error) { return "x", nil })))
+	assert.Equal(t, []interface{}{"x"}, vals)
+}
+
+func TestObservableFromChannel(t *testing.T) {
+	defer time.AfterFunc(time.Second, t.FailNow).Stop() // limit test to 1 second running time.
+
+	ch := make(chan int)
+	obs := FromChannel(ch)
+	a, doneA := obs.ToChannel(10)
+	b, doneB := obs.ToChannel(10)
+	ch <- 1
+	ch <- 2
+	close(ch)
+	_, okA := settled(doneA)
+	_, okB := settled(doneB)
+	assert.True(t, okA)
+	assert.True(t, okB)
+	assert.Equal(t, 2, len(a))
+	assert.Equal(t, 2, len(b))
+
+	// Late subscribers see the completion.
+	_, ok := settled(obs.ToPromise())
+	assert.True(t, ok)
+
+	assert.Panics(t, func() { FromChannel(42) })
+}
+
+func TestObservableUnsubscribe(t *testing.T) {
+	defer time.AfterFunc(time.Second, t.FailNow).Stop() // limit test to 1 second running time.
+
+	stopped := make(chan bool, 1)
+	var emit func(interface{})
+	obs := NewObservable(func(o Observer) func() {
+		emit = o.Next
+		return func() { stopped <- true }
+	})
+	var got []interface{}
+	unsubscribe := obs.Subscribe(Observer{Next: func(v interface{}) { got = append(got, v) }})
+	emit(1)
+	unsubscribe()
+	unsubscribe()
+	emit(2)
+	assert.Equal(t, []interface{}{1}, got)
+	assert.Equal(t, 1, len(stopped))
+}
+
+func TestObservableToChannelConcurrentComplete(t *testing.T) {
+	defer time.AfterFunc(time.Second, t.FailNow).Stop() // limit test to 1 second running time.
+
+	// Values emitted while the observable completes on another goroutine are
+	// either received or dropped, never sent on the closed channel.
+	for i := 0; i < 100; i++ {
+		obs := NewObservable(func(o Observer) func() {
+			for j := 0; j < 5; j++ {
+				go o.Next(j)
+			}
+			go o.Complete()
+			return nil
+		})
+		vals, done := collect(obs)
+		assert.True(t, len(vals) <= 5)
+		_, ok := settled(done)
+		assert.True(t, ok)
+	}
+}
@@ -0,0 +1,110 @@
+package promise
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+	"sync/atomic"
+)
+
+// StackCapture selects whether a promise records the stack of its creation.
+type StackCapture int
+
+const (
+	// StackDefault follows Config.CaptureStacks.
+	StackDefault StackCapture = iota
+	// StackOn always records the creation stack.
+	StackOn
+	// StackOff never records the creation stack.
+	StackOff
+)
+
+// Options configures a promise created by NewWith.  The zero value of each
+// field selects the behavior configured with Configure.
+type Options struct {
+	Label     string       // see SetLabel
+	Scheduler Scheduler    // runs the promise's callbacks
+	Token     *CancelToken // see WithToken
+	Stack     StackCapture // whether to record the creation stack
+	// Synchronous runs callbacks registered after the promise settled right
+	// away, see SetSynchronous.
+	Synchronous bool
+	// ConcurrentHandlers dispatches each callback of the promise as a separate
+	// task, see SetConcurrentHandlers.
+	ConcurrentHandlers bool
+	// Priority schedules the callbacks of the promise, see SetPriority.
+	Priority int
+}
+
+// NewWith creates a promise with opts and calls executor synchronously with
+// functions that resolve or reject it, like the JS Promise constructor.  Only
+// the first call of either function has any effect, and a panic in executor
+// rejects the promise with the panic value (if it isn't settled yet).
+//
+// NewWith lets individual promises opt out of (or into) settings that are
+// enabled globally, e.g. stack capture for promises on a hot path:
+//
+//	p := promise.NewWith(promise.Options{Stack: promise.StackOff},
+//		func(resolve, reject func(interface{})) { ... })
+func NewWith(opts Options, executor func(resolve, reject func(value interface{}))) (p *Promise) {
+	p = newPromiseWith(opts)
+	var settled int32
+	settle := func(settle func(interface{}) interface{}) func(interface{}) {
+		return func(value interface{}) {
+			if atomic.CompareAndSwapInt32(&settled, 0, 1) {
+				settle(value)
+			}
+		}
+	}
+	resolve, reject := settle(p.Resolve), settle(p.Reject)
+	defer func() {
+		if x := recover(); x != nil {
+			reject(x)
+		}
+	}()
+	executor(resolve, reject)
+	return p
+}
+
+// newPromiseWith returns a new pending promise configured by opts.
+func newPromiseWith(opts Options) *Promise {
+	c := Config{Scheduler: opts.Scheduler}.merge(CurrentConfig())
+	p := newPromiseConfig(&c, opts.Stack)
+	p.label = opts.Label
+	if opts.Synchronous {
+		p.synchronous = true
+	}
+	p.concurrent, p.priority = opts.ConcurrentHandlers, opts.Priority
+	if opts.Token != nil {
+		p.WithToken(opts.Token)
+	}
+	return p
+}
+
+// maxStackDepth bounds the number of frames recorded for creation stacks.
+const maxStackDepth = 32
+
+// captureStack records the stack of the caller's caller, skipping the frames
+// inside this package's constructors.
+func captureStack() []uintptr {
+	pcs := make([]uintptr, maxStackDepth)
+	return pcs[:runtime.Callers(4, pcs)]
+}
+
+// CreationStack returns the stack at which p was created, one frame per line,
+// if stack capture was enabled for it, or "" otherwise.
+func (p *Promise) CreationStack() string {
+	if len(p.stack) == 0 {
+		return ""
+	}
+	var lines []string
+	frames := runtime.CallersFrames(p.stack)
+	for {
+		frame, more := frames.Next()
+		lines = append(lines, fmt.Sprintf("%s\n\t%s:%d", frame.Function, frame.File, frame.Line))
+		if !more {
+			break
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,45 @@
+package promise
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewWith(t *testing.T) {
+	defer time.AfterFunc(time.Second, t.FailNow).Stop() // limit test to 1 second running time.
+
+	p := NewWith(Options{Label: "answer"}, func(resolve, reject func(interface{})) {
+		resolve(42)
+		reject("ignored")
+	})
+	assert.Equal(t, "answer", p.Label())
+	val, ok := settled(p)
+	assert.True(t, ok)
+	assert.Equal(t, 42, val)
+
+	val, ok = settled(NewWith(Options{}, func(resolve, reject func(interface{})) { panic("oops") }))
+	assert.False(t, ok)
+	assert.Equal(t, "oops", val)
+
+	token := NewCancelToken()
+	p = NewWith(Options{Token: token}, func(resolve, reject func(interface{})) {})
+	token.Cancel("stop")
+	val, _ = settled(p)
+	assert.Equal(t, &CancelError{"stop"}, val)
+}
+
+func TestCreationStack(t *testing.T) {
+	defer Configure(Config{})
+
+	assert.Equal(t, "", newPromise().CreationStack())
+	off := func(resolve, reject func(interface{})) {}
+	stack := NewWith(Options{Stack: StackOn}, off).CreationStack()
+	assert.True(t, strings.Contains(stack, "TestCreationStack"), stack)
+
+	Configure(Config{CaptureStacks: true})
+	assert.True(t, strings.Contains(newPromise().CreationStack(), "TestCreationStack"))
+	assert.Equal(t, "", NewWith(Options{Stack: StackOff}, off).CreationStack())
+}
@@ -0,0 +1,101 @@
+package promise
+
+import (
+	"context"
+	"io"
+	"reflect"
+
+	"github.com/gopherjs/gopherjs/js"
+)
+
+var writerType = reflect.TypeOf((*io.Writer)(nil)).Elem()
+
+// output is the io.Writer supplied to promisified functions with an io.Writer
+// parameter.  Its writes are forwarded to the OnOutput listeners of the
+// promise of the call, so that functions can produce incremental output (logs,
+// generated files, ...) rather than one final value.  For example:
+//
+//	func build(w io.Writer, target string) error {
+//		fmt.Fprintf(w, "building %s\n", target)
+//		...
+//	}
+//
+// can be called from JS as:
+//
+//	build("app").onData(chunk => log.append(decoder.decode(chunk))).then(...)
+//
+// or have its output written to a WritableStream:
+//
+//	build("app").pipeTo(fileStream).then(...)
+type output struct {
+	p *Promise
+}
+
+// Write sends a copy of b to the output listeners of the promise.  It fails
+// with io.ErrClosedPipe once the promise has settled.
+func (w output) Write(b []byte) (int, error) {
+	if !w.p.IsPending() {
+		return 0, io.ErrClosedPipe
+	}
+	w.p.mu.Lock()
+	listeners := w.p.output
+	w.p.mu.Unlock()
+	chunk := append([]byte(nil), b...)
+	for _, fn := range listeners {
+		fn(chunk)
+	}
+	return len(b), nil
+}
+
+// OnOutput registers fn to be called with the chunks written by the
+// promisified function producing p to its io.Writer parameter, and returns p
+// for chaining.  The chunks are delivered synchronously and fn may retain
+// them.
+func (p *Promise) OnOutput(fn func(chunk []byte)) *Promise {
+	p.mu.Lock()
+	p.output = append(p.output, fn)
+	p.mu.Unlock()
+	return p
+}
+
+// pipeTo writes the output of p to the JS WritableStream stream, closing it
+// when p is fulfilled and aborting it when p is rejected.  It returns a promise
+// that settles like p once the stream is closed or aborted.
+func (p *Promise) pipeTo(stream *js.Object) *Promise {
+	writer := stream.Call("getWriter")
+	p.OnOutput(func(chunk []byte) { writer.Call("write", chunk) })
+	piped := newPromise()
+	p.Then(func(value interface{}) interface{} {
+		fromJS(writer.Call("close")).Then(func(interface{}) interface{} {
+			piped.Resolve(value)
+			return nil
+		}, func(reason interface{}) interface{} {
+			piped.Reject(reason)
+			return nil
+		})
+		return nil
+	}, func(reason interface{}) interface{} {
+		writer.Call("abort", reason)
+		piped.Reject(reason)
+		return nil
+	})
+	return piped
+}
+
+// injectedValue returns the value of an injected parameter of type t for the
+// call producing p.
+func injectedValue(t reflect.Type, p *Promise) reflect.Value {
+	switch t {
+	case writerType:
+		return reflect.ValueOf(output{p})
+	case contextType:
+		ctx, cancel := context.WithCancel(ContextOf(p))
+		p.onStop(func(interface{}) { cancel() })
+		return reflect.ValueOf(&ctx).Elem()
+	case cancelTokenType:
+		token := NewCancelToken()
+		p.onStop(token.Cancel)
+		return reflect.ValueOf(token)
+	}
+	return reflect.ValueOf(&Progress{p})
+}
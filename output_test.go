@@ -0,0 +1,53 @@
+package promise
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOutput(t *testing.T) {
+	defer time.AfterFunc(time.Second, t.FailNow).Stop() // limit test to 1 second running time.
+
+	start := make(chan bool)
+	var leaked io.Writer
+	build := Method(func(w io.Writer, targets ...string) int {
+		<-start
+		buf := []byte("building ")
+		for _, target := range targets {
+			w.Write(append(buf, target...))
+		}
+		buf[0] = 'B' // writers must not retain the buffer
+		leaked = w
+		return len(targets)
+	})
+
+	var chunks []string
+	p := build("app", "lib").OnOutput(func(chunk []byte) { chunks = append(chunks, string(chunk)) })
+	close(start)
+	val, ok := settled(p)
+	assert.True(t, ok)
+	assert.Equal(t, 2, val)
+	assert.Equal(t, []string{"building app", "building lib"}, chunks)
+
+	_, err := fmt.Fprint(leaked, "late")
+	assert.Equal(t, io.ErrClosedPipe, err)
+	assert.Equal(t, 2, len(chunks))
+}
+
+func TestOutputSignature(t *testing.T) {
+	sig := Describe(func(w io.Writer, name string) error { return nil })
+	assert.Equal(t, []Param{{"arg1", "string"}}, sig.Params)
+	assert.True(t, sig.Streaming)
+
+	var p Promise
+	in, err := converter{}.convertArgs(reflect.TypeOf(func(name string, w io.Writer) {}), &p, nil)
+	assert.NoError(t, err)
+	if assert.Equal(t, 2, len(in)) {
+		assert.Equal(t, output{&p}, in[1].Interface())
+	}
+}
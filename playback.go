@@ -0,0 +1,207 @@
+package promise
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/gopherjs/gopherjs/js"
+)
+
+// ErrNotRecorded is the rejection reason for a promisified call made while
+// replaying (see Replay) that doesn't match any remaining recorded call.
+var ErrNotRecorded = errors.New("promise: call not recorded")
+
+// RecordedCall is a promisified call and its settlement as recorded by a
+// Recorder.  Arguments and results are stored as JSON, so a recording can be
+// saved with encoding/json and loaded again for Replay.
+type RecordedCall struct {
+	Func     string            `json:"func"` // the Go name of the function
+	Args     []json.RawMessage `json:"args"` // not including injected parameters
+	Value    json.RawMessage   `json:"value,omitempty"`
+	Reason   json.RawMessage   `json:"reason,omitempty"`
+	Rejected bool              `json:"rejected,omitempty"`
+}
+
+// Recorder records the calls of promisified functions, see Record.
+type Recorder struct {
+	mu    sync.Mutex
+	calls []RecordedCall
+}
+
+// Replayer serves recorded settlements, see Replay.
+type Replayer struct {
+	mu    sync.Mutex
+	calls []RecordedCall
+	used  []bool
+}
+
+var playback struct {
+	sync.Mutex
+	recorder *Recorder
+	replayer *Replayer
+}
+
+// Record starts recording every call of a promisified function: its
+// arguments, and the value it resolved with or the reason (as serialized for
+// JS) it was rejected with.  Calls that panic or time out are not recorded.
+// Recording stops with Stop, or when Record or Replay is called again.
+//
+//	rec := promise.Record()
+//	... // exercise the app against the live backend
+//	data, _ := json.Marshal(rec.Calls())
+func Record() *Recorder {
+	r := &Recorder{}
+	playback.Lock()
+	playback.recorder, playback.replayer = r, nil
+	playback.Unlock()
+	return r
+}
+
+// Stop stops recording, if r is still recording.
+func (r *Recorder) Stop() {
+	playback.Lock()
+	if playback.recorder == r {
+		playback.recorder = nil
+	}
+	playback.Unlock()
+}
+
+// Calls returns the calls recorded so far, in the order in which they
+// settled.
+func (r *Recorder) Calls() []RecordedCall {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]RecordedCall(nil), r.calls...)
+}
+
+// Replay makes promisified functions serve the settlements of calls, as
+// recorded by a Recorder, instead of calling the real Go functions, so that
+// frontend tests and bug reproductions run deterministically without live
+// backends.  Each call is matched with the first unused recorded call of the
+// same function with equal arguments; calls without a match are rejected
+// with ErrNotRecorded (as serialized for JS).  Replaying stops with Stop, or
+// when Record or Replay is called again.
+func Replay(calls []RecordedCall) *Replayer {
+	r := &Replayer{calls: calls, used: make([]bool, len(calls))}
+	playback.Lock()
+	playback.recorder, playback.replayer = nil, r
+	playback.Unlock()
+	return r
+}
+
+// Stop stops replaying, if r is still replaying.
+func (r *Replayer) Stop() {
+	playback.Lock()
+	if playback.replayer == r {
+		playback.replayer = nil
+	}
+	playback.Unlock()
+}
+
+// Unused returns the recorded calls that haven't been replayed, e.g. to check
+// that a test made every call that it was expected to.
+func (r *Replayer) Unused() []RecordedCall {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var unused []RecordedCall
+	for i, call := range r.calls {
+		if !r.used[i] {
+			unused = append(unused, call)
+		}
+	}
+	return unused
+}
+
+// match returns the first unused call matching name and args and marks it as
+// used.
+func (r *Replayer) match(name string, args []json.RawMessage) (RecordedCall, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, call := range r.calls {
+		if !r.used[i] && call.Func == name && equalJSON(call.Args, args) {
+			r.used[i] = true
+			return call, true
+		}
+	}
+	return RecordedCall{}, false
+}
+
+func equalJSON(a, b []json.RawMessage) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		var ca, cb bytes.Buffer
+		if json.Compact(&ca, a[i]) != nil || json.Compact(&cb, b[i]) != nil || !bytes.Equal(ca.Bytes(), cb.Bytes()) {
+			return false
+		}
+	}
+	return true
+}
+
+// playbackMode returns the active recorder or replayer, if any.
+func playbackMode() (*Recorder, *Replayer) {
+	playback.Lock()
+	defer playback.Unlock()
+	return playback.recorder, playback.replayer
+}
+
+// recordedArgs returns the JSON of the arguments of a call of a function of
+// type t, skipping the injected parameters.
+func recordedArgs(t reflect.Type, in []reflect.Value) []json.RawMessage {
+	args := []json.RawMessage{}
+	for i, v := range in {
+		if i < t.NumIn() && injected(t.In(i)) {
+			continue
+		}
+		args = append(args, toJSON(v.Interface()))
+	}
+	return args
+}
+
+// record adds a call of name with args that settled with value or reason.
+func (r *Recorder) record(name string, args []json.RawMessage, rejected bool, v interface{}) {
+	call := RecordedCall{Func: name, Args: args, Rejected: rejected}
+	if rejected {
+		call.Reason = toJSON(v)
+	} else {
+		call.Value = toJSON(v)
+	}
+	r.mu.Lock()
+	r.calls = append(r.calls, call)
+	r.mu.Unlock()
+}
+
+// replay settles p like call.
+func (call RecordedCall) replay(p *Promise) {
+	if call.Rejected {
+		p.Reject(fromJSON(call.Reason))
+	} else {
+		p.Resolve(fromJSON(call.Value))
+	}
+}
+
+// toJSON encodes v, using JSON.stringify for JS values.  Values that can't be
+// encoded are recorded as a string describing them.
+func toJSON(v interface{}) json.RawMessage {
+	if o, ok := v.(*js.Object); ok && o != nil && o != js.Undefined {
+		return json.RawMessage(js.Global.Get("JSON").Call("stringify", o).String())
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		data, _ = json.Marshal(fmt.Sprintf("%T", v))
+	}
+	return data
+}
+
+func fromJSON(data json.RawMessage) interface{} {
+	var v interface{}
+	if len(data) > 0 {
+		json.Unmarshal(data, &v)
+	}
+	return v
+}
@@ -0,0 +1,56 @@
+package promise
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecordReplay(t *testing.T) {
+	rec := Record()
+	typ := reflect.TypeOf(func(*Progress, string, ...int) {})
+	args := recordedArgs(typ, reflectAll(&Progress{}, "user", 1, 2))
+	rec.record("main.lookup", args, false, map[string]interface{}{"name": "Ada"})
+	rec.record("main.lookup", recordedArgs(typ, reflectAll(&Progress{}, "missing")), true, "not found")
+	rec.Stop()
+	recorder, _ := playbackMode()
+	assert.Nil(t, recorder)
+
+	// Recordings survive a round trip through JSON.
+	data, err := json.Marshal(rec.Calls())
+	assert.NoError(t, err)
+	assert.Equal(t, `[{"func":"main.lookup","args":["user",1,2],"value":{"name":"Ada"}},`+
+		`{"func":"main.lookup","args":["missing"],"reason":"not found","rejected":true}]`, string(data))
+	var calls []RecordedCall
+	assert.NoError(t, json.Unmarshal(data, &calls))
+
+	rep := Replay(calls)
+	defer rep.Stop()
+	_, replayer := playbackMode()
+	assert.True(t, replayer == rep)
+
+	_, ok := rep.match("main.lookup", []json.RawMessage{json.RawMessage(`"other"`)})
+	assert.False(t, ok)
+	call, ok := rep.match("main.lookup", []json.RawMessage{json.RawMessage(`"missing"`)})
+	assert.True(t, ok)
+	p := newPromise()
+	call.replay(p)
+	val, ok := settled(p)
+	assert.False(t, ok)
+	assert.Equal(t, "not found", val)
+
+	_, ok = rep.match("main.lookup", []json.RawMessage{json.RawMessage(`"missing"`)})
+	assert.False(t, ok, "each recorded call is replayed once")
+	assert.Len(t, rep.Unused(), 1)
+
+	call, ok = rep.match("main.lookup", []json.RawMessage{json.RawMessage(`"user"`), json.RawMessage(`1`), json.RawMessage(` 2 `)})
+	assert.True(t, ok)
+	p = newPromise()
+	call.replay(p)
+	val, ok = settled(p)
+	assert.True(t, ok)
+	assert.Equal(t, map[string]interface{}{"name": "Ada"}, val)
+	assert.Empty(t, rep.Unused())
+}
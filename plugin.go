@@ -0,0 +1,157 @@
+package promise
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"sync/atomic"
+
+	"github.com/gopherjs/gopherjs/js"
+)
+
+// Plugin bundles extensions of this package, so that third-party packages
+// (tracing, validation, RPC codecs, ...) can hook into it through a single
+// call to RegisterPlugin.  Every field is optional.
+type Plugin struct {
+	// Name identifies the plugin; it must be unique.
+	Name string
+
+	// Created is called with every new promise created by this package (such
+	// as the ones returned by Then), but not with zero-value Promises.
+	Created func(p *Promise)
+	// Settled is called when a promise is resolved or rejected.
+	Settled func(p *Promise, value interface{}, rejected bool)
+	// TransformRejection returns the reason to reject p with instead of
+	// reason, e.g. to strip internal details or attach a correlation ID.  The
+	// transformations of all plugins are applied in registration order to
+	// every rejection by Reject, including panics, timeouts and the results of
+	// failure callbacks, before Settled and any handler see it.  Rejections
+	// that are passed on unchanged to the promises returned by Then, or
+	// rethrown by a failure callback, are not transformed again.
+	TransformRejection func(p *Promise, reason interface{}) interface{}
+
+	// Converters are tried in order before the built-in rules when converting
+	// a JS argument for a parameter of type t of a promisified function.  A
+	// converter returns ok == false to decline.
+	Converters []func(arg *js.Object, t reflect.Type) (v reflect.Value, ok bool, err error)
+
+	// SerializeError converts an error for JS, unless it returns ok == false.
+	// It is used when Config.ErrorSerializer isn't set.
+	SerializeError func(err error) (v interface{}, ok bool)
+
+	// Methods are added to the JS wrappers of promises created by Js: each
+	// function receives the promise and returns the value of the property,
+	// typically a function.
+	Methods map[string]func(p *Promise) interface{}
+}
+
+var plugins struct {
+	mu    sync.Mutex
+	names map[string]bool
+	list  atomic.Value // []Plugin, read without locking on the hot path
+}
+
+// RegisterPlugin installs p.  Plugins should be registered during program
+// initialization, before promises are created.  RegisterPlugin panics if a
+// plugin with the same name is already registered.
+func RegisterPlugin(p Plugin) {
+	plugins.mu.Lock()
+	defer plugins.mu.Unlock()
+	if plugins.names[p.Name] {
+		panic(fmt.Errorf("RegisterPlugin: duplicate plugin %q", p.Name))
+	}
+	if plugins.names == nil {
+		plugins.names = map[string]bool{}
+	}
+	plugins.names[p.Name] = true
+	list := append([]Plugin(nil), registeredPlugins()...)
+	plugins.list.Store(append(list, p))
+}
+
+// unregisterPlugin removes the plugin called name, for tests.
+func unregisterPlugin(name string) {
+	plugins.mu.Lock()
+	defer plugins.mu.Unlock()
+	delete(plugins.names, name)
+	var list []Plugin
+	for _, p := range registeredPlugins() {
+		if p.Name != name {
+			list = append(list, p)
+		}
+	}
+	plugins.list.Store(list)
+}
+
+func registeredPlugins() []Plugin {
+	list, _ := plugins.list.Load().([]Plugin)
+	return list
+}
+
+func pluginsCreated(p *Promise) {
+	for _, plugin := range registeredPlugins() {
+		if plugin.Created != nil {
+			plugin.Created(p)
+		}
+	}
+}
+
+func pluginsSettled(p *Promise, value interface{}, rejected bool) {
+	for _, plugin := range registeredPlugins() {
+		if plugin.Settled != nil {
+			plugin.Settled(p, value, rejected)
+		}
+	}
+}
+
+func pluginsTransformRejection(p *Promise, reason interface{}) interface{} {
+	for _, plugin := range registeredPlugins() {
+		if plugin.TransformRejection != nil {
+			reason = plugin.TransformRejection(p, reason)
+		}
+	}
+	return reason
+}
+
+func pluginsConvert(arg *js.Object, t reflect.Type) (reflect.Value, bool, error) {
+	for _, plugin := range registeredPlugins() {
+		for _, convert := range plugin.Converters {
+			if v, ok, err := convert(arg, t); ok || err != nil {
+				return v, true, err
+			}
+		}
+	}
+	return reflect.Value{}, false, nil
+}
+
+// pluginsSerializeError converts err with the first plugin that accepts it,
+// falling back to the error's message.
+func pluginsSerializeError(err error) interface{} {
+	for _, plugin := range registeredPlugins() {
+		if plugin.SerializeError != nil {
+			if v, ok := plugin.SerializeError(err); ok {
+				return v
+			}
+		}
+	}
+	if errors.Is(err, ErrCanceled) {
+		return jsAbortError(err)
+	} else if errors.Is(err, ErrResolvedWithItself) {
+		return js.Global.Get("TypeError").New(err.Error())
+	} else if agg, ok := err.(AggregateError); ok && js.Global.Get("AggregateError") != js.Undefined {
+		reasons := make([]interface{}, len(agg.Errors))
+		for i, reason := range agg.Errors {
+			reasons[i] = jsReason(reason)
+		}
+		return js.Global.Get("AggregateError").New(reasons, err.Error())
+	}
+	return err.Error()
+}
+
+func pluginsMethods(p *Promise, o *js.Object) {
+	for _, plugin := range registeredPlugins() {
+		for name, method := range plugin.Methods {
+			o.Set(name, method(p))
+		}
+	}
+}
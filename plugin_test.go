@@ -0,0 +1,102 @@
+package promise
+
+import (
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// codedError is serialized by the test plugin.
+type codedError struct{ code int }
+
+func (e codedError) Error() string { return "coded" }
+
+func TestRegisterPlugin(t *testing.T) {
+	defer time.AfterFunc(time.Second, t.FailNow).Stop() // limit test to 1 second running time.
+
+	settledValues := make(chan interface{}, 10)
+	defer unregisterPlugin("test")
+	RegisterPlugin(Plugin{
+		Name: "test",
+		Settled: func(p *Promise, value interface{}, rejected bool) {
+			if value == "plugin-test" {
+				settledValues <- rejected
+			}
+		},
+		SerializeError: func(err error) (interface{}, bool) {
+			if e, ok := err.(codedError); ok {
+				return e.code, true
+			}
+			return nil, false
+		},
+	})
+	assert.Panics(t, func() { RegisterPlugin(Plugin{Name: "test"}) })
+
+	var p Promise
+	p.Reject("plugin-test")
+	assert.Equal(t, true, <-settledValues)
+
+	assert.Equal(t, 7, jsReason(codedError{7}))
+	assert.Equal(t, "promise: timed out", jsReason(ErrTimeout))
+
+	unregisterPlugin("test")
+	assert.Equal(t, "coded", jsReason(codedError{7}))
+}
+
+// internalReason is rewritten by the test plugin's TransformRejection.
+type internalReason string
+
+func TestTransformRejection(t *testing.T) {
+	defer time.AfterFunc(time.Second, t.FailNow).Stop() // limit test to 1 second running time.
+
+	var transforms int32
+	defer unregisterPlugin("transform")
+	RegisterPlugin(Plugin{
+		Name: "transform",
+		TransformRejection: func(p *Promise, reason interface{}) interface{} {
+			if r, ok := reason.(internalReason); ok {
+				atomic.AddInt32(&transforms, 1)
+				return "public: " + string(r)[:4]
+			}
+			return reason
+		},
+	})
+
+	p := newPromise()
+	child := p.Then(panicIfCalled, nil).Then(panicIfCalled, nil)
+	p.Reject(internalReason("oops at /srv/internal/db.go:12"))
+	val, ok := settled(child)
+	assert.False(t, ok)
+	assert.Equal(t, "public: oops", val)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&transforms))
+
+	// Panics and the results of failure callbacks are new rejections.
+	val, _ = settled(Resolved(1).Then(func(interface{}) interface{} { panic(internalReason("boom!")) }, nil))
+	assert.Equal(t, "public: boom", val)
+	val, _ = settled(p.Then(nil, func(interface{}) interface{} { return internalReason("again") }))
+	assert.Equal(t, "public: agai", val)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&transforms))
+}
+
+func TestTransformRejectionOnce(t *testing.T) {
+	defer time.AfterFunc(time.Second, t.FailNow).Stop() // limit test to 1 second running time.
+
+	var id int32
+	defer unregisterPlugin("cid")
+	RegisterPlugin(Plugin{
+		Name: "cid",
+		TransformRejection: func(p *Promise, reason interface{}) interface{} {
+			return fmt.Sprintf("[cid=%d] %v", atomic.AddInt32(&id, 1), reason)
+		},
+	})
+
+	// Reasons passed on by Finally or rethrown by Catch aren't new rejections.
+	failed := Rejected("boom")
+	val, _ := settled(failed.Finally(func() {}))
+	assert.Equal(t, "[cid=1] boom", val)
+	val, _ = settled(failed.Catch(func(reason interface{}) interface{} { return reason }))
+	assert.Equal(t, "[cid=1] boom", val)
+}
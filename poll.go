@@ -0,0 +1,64 @@
+package promise
+
+import (
+	"errors"
+	"sync/atomic"
+	"time"
+)
+
+// ErrTimeout is the rejection reason used when an operation in this package
+// doesn't complete within its allotted time.
+var ErrTimeout = errors.New("promise: timed out")
+
+// Poll calls fn repeatedly, waiting interval between calls, until fn reports
+// that it is done.  The returned promise is resolved with the value from the
+// call that reported done, or rejected with the error if fn returns a non-nil
+// error (or the panic value if fn panics).  The first call is made right
+// away.
+//
+// If timeout is positive and fn hasn't finished by then, polling stops and the
+// promise is rejected with ErrTimeout.  Canceling the promise stops polling
+// too, see Cancel.
+func Poll(fn func() (done bool, value interface{}, err error), interval, timeout time.Duration) *Promise {
+	return PollBackoff(fn, ConstantBackoff{interval}, timeout)
+}
+
+// PollBackoff is like Poll but waits between calls according to backoff.
+func PollBackoff(fn func() (done bool, value interface{}, err error), backoff Backoff, timeout time.Duration) *Promise {
+	p := NewCancelable(nil)
+	stop := make(chan struct{})
+	p.onSettle(func() { close(stop) })
+	var expired <-chan time.Time
+	if timeout > 0 {
+		expired = time.After(timeout)
+	}
+	atomic.AddInt64(&counters.Goroutines, 1)
+	go func() {
+		defer func() {
+			if x := recover(); x != nil {
+				p.Reject(x)
+			}
+		}()
+		var delay time.Duration
+		for attempt := 1; ; attempt++ {
+			done, value, err := fn()
+			if err != nil {
+				p.Reject(err)
+				return
+			} else if done {
+				p.Resolve(value)
+				return
+			}
+			delay = backoff.Delay(attempt, delay)
+			select {
+			case <-time.After(delay):
+			case <-expired:
+				p.Reject(ErrTimeout)
+				return
+			case <-stop:
+				return
+			}
+		}
+	}()
+	return p.Promise
+}
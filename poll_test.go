@@ -0,0 +1,83 @@
+package promise
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPoll(t *testing.T) {
+	defer time.AfterFunc(time.Second, t.FailNow).Stop() // limit test to 1 second running time.
+
+	calls := 0
+	val, ok := settled(Poll(func() (bool, interface{}, error) {
+		calls++
+		return calls == 3, calls, nil
+	}, time.Millisecond, time.Second))
+	assert.True(t, ok)
+	assert.Equal(t, 3, val)
+
+	failure := errors.New("job failed")
+	val, ok = settled(Poll(func() (bool, interface{}, error) {
+		return false, nil, failure
+	}, time.Millisecond, 0))
+	assert.False(t, ok)
+	assert.Equal(t, failure, val)
+
+	val, ok = settled(Poll(func() (bool, interface{}, error) {
+		return false, nil, nil
+	}, time.Millisecond, 20*time.Millisecond))
+	assert.False(t, ok)
+	assert.Equal(t, ErrTimeout, val)
+
+	val, ok = settled(Poll(func() (bool, interface{}, error) {
+		panic("oops")
+	}, time.Millisecond, 0))
+	assert.False(t, ok)
+	assert.Equal(t, "oops", val)
+}
+
+func TestPollBackoff(t *testing.T) {
+	defer time.AfterFunc(time.Second, t.FailNow).Stop() // limit test to 1 second running time.
+
+	var delays []time.Duration
+	calls := 0
+	val, ok := settled(PollBackoff(func() (bool, interface{}, error) {
+		calls++
+		return calls == 4, calls, nil
+	}, recordingBackoff{&delays, ExponentialBackoff{Initial: time.Millisecond}}, 0))
+	assert.True(t, ok)
+	assert.Equal(t, 4, val)
+	assert.Equal(t, []time.Duration{1, 2, 4}, scale(delays, time.Millisecond))
+}
+
+type recordingBackoff struct {
+	delays *[]time.Duration
+	Backoff
+}
+
+func (r recordingBackoff) Delay(attempt int, previous time.Duration) time.Duration {
+	d := r.Backoff.Delay(attempt, previous)
+	*r.delays = append(*r.delays, d)
+	return d
+}
+
+func TestPollCancel(t *testing.T) {
+	defer time.AfterFunc(time.Second, t.FailNow).Stop() // limit test to 1 second running time.
+
+	calls := make(chan bool, 100)
+	p := Poll(func() (bool, interface{}, error) {
+		calls <- true
+		return false, nil, nil
+	}, time.Millisecond, 0)
+	<-calls
+	p.Cancel("stop")
+	time.Sleep(10 * time.Millisecond) // let a call in progress finish.
+	n := len(calls)
+	time.Sleep(20 * time.Millisecond)
+	assert.Equal(t, n, len(calls), "fn is no longer called")
+	val, _ := settled(p)
+	assert.Equal(t, &CancelError{"stop"}, val)
+}
@@ -0,0 +1,140 @@
+package promise
+
+import (
+	"errors"
+	"sync"
+)
+
+var (
+	// ErrPoolFull is the rejection reason for tasks submitted to a Pool whose
+	// queue is already at its maximum length.
+	ErrPoolFull = errors.New("promise: pool queue is full")
+	// ErrPoolDraining is the rejection reason for tasks submitted to a Pool
+	// after Drain has been called.
+	ErrPoolDraining = errors.New("promise: pool is draining")
+)
+
+// Pool runs promise-producing tasks with at most a fixed number of their
+// promises pending at a time.  Tasks submitted while the pool is busy wait in
+// a FIFO queue, which may be limited in length.
+//
+// The zero value is a pool of size 1 with an unbounded queue ready to use.
+type Pool struct {
+	size     int
+	maxQueue int
+
+	mu       sync.Mutex
+	running  int
+	waiting  []func()
+	draining bool
+	drained  []*Promise
+}
+
+// NewPool returns a pool that runs at most size tasks at a time and holds at
+// most maxQueue tasks waiting to run.  A size less than 1 is treated as 1 and
+// a maxQueue less than 1 means the queue is unbounded.
+func NewPool(size, maxQueue int) *Pool {
+	return &Pool{size: size, maxQueue: maxQueue}
+}
+
+// Submit starts task if the pool has a free slot or adds it to the queue
+// otherwise.  It returns a promise that settles the same way as the promise
+// returned by task once it has run.  A task that panics is treated as if it
+// returned a promise rejected with the panic value.
+//
+// If the queue is full the returned promise is rejected with ErrPoolFull, and
+// if the pool is draining it is rejected with ErrPoolDraining.
+func (pool *Pool) Submit(task func() *Promise) *Promise {
+	result := newPromise()
+	run := func() {
+		call(task).Then(
+			func(val interface{}) interface{} {
+				result.Resolve(val)
+				pool.done()
+				return val
+			},
+			func(err interface{}) interface{} {
+				result.Reject(err)
+				pool.done()
+				return err
+			})
+	}
+
+	pool.mu.Lock()
+	switch {
+	case pool.draining:
+		pool.mu.Unlock()
+		result.Reject(ErrPoolDraining)
+	case pool.running < pool.limit():
+		pool.running++
+		pool.mu.Unlock()
+		run()
+	case pool.maxQueue > 0 && len(pool.waiting) >= pool.maxQueue:
+		pool.mu.Unlock()
+		result.Reject(ErrPoolFull)
+	default:
+		pool.waiting = append(pool.waiting, run)
+		pool.mu.Unlock()
+	}
+	return result
+}
+
+// Drain stops the pool from accepting new tasks and returns a promise that is
+// resolved once all previously submitted tasks, both running and queued, have
+// finished.
+func (pool *Pool) Drain() *Promise {
+	drained := newPromise()
+	pool.mu.Lock()
+	pool.draining = true
+	if pool.running > 0 {
+		pool.drained = append(pool.drained, drained)
+		pool.mu.Unlock()
+		return drained
+	}
+	pool.mu.Unlock()
+	drained.Resolve(nil)
+	return drained
+}
+
+// Len returns the number of tasks waiting to run.
+func (pool *Pool) Len() int {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+	return len(pool.waiting)
+}
+
+// Running returns the number of tasks whose promises are still pending.
+func (pool *Pool) Running() int {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+	return pool.running
+}
+
+func (pool *Pool) limit() int {
+	if pool.size < 1 {
+		return 1
+	}
+	return pool.size
+}
+
+// done is called when a running task settles to start the next waiting task,
+// or to release its slot (and finish draining) if there is none.
+func (pool *Pool) done() {
+	pool.mu.Lock()
+	if len(pool.waiting) > 0 {
+		run := pool.waiting[0]
+		pool.waiting = pool.waiting[1:]
+		pool.mu.Unlock()
+		run()
+		return
+	}
+	pool.running--
+	var drained []*Promise
+	if pool.running == 0 && pool.draining {
+		drained, pool.drained = pool.drained, nil
+	}
+	pool.mu.Unlock()
+	for _, d := range drained {
+		d.Resolve(nil)
+	}
+}
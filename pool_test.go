@@ -0,0 +1,44 @@
+package promise
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPool(t *testing.T) {
+	defer time.AfterFunc(time.Second, t.FailNow).Stop() // limit test to 1 second running time.
+
+	var g gauge
+	pool := NewPool(2, 2)
+	var results []*Promise
+	for i := 0; i < 4; i++ {
+		results = append(results, pool.Submit(g.task(i, false)))
+	}
+	assert.Equal(t, 2, pool.Running())
+	assert.Equal(t, 2, pool.Len())
+
+	val, ok := settled(pool.Submit(g.task(4, false)))
+	assert.False(t, ok)
+	assert.Equal(t, ErrPoolFull, val)
+
+	drained := pool.Drain()
+	val, ok = settled(pool.Submit(g.task(5, false)))
+	assert.False(t, ok)
+	assert.Equal(t, ErrPoolDraining, val)
+
+	_, ok = settled(drained)
+	assert.True(t, ok)
+	assert.Equal(t, 0, pool.Running())
+	assert.Equal(t, 2, g.max)
+	for i, p := range results {
+		val, ok := settled(p)
+		assert.True(t, ok)
+		assert.Equal(t, i, val)
+	}
+
+	// Draining an idle pool resolves immediately.
+	_, ok = settled(NewPool(1, 0).Drain())
+	assert.True(t, ok)
+}
@@ -0,0 +1,139 @@
+package promise
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// Population counts promises by state, see Runtime.
+type Population struct {
+	Pending   int64 // promises created by this package that haven't settled
+	Fulfilled int64 // promises that were resolved
+	Rejected  int64 // promises that were rejected, including canceled ones
+	Canceled  int64 // promises that were rejected by cancellation
+}
+
+// RuntimeStats is a snapshot of the promise population, see Runtime.
+type RuntimeStats struct {
+	Population
+	// ByLabel breaks the population down by label (see SetLabel), if
+	// EnableRuntimeLabels is on.  Promises without a label are counted under
+	// "".
+	ByLabel map[string]Population
+}
+
+var population struct {
+	Population // updated atomically
+
+	labeled int32 // see EnableRuntimeLabels
+	mu      sync.Mutex
+	pending map[*Promise]bool
+	settled map[string]*Population
+}
+
+// Runtime returns the current promise population: how many promises are
+// pending right now, and how many have been fulfilled, rejected and canceled
+// since the program started.  Unlike Stats, these counts are not reset by
+// ResetStats.  Runtime is cheap enough to call periodically, e.g. to watch
+// the number of pending promises for slow leaks of work that never finishes;
+// promises returned by Never are not counted.
+func Runtime() RuntimeStats {
+	r := RuntimeStats{Population: Population{
+		Pending:   atomic.LoadInt64(&population.Pending),
+		Fulfilled: atomic.LoadInt64(&population.Fulfilled),
+		Rejected:  atomic.LoadInt64(&population.Rejected),
+		Canceled:  atomic.LoadInt64(&population.Canceled),
+	}}
+	if atomic.LoadInt32(&population.labeled) == 0 {
+		return r
+	}
+	population.mu.Lock()
+	defer population.mu.Unlock()
+	r.ByLabel = map[string]Population{}
+	for label, s := range population.settled {
+		r.ByLabel[label] = *s
+	}
+	for p := range population.pending {
+		s := r.ByLabel[p.Label()]
+		s.Pending++
+		r.ByLabel[p.label] = s
+	}
+	return r
+}
+
+// EnableRuntimeLabels starts (or stops) breaking down the population reported
+// by Runtime by label.  This keeps track of every pending promise, which
+// costs more than the plain counts, and only promises created while it is on
+// are counted.  Enabling discards the counts recorded before.
+func EnableRuntimeLabels(enabled bool) {
+	population.mu.Lock()
+	defer population.mu.Unlock()
+	var v int32
+	population.pending, population.settled = nil, nil
+	if enabled {
+		v = 1
+		population.pending, population.settled = map[*Promise]bool{}, map[string]*Population{}
+	}
+	atomic.StoreInt32(&population.labeled, v)
+}
+
+// populationCreated counts the new promise p as pending.
+func populationCreated(p *Promise) {
+	p.counted = true
+	atomic.AddInt64(&population.Pending, 1)
+	if atomic.LoadInt32(&population.labeled) == 0 {
+		return
+	}
+	population.mu.Lock()
+	if population.pending != nil {
+		population.pending[p] = true
+	}
+	population.mu.Unlock()
+}
+
+// populationSettled counts p as no longer pending, and as fulfilled or
+// rejected.
+func populationSettled(p *Promise, s State) {
+	if p.counted {
+		atomic.AddInt64(&population.Pending, -1)
+	}
+	if s == StateFulfilled {
+		atomic.AddInt64(&population.Fulfilled, 1)
+	} else {
+		atomic.AddInt64(&population.Rejected, 1)
+	}
+	populationLabel(p, func(c *Population) {
+		if s == StateFulfilled {
+			c.Fulfilled++
+		} else {
+			c.Rejected++
+		}
+	})
+}
+
+// populationCanceled counts p, which was just rejected, as canceled.
+func populationCanceled(p *Promise) {
+	atomic.AddInt64(&population.Canceled, 1)
+	populationLabel(p, func(c *Population) { c.Canceled++ })
+}
+
+// populationLabel applies count to the settled counts of p's label, and
+// forgets p as pending, if the population is broken down by label.
+func populationLabel(p *Promise, count func(c *Population)) {
+	if atomic.LoadInt32(&population.labeled) == 0 {
+		return
+	}
+	population.mu.Lock()
+	defer population.mu.Unlock()
+	if population.settled == nil {
+		return
+	}
+	delete(population.pending, p)
+	label := p.Label()
+	c := population.settled[label]
+	if c == nil {
+		c = &Population{}
+		population.settled[label] = c
+	}
+	count(c)
+}
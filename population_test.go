@@ -0,0 +1,44 @@
+package promise
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRuntime(t *testing.T) {
+	defer time.AfterFunc(time.Second, t.FailNow).Stop() // limit test to 1 second running time.
+
+	EnableRuntimeLabels(true)
+	defer EnableRuntimeLabels(false)
+	before := Runtime()
+
+	ok := newPromise().SetLabel("ok")
+	bad := newPromise().SetLabel("bad")
+	stopped := newPromise().SetLabel("bad")
+	waiting := newPromise().SetLabel("waiting")
+	Never()
+	var zero Promise
+
+	ok.Resolve(1)
+	bad.Reject("no")
+	stopped.Cancel("stop")
+	zero.Resolve(2)
+
+	// Other tests may leave work running, so the totals are only bounded.
+	r := Runtime()
+	assert.True(t, r.Fulfilled >= before.Fulfilled+2)
+	assert.True(t, r.Rejected >= before.Rejected+2)
+	assert.True(t, r.Canceled >= before.Canceled+1)
+	assert.Equal(t, Population{Fulfilled: 1}, r.ByLabel["ok"])
+	assert.Equal(t, Population{Rejected: 2, Canceled: 1}, r.ByLabel["bad"])
+	assert.Equal(t, Population{Pending: 1}, r.ByLabel["waiting"])
+	assert.True(t, r.ByLabel[""].Fulfilled >= 1)
+
+	waiting.Resolve(nil)
+	assert.Equal(t, Population{Fulfilled: 1}, Runtime().ByLabel["waiting"])
+
+	EnableRuntimeLabels(false)
+	assert.Nil(t, Runtime().ByLabel)
+}
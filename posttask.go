@@ -0,0 +1,82 @@
+package promise
+
+import (
+	"sync/atomic"
+
+	"github.com/gopherjs/gopherjs/js"
+)
+
+// Priorities understood by PostTaskScheduler, matching the priorities of the
+// browser's Prioritized Task Scheduling API.  Other PriorityScheduler
+// implementations may use any int, with higher values running first.
+const (
+	// BackgroundPriority is for work that can wait, such as prefetching,
+	// logging or indexing.
+	BackgroundPriority = -1
+	// UserVisiblePriority is for work whose result the user sees, but not
+	// immediately.  It is the default.
+	UserVisiblePriority = 0
+	// UserBlockingPriority is for work that the user is waiting on, such as
+	// the response to input.
+	UserBlockingPriority = 1
+)
+
+// priorityName returns the name of priority for scheduler.postTask.
+func priorityName(priority int) string {
+	switch {
+	case priority < UserVisiblePriority:
+		return "background"
+	case priority > UserVisiblePriority:
+		return "user-blocking"
+	}
+	return "user-visible"
+}
+
+// PostTaskScheduler is a PriorityScheduler backed by the browser's
+// scheduler.postTask, so that background chains of promises don't compete
+// equally with input handling.  Where postTask isn't available it falls back
+// to setTimeout, ignoring priorities.  Use SetPriority (or Options.Priority)
+// to mark promises, or the "priority" call option for promisified calls:
+//
+//	promise.Configure(promise.Config{Scheduler: promise.PostTaskScheduler})
+//	prefetch().SetPriority(promise.BackgroundPriority).Then(...)
+//
+// The posted task starts a goroutine for the actual work, since it may block.
+var PostTaskScheduler PriorityScheduler = postTaskScheduler{}
+
+type postTaskScheduler struct{}
+
+func (s postTaskScheduler) Schedule(task func()) {
+	s.SchedulePriority(task, UserVisiblePriority)
+}
+
+func (postTaskScheduler) SchedulePriority(task func(), priority int) {
+	run := func() {
+		atomic.AddInt64(&counters.Goroutines, 1)
+		go task()
+	}
+	if scheduler := js.Global.Get("scheduler"); scheduler != js.Undefined && scheduler.Get("postTask") != js.Undefined {
+		opts := js.Global.Get("Object").New()
+		opts.Set("priority", priorityName(priority))
+		scheduler.Call("postTask", run, opts)
+		return
+	}
+	js.Global.Call("setTimeout", run, 0)
+}
+
+// SetPriority sets the priority with which the callbacks of p are scheduled, if
+// its scheduler is a PriorityScheduler, and returns p for chaining.  Promises
+// returned by Then inherit the priority.
+func (p *Promise) SetPriority(priority int) *Promise {
+	p.mu.Lock()
+	p.priority = priority
+	p.mu.Unlock()
+	return p
+}
+
+// Priority returns the priority of p, see SetPriority.
+func (p *Promise) Priority() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.priority
+}
@@ -0,0 +1,92 @@
+package promise
+
+import (
+	"reflect"
+)
+
+// ProgressEvent is the structured progress notification sent through a
+// Progress.  In JS it is the object {fraction, message}.
+type ProgressEvent struct {
+	Fraction float64 // how much of the work is done, from 0 to 1
+	Message  string  // optional description of the current step
+}
+
+// Progress reports the progress of a promisified function to the callers of
+// its promise.  A function passed to Promisify or Method that has a *Progress
+// parameter receives one for the promise of each call; it is not an argument
+// from the caller's point of view.  For example:
+//
+//	func upload(p *promise.Progress, files []string) error {
+//		for i, f := range files {
+//			p.Report(float64(i)/float64(len(files)), "uploading "+f)
+//			...
+//		}
+//		return nil
+//	}
+//
+// can be called from JS as:
+//
+//	upload(files).onProgress(e => bar.value = e.fraction).then(...)
+type Progress struct {
+	p *Promise
+}
+
+// Report notifies the listeners of the promise of the given progress.
+func (pr *Progress) Report(fraction float64, message string) {
+	pr.p.Notify(ProgressEvent{fraction, message})
+}
+
+var progressType = reflect.TypeOf((*Progress)(nil))
+
+// Notify sends value to the progress listeners registered with OnProgress.
+// Notifications are delivered synchronously and are ignored once the promise
+// has settled.
+func (p *Promise) Notify(value interface{}) {
+	p.mu.Lock()
+	listeners := p.progress
+	p.mu.Unlock()
+	if !p.IsPending() {
+		return
+	}
+	for _, fn := range listeners {
+		fn(value)
+	}
+}
+
+// OnProgress registers fn to be called with the values passed to Notify, and
+// returns p for chaining.
+func (p *Promise) OnProgress(fn func(value interface{})) *Promise {
+	p.mu.Lock()
+	p.progress = append(p.progress, fn)
+	p.mu.Unlock()
+	return p
+}
+
+// jsProgress converts a progress notification for JS.
+func jsProgress(value interface{}) interface{} {
+	if e, ok := value.(ProgressEvent); ok {
+		return map[string]interface{}{"fraction": e.Fraction, "message": e.Message}
+	}
+	return value
+}
+
+// injected reports whether a parameter of type t is supplied by this package
+// rather than by the caller.
+func injected(t reflect.Type) bool {
+	return t == progressType || t == writerType || t == contextType || t == cancelTokenType
+}
+
+// callArgs returns the arguments for calling a function of type t with args
+// from the caller, inserting the injected parameters for the call producing p.
+func callArgs(t reflect.Type, p *Promise, args []interface{}) []reflect.Value {
+	in := make([]reflect.Value, 0, len(args)+1)
+	for i := 0; i < t.NumIn() && !(t.IsVariadic() && i == t.NumIn()-1); i++ {
+		if injected(t.In(i)) {
+			in = append(in, injectedValue(t.In(i), p))
+		} else if len(args) > 0 {
+			in = append(in, reflect.ValueOf(args[0]))
+			args = args[1:]
+		}
+	}
+	return append(in, reflectAll(args...)...)
+}
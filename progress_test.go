@@ -0,0 +1,42 @@
+package promise
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProgress(t *testing.T) {
+	defer time.AfterFunc(time.Second, t.FailNow).Stop() // limit test to 1 second running time.
+
+	start := make(chan bool)
+	upload := Method(func(p *Progress, files ...string) int {
+		<-start
+		for i, f := range files {
+			p.Report(float64(i+1)/float64(len(files)), f)
+		}
+		return len(files)
+	})
+
+	var events []interface{}
+	p := upload("a", "b").OnProgress(func(v interface{}) { events = append(events, v) })
+	close(start)
+	val, ok := settled(p)
+	assert.True(t, ok)
+	assert.Equal(t, 2, val)
+	assert.Equal(t, []interface{}{ProgressEvent{0.5, "a"}, ProgressEvent{1, "b"}}, events)
+
+	p.Notify("ignored after settling")
+	assert.Equal(t, 2, len(events))
+	assert.Equal(t, map[string]interface{}{"fraction": 0.5, "message": "a"}, jsProgress(events[0]))
+}
+
+func TestProgressSignature(t *testing.T) {
+	fn := func(name string, p *Progress, n int) error { return nil }
+	sig := Describe(fn)
+	assert.Equal(t, []Param{{"arg0", "string"}, {"arg2", "number"}}, sig.Params)
+	assert.Equal(t, "(arg0: string, arg2: number): Promise<null>",
+		tsSignature(reflect.TypeOf(fn), 0, FuncDoc{}))
+}
@@ -57,36 +57,23 @@
 //
 // This package still has some rough edges:
 //
-//    * Does not adopt promise state when a promise is returned from a handler.
-//      E.g.:
-//        func Op1() Promise {...}
-//        func Op2() Promise {...}
-//        // Fails because when Op2 returns a promise, log is immediately called
-//        // instead of waiting for the Op2 promise to be fulfilled.
-//        Op1().Then(Op2, nil).Then(log, nil)
-//      To fix this, we need to be able to inspect the result of the success
-//      function and determine if it's a Promise (has a .then method), and if
-//      so we need to trigger downstream promises off of that instead of
-//      directly passing the result to the downstream promises.
 //    * Does not do JS object type detection on .then() args.  The promises
 //      spec suggests we should handle arbitrary arguments.
 //      E.g:
 //        somePromise.then(function(){...}, 123) should be equivalent to
 //        somePromise.then(function(){...})
-//    * Promisify() doesn't not auto-convert JS to strongly-typed Go types.
-//      E.g.:
-//        type Foo string
-//        func something(f Foo) {...}
-//      cannot be called from JS as:
-//        something("asdf")
-//      Instead, something must have signature:
-//        func something(f string) { ... }
 //
 package promise
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"reflect"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/gopherjs/gopherjs/js"
 )
@@ -96,22 +83,24 @@ import (
 // callback is passed to dependencies.
 type Callback func(value interface{}) interface{}
 
-// state of the promise: pending, fulfilled, rejected
-type state int
+// State is the state of a promise: pending, fulfilled or rejected, see
+// Promise.State.
+type State int
 
+// The states of a promise.
 const (
-	pending state = iota
-	fulfilled
-	rejected
+	StatePending State = iota
+	StateFulfilled
+	StateRejected
 )
 
-func (s state) String() string {
+func (s State) String() string {
 	switch s {
-	case pending:
+	case StatePending:
 		return "pending"
-	case fulfilled:
+	case StateFulfilled:
 		return "fulfilled"
-	case rejected:
+	case StateRejected:
 		return "rejected"
 	default:
 		panic(fmt.Errorf("Unknown state: %d", int(s)))
@@ -149,26 +138,197 @@ func safe(c Callback) Callback {
 // example:
 //   Promisify(computeResult)
 //
+// A Promise is safe for concurrent use: it may be settled on one goroutine
+// while others register callbacks with Then or cancel it.
 type Promise struct {
-	state state
+	// mu guards the state, value and callbacks, the cancellation bookkeeping
+	// and the settings below, so that a promise can be settled on one
+	// goroutine while others register callbacks, cancel it or change its
+	// settings.
+	mu sync.Mutex
+
+	state State
 	value interface{}
 
 	success, failure []Callback
+	next             []*Promise  // the promises returned by Then, per callback
+	bounce           *trampoline // see flushWith
+
+	token    *CancelToken // inherited by children, see WithToken
+	canceled bool         // rejected by cancellation; later settles are ignored
+	never    bool         // see Never
+	locked   bool         // resolved with a promise that it adopts, see adopt
+	readOnly bool         // settled only through a Deferred
+	counted  bool         // counted as pending by Runtime
+
+	// Cancellation bookkeeping, see Cancel.
+	parent                     *Promise
+	children, canceledChildren int
+	stop                       []func(reason interface{})
+	stopReason                 interface{} // passed to stop, see halt
+	whenSettled                []func()    // see onSettle
+
+	progress []func(value interface{}) // see OnProgress
+	output   []func(chunk []byte)      // see OnOutput
+
+	scheduler Scheduler // see Config.Scheduler
+	stack     []uintptr // creation stack, see Config.CaptureStacks
+
+	ctx  context.Context        // see WithContext, inherited by children
+	meta map[string]interface{} // see WithMeta, copied on write, inherited by children
+
+	task        *js.Object // DevTools async stack tag, inherited by children
+	label       string     // see SetLabel, inherited by children
+	synchronous bool       // see SetSynchronous, inherited by children
+	concurrent  bool       // see SetConcurrentHandlers
+	priority    int        // see SetPriority, inherited by children
+
+	// rejected with the result of a failure callback, which counts as handling
+	// the rejection, see OnUnhandledRejection.
+	fromHandler bool
+}
+
+// newPromise returns a new pending promise and records it in the counters.
+func newPromise() *Promise {
+	return newPromiseConfig(currentConfig(), StackDefault)
+}
+
+// newPromiseConfig returns a new pending promise using the scheduler and
+// SynchronousThen setting of c, recording its creation stack according to
+// stack and c.CaptureStacks.
+func newPromiseConfig(c *Config, stack StackCapture) *Promise {
+	atomic.AddInt64(&counters.Created, 1)
+	p := &Promise{scheduler: c.Scheduler, synchronous: c.SynchronousThen}
+	if stack == StackOn || stack == StackDefault && c.CaptureStacks {
+		p.stack = captureStack()
+	}
+	populationCreated(p)
+	graphNode(p)
+	pluginsCreated(p)
+	return p
+}
+
+// Resolved returns a promise that is already resolved with value, for code
+// that has its result at hand but must return a promise.  Like Resolve, it
+// adopts the state of a value that is a promise or a JS thenable, so the
+// returned promise may still be pending; a *Promise is returned as is.
+func Resolved(value interface{}) *Promise {
+	if p, ok := value.(*Promise); ok && p != nil {
+		return p
+	}
+	p := newPromise()
+	p.Resolve(value)
+	return p
+}
+
+// Rejected returns a promise that is already rejected with reason, see
+// Reject.  Unlike Resolved, a reason that is a promise is not adopted.
+func Rejected(reason interface{}) *Promise {
+	p := newPromise()
+	p.Reject(reason)
+	return p
 }
 
 // Then registers success and failure to be called if the promise is fulfilled
 // or rejected respectively.  It returns a new promise that will be resolved or
 // rejected with the result of the success or failure callbacks.
 //
-// Note that if success or failure return a promise, the promise itself is
-// passed along as the value rather than adopting the returned promise's state.
+// If success or failure return a promise (a *Promise, a *CancelablePromise or
+// a JS thenable), the returned promise adopts its state instead: it waits for
+// that promise and settles the same way.
 func (p *Promise) Then(success, failure Callback) *Promise {
-	var child Promise
+	child := newPromise()
+	child.parent = p
+	p.mu.Lock()
+	child.ctx, child.meta, child.task, child.label = p.ctx, p.meta, p.task, p.label
+	child.synchronous, child.priority = p.synchronous, p.priority
+	if p.scheduler != nil {
+		child.scheduler = p.scheduler
+	}
+	token, label := p.token, p.label
+	p.mu.Unlock()
+	graphEdge(p, child, EdgeThen)
+	if token != nil {
+		child.WithToken(token)
+	}
 	success, failure = child.wrap(success, failure)
+	if label != "" {
+		success, failure = traced(label, success), traced(label, failure)
+	}
+	p.listen(success, failure, child)
+	return child
+}
+
+// Catch registers failure to be called if the promise is rejected, and is
+// short for Then(nil, failure).  As with Then, the result of failure rejects
+// the returned promise, unless it is a promise whose state is adopted, so
+// returning a fulfilled promise recovers from the rejection.
+func (p *Promise) Catch(failure Callback) *Promise { return p.Then(nil, failure) }
+
+// Finally registers fn to be called once the promise settles either way.  The
+// returned promise settles with the same value or reason, unless fn panics, in
+// which case it is rejected with the panic value.
+func (p *Promise) Finally(fn func()) *Promise {
+	return p.Then(func(value interface{}) interface{} {
+		fn()
+		return value
+	}, func(reason interface{}) interface{} {
+		fn()
+		return reason
+	})
+}
+
+// listen registers success and failure, which settle next, to be dispatched
+// when p settles.
+func (p *Promise) listen(success, failure Callback, next *Promise) {
+	p.mu.Lock()
+	p.children++
+	wasRejected := p.state == StateRejected
 	p.success = append(p.success, success)
 	p.failure = append(p.failure, failure)
-	p.flush()
-	return &child
+	p.next = append(p.next, next)
+	synchronous := p.synchronous
+	p.mu.Unlock()
+	if wasRejected {
+		trackHandler(p)
+	}
+	if synchronous {
+		p.flushWith(runNow)
+	} else {
+		p.flush()
+	}
+}
+
+// ErrSettledInHandler is the rejection reason of the promise returned by Then
+// if its callback calls Resolve or Reject on the promise that it was
+// registered on.  That promise has necessarily settled already, so the call is
+// a bug, e.g. a callback that was meant to settle a different promise.
+var ErrSettledInHandler = errors.New("promise: Resolve or Reject called on a settled promise from one of its own callbacks")
+
+// notPendingError is the panic value of Resolve and Reject on a promise that
+// has already settled, or that is locked in to a promise it adopts.
+type notPendingError struct {
+	p   *Promise
+	was State
+}
+
+func (e *notPendingError) Error() string {
+	if e.was == StatePending {
+		return "Cannot change p promise that was resolved with another promise"
+	}
+	return fmt.Sprintf("Cannot change p promise that isn't pending: %s", e.was)
+}
+
+// settledInHandler returns ErrSettledInHandler if x, recovered from a
+// callback registered on from, is the panic of settling from again, and x
+// otherwise.  Only the panics of the callback itself are recovered by its
+// dispatch, so settling from on another goroutine while the callback runs
+// panics as usual.
+func settledInHandler(x interface{}, from *Promise) interface{} {
+	if e, ok := x.(*notPendingError); ok && from != nil && e.p == from {
+		return ErrSettledInHandler
+	}
+	return x
 }
 
 // wrap returns a new pair of callbacks that will not only call the provided
@@ -178,64 +338,283 @@ func (p *Promise) wrap(success, failure Callback) (Callback, Callback) {
 	return func(val interface{}) interface{} {
 			defer func() {
 				if x := recover(); x != nil {
-					p.Reject(x)
+					p.Reject(settledInHandler(x, p.parent))
 				}
 			}()
 			return p.Resolve(safe(success)(val))
 		},
-		func(val interface{}) interface{} { return p.Reject(safe(failure)(val)) }
+		func(val interface{}) interface{} {
+			defer func() {
+				if x := recover(); x != nil {
+					p.Reject(settledInHandler(x, p.parent))
+				}
+			}()
+			if failure == nil {
+				return p.reject(val) // passed on, already transformed
+			}
+			result := failure(val)
+			if p.adopt(result) {
+				return result
+			}
+			p.mu.Lock()
+			p.fromHandler = true
+			p.mu.Unlock()
+			if sameReason(result, val) {
+				return p.reject(result) // rethrown, already transformed
+			}
+			return p.Reject(result)
+		}
+}
+
+// sameReason returns whether the result of a failure callback is the reason
+// it was called with, i.e. the callback rethrew it.
+func sameReason(result, reason interface{}) bool {
+	t := reflect.TypeOf(result)
+	return t != nil && t == reflect.TypeOf(reason) && t.Comparable() && result == reason
+}
+
+// commit settles p with val, returning false if p was canceled (or is
+// never settled, see Never).  If halting, it returns false instead of
+// panicking if p has already settled, and marks p as canceled otherwise, see
+// halt.
+func (p *Promise) commit(s State, val interface{}, halting bool) bool {
+	p.mu.Lock()
+	if p.canceled || p.never || (halting && p.state != StatePending) {
+		p.mu.Unlock()
+		return false // The producer was too late, just drop the result.
+	}
+	if was := p.state; was != StatePending || p.locked && !halting {
+		p.mu.Unlock()
+		panic(&notPendingError{p, was})
+	}
+	p.settleLocked(s, val, halting)
+	return true
+}
+
+// tryResolve fulfills p with val and dispatches its callbacks, unless p has
+// already settled or can't be settled, returning whether it did.  Unlike
+// Resolve, it doesn't panic, and val isn't adopted.
+func (p *Promise) tryResolve(val interface{}) bool {
+	if !p.trySettle(StateFulfilled, val) {
+		return false
+	}
+	p.flush()
+	return true
 }
 
-func (p *Promise) commit(s state, val interface{}, callbacks []Callback) {
-	if p.state != pending {
-		panic(fmt.Errorf("Cannot change p promise that isn't pending: %s", p.state))
+// trySettle settles p, even if it is locked in to a promise it adopts,
+// unless p has already settled or can't be settled, returning whether it did.
+// It doesn't dispatch the callbacks of p.
+func (p *Promise) trySettle(s State, val interface{}) bool {
+	p.mu.Lock()
+	if p.canceled || p.never || p.state != StatePending {
+		p.mu.Unlock()
+		return false
+	}
+	p.settleLocked(s, val, false)
+	return true
+}
+
+// settleLocked records the settlement of p, which is pending, and unlocks
+// p.mu, see commit.
+func (p *Promise) settleLocked(s State, val interface{}, halting bool) {
+	p.value, p.state, p.canceled = val, s, halting
+	whenSettled := p.whenSettled
+	p.whenSettled = nil
+	p.mu.Unlock()
+	for _, fn := range whenSettled {
+		fn()
+	}
+	atomic.AddInt64(&counters.Settled, 1)
+	if s == StateRejected {
+		atomic.AddInt64(&counters.Rejected, 1)
 	}
-	p.value = val
-	p.state = s
+	populationSettled(p, s)
+	pluginsSettled(p, val, s == StateRejected)
 }
 
-func (p *Promise) flush() {
-	if p.state == pending {
+// State returns the current state of p.  It is meant for debugging and
+// tests: code that needs the outcome of p should register callbacks with
+// Then, which also works while p is pending.
+func (p *Promise) State() State {
+	s, _ := p.current()
+	return s
+}
+
+// IsPending returns whether p has not yet been resolved or rejected.
+func (p *Promise) IsPending() bool { return p.State() == StatePending }
+
+// IsFulfilled returns whether p has been resolved with a value.
+func (p *Promise) IsFulfilled() bool { return p.State() == StateFulfilled }
+
+// IsRejected returns whether p has been rejected, including by cancellation.
+func (p *Promise) IsRejected() bool { return p.State() == StateRejected }
+
+// Value returns the value that p was resolved with, or nil if p isn't
+// fulfilled.
+func (p *Promise) Value() interface{} {
+	if s, value := p.current(); s == StateFulfilled {
+		return value
+	}
+	return nil
+}
+
+// Reason returns the reason that p was rejected with, or nil if p isn't
+// rejected.
+func (p *Promise) Reason() interface{} {
+	if s, reason := p.current(); s == StateRejected {
+		return reason
+	}
+	return nil
+}
+
+// current returns the state of p and its value (or reason).
+func (p *Promise) current() (State, interface{}) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.state, p.value
+}
+
+func (p *Promise) flush() { p.flushWith(p.schedule) }
+
+// flushWith dispatches the callbacks of p, if it has settled, with schedule.
+// If p was settled by one of its parent's callbacks, they are dispatched on
+// the parent's trampoline instead, unless p has another scheduler or priority,
+// see trampoline.
+func (p *Promise) flushWith(schedule func(task func())) {
+	p.mu.Lock()
+	if p.state == StatePending {
+		p.mu.Unlock()
 		return
 	}
+	val, callbacks, next := p.value, p.success, p.next
+	if p.state == StateRejected {
+		callbacks = p.failure
+	}
+	p.success, p.failure, p.next = nil, nil, nil
+	bounce, concurrent := p.bounce, p.concurrent
+	scheduler, priority := p.scheduler, p.priority
+	p.mu.Unlock()
 
-	if p.state == fulfilled {
-		go sendSoon(p.value, p.success)
-	} else if p.state == rejected {
-		go sendSoon(p.value, p.failure)
+	if concurrent && len(callbacks) > 1 {
+		for i := range callbacks {
+			callbacks, next := callbacks[i:i+1], next[i:i+1]
+			schedule(func() {
+				t := newTrampoline(scheduler, priority)
+				sendSoon(val, callbacks, next, t)
+				t.run()
+			})
+		}
+		return
+	}
+	send := func(t *trampoline) { sendSoon(val, callbacks, next, t) }
+	if bounce != nil && bounce.runs(scheduler, priority) {
+		bounce.push(send)
+		return
 	}
-	p.success = nil
-	p.failure = nil
+	schedule(func() {
+		t := newTrampoline(scheduler, priority)
+		send(t)
+		t.run()
+	})
 }
 
 // This is explicitly not part of the Promise object so we don't mutate state.
 // In JS, this is asynchronously scheduled in the next process tick.  In Go,
 // this is run concurrently.  So we explicitly accept the arguments and hold
 // them here, they should not be modified after this goroutine is started.
-func sendSoon(val interface{}, callbacks []Callback) {
-	for _, cb := range callbacks {
+//
+// next holds the promises returned by Then for each of the callbacks, which
+// are settled by them: their own callbacks are queued on t rather than
+// scheduled separately.
+func sendSoon(val interface{}, callbacks []Callback, next []*Promise, t *trampoline) {
+	for i, cb := range callbacks {
 		if cb != nil {
+			atomic.AddInt64(&counters.Handlers, 1)
+			next[i].setBounce(t)
 			cb(val)
+			next[i].setBounce(nil)
 		}
 	}
 }
 
+func (p *Promise) setBounce(t *trampoline) {
+	p.mu.Lock()
+	p.bounce = t
+	p.mu.Unlock()
+}
+
 // Resolve this promise with the provided value.  Either Resolve or Reject may
-// be called at most once on a promise instance.
+// be called at most once on a promise instance.  Calls on a promise that was
+// canceled by its CancelToken are ignored.
+//
+// If value is a promise (a *Promise, a *CancelablePromise or a JS thenable),
+// p adopts its state instead, following the resolution procedure of the
+// Promises/A+ spec: p stays pending until value settles, and then settles the
+// same way.  Resolving p with itself rejects it with ErrResolvedWithItself.
+// Meanwhile p counts as resolved: calling Resolve or Reject again panics.
+//
+// The promise of a Deferred can only be settled through the Deferred, and
+// Resolve and Reject panic with ErrReadOnly if called on it.
 func (p *Promise) Resolve(value interface{}) interface{} {
-	p.commit(fulfilled, value, p.success)
-	p.flush()
+	p.checkWritable()
+	return p.resolve(value)
+}
+
+// resolve is Resolve without the check for read-only promises.
+func (p *Promise) resolve(value interface{}) interface{} {
+	if p.adopt(value) {
+		return value
+	}
+	if p.commit(StateFulfilled, value, false) {
+		p.flush()
+	}
 	return value
 }
 
 // Reject this promise with the specified errror.  Either Resolve or Reject may
-// be called at most once on a promise instance.
+// be called at most once on a promise instance.  Calls on a promise that was
+// canceled by its CancelToken are ignored.  A reason that isn't an error is
+// handled according to Config.NonErrorRejections, and the reason is
+// transformed by the registered plugins, see Plugin.TransformRejection.
 func (p *Promise) Reject(err interface{}) interface{} {
-	p.commit(rejected, err, p.failure)
-	p.flush()
+	p.checkWritable()
+	return p.rejectReason(err)
+}
+
+// rejectReason is Reject without the check for read-only promises.
+func (p *Promise) rejectReason(err interface{}) interface{} {
+	return p.reject(pluginsTransformRejection(p, coerceReason(p, err)))
+}
+
+// reject rejects p with err as is.
+func (p *Promise) reject(err interface{}) interface{} {
+	p.commitRejection(err, false)
 	return err
 }
 
+// commitRejection rejects p with err and dispatches its callbacks, returning
+// whether p was rejected, see commit.
+func (p *Promise) commitRejection(err interface{}, halting bool) bool {
+	if !p.commit(StateRejected, err, halting) {
+		return false
+	}
+	p.dispatchRejection()
+	return true
+}
+
+// dispatchRejection dispatches the callbacks of p, which was just rejected,
+// tracking the rejection if nothing handles it.
+func (p *Promise) dispatchRejection() {
+	p.mu.Lock()
+	unhandled := len(p.failure) == 0 && !p.fromHandler
+	p.mu.Unlock()
+	if unhandled {
+		trackRejection(p)
+	}
+	p.flush()
+}
+
 func jsCallback(f *js.Object) Callback {
 	if f == nil || f == js.Undefined {
 		return nil
@@ -243,38 +622,249 @@ func jsCallback(f *js.Object) Callback {
 	return func(val interface{}) interface{} { return f.Invoke(val) }
 }
 
+// fromJS returns a promise that settles like the JS promise (or thenable) p,
+// rejecting with a *js.Error for JS rejection reasons.  If p isn't a thenable
+// (see IsThenable), the promise resolves with p itself.
+func fromJS(p *js.Object) *Promise {
+	then, err := thenOf(p)
+	if err != nil {
+		return Rejected(err)
+	} else if then == nil {
+		return Resolved(p)
+	}
+	return fromThen(p, then)
+}
+
 // Js creates a JS wrapper object for this promise that includes the 'then'
-// method required by the Promises/A+ spec.
+// method required by the Promises/A+ spec (which also accepts a progress
+// callback as its third argument, like Q and Angular's $q), the 'catch' and
+// 'finally' methods of native promises (see Catch and Finally; the result of
+// the finally callback is ignored), an 'onProgress' method that registers a
+// callback for progress notifications and returns the wrapper, and 'onData'
+// and 'pipeTo' methods that receive the output of promisified functions with
+// an io.Writer parameter (see OnOutput).  onData registers a callback for
+// Uint8Array chunks and returns the wrapper; pipeTo writes the chunks to a
+// WritableStream and returns a promise for the result once the stream is
+// closed.
 func (p *Promise) Js() *js.Object {
 	o := js.MakeWrapper(p)
-	o.Set("then", func(success, failure *js.Object) *js.Object {
-		return p.Then(jsCallback(success), jsCallback(failure)).Js()
+	o.Set("then", func(success, failure, progress *js.Object) *js.Object {
+		if isFunction(progress) {
+			p.OnProgress(func(val interface{}) { progress.Invoke(jsProgress(val)) })
+		}
+		return p.Then(inTask(p.task, jsCallback(success)), inTask(p.task, jsCallback(failure))).Js()
+	})
+	o.Set("catch", func(failure *js.Object) *js.Object {
+		return p.Catch(inTask(p.task, jsCallback(failure))).Js()
+	})
+	o.Set("finally", func(fn *js.Object) *js.Object {
+		onFinally := inTask(p.task, jsCallback(fn))
+		return p.Finally(func() {
+			if onFinally != nil {
+				onFinally(nil)
+			}
+		}).Js()
+	})
+	o.Set("onProgress", func(cb *js.Object) *js.Object {
+		p.OnProgress(func(val interface{}) { cb.Invoke(jsProgress(val)) })
+		return o
+	})
+	o.Set("onData", func(cb *js.Object) *js.Object {
+		p.OnOutput(func(chunk []byte) { cb.Invoke(chunk) })
+		return o
 	})
+	o.Set("pipeTo", func(stream *js.Object) *js.Object {
+		return p.pipeTo(stream).Js()
+	})
+	pluginsMethods(p, o)
 	return o
 }
 
 // Promisify takes any Go function and converts it to a function that runs
 // asynchronously and returns a Promise.
 //
-// Note: Currently this does not convert javascript types to Go types even if
-// they are structurally equivalent.  It therefore works only with plain data
-// types or values explicitly created by Go code (passed back to java).
+// The returned JS function carries JSDoc metadata describing fn in its "jsdoc"
+// property and its Signature in its "__signature" property.  Use Document to
+// include parameter names and a description.
+//
+// If fn has a *Progress parameter, it is not taken from the JS arguments but
+// reports progress to the returned promise, see Progress.  Likewise, an
+// io.Writer parameter streams output to the returned promise, see OnOutput,
+// a context.Context parameter receives a context that is canceled if the
+// returned promise is canceled or times out, and a *CancelToken parameter
+// receives a token that is canceled with it, with the same reason.
+//
+// When the browser supports async stack tagging (console.createTask), the JS
+// callbacks of the returned promise and its children run in a task for the
+// call, so DevTools shows where the call was made in async stack traces.
+//
+// JS arguments are converted for the parameters of fn: numbers, strings and
+// booleans convert to any Go type of the same kind (e.g. "type ID string"),
+// arrays and objects convert element-wise to slices and maps, *js.Object and
+// interface{} parameters receive the argument unconverted, a Blob or File is
+// read into []byte and io.Reader parameters, and missing, null and undefined
+// arguments are passed as zero values (see Config.NullArgs).  If the
+// arguments aren't acceptable (see Config.Conversion) or exceed
+// Config.ArgLimits, the promise is rejected.  A result that is an io.Reader
+// (such as an io.ReadCloser) is resolved as a ReadableStream that reads from
+// it as JS consumes the stream, rather than buffering it.  Other pointer
+// results are dereferenced, and nil results resolve as null (see
+// Config.NilResults).  Struct fields map to object properties named by
+// Config.FieldNaming (lowerCamelCase by default) or their json tags, in both
+// directions.
+//
+// The promise is scheduled, serializes errors and handles panics and timeouts
+// according to the configuration set by Configure.  JS callers may override
+// some of this for a single call with a trailing options object, see
+// CallOptions.  Calls can be recorded and served from a recording instead of
+// fn, see Record and Replay.
 func Promisify(fn interface{}) interface{} {
+	return PromisifyWith(fn, Config{})
+}
+
+// PromisifyWith is like Promisify, but the set fields of opts override the
+// configuration set by Configure for calls of the returned function.
+func PromisifyWith(fn interface{}, opts Config) interface{} {
+	fn, doc := undocument(fn)
 	f := reflect.ValueOf(fn)
-	return func(args ...interface{}) *js.Object {
-		var p Promise
-		go func() {
-			// TODO(aroman) Attempt to convert all args to the parameter type.
-			results := f.Call(reflectAll(args...))
+	name := funcName(f.Pointer())
+	min, _ := arity(f.Type())
+	return jsFunction(f, doc, func(args []*js.Object) *js.Object {
+		c := opts.resolved()
+		args, call := splitCallOptions(args, min)
+		p := newPromise()
+		p.scheduler, p.synchronous = c.Scheduler, c.SynchronousThen
+		p.task, p.label = newAsyncTask(name), name
+		if call.Label != "" {
+			p.label = call.Label
+		}
+		p.priority = call.Priority
+		timeout := c.DefaultTimeout
+		if call.Timeout > 0 {
+			timeout = call.Timeout
+		}
+		if timeout > 0 {
+			timer := time.AfterFunc(timeout, func() { p.halt(c.ErrorSerializer(ErrTimeout), ErrTimeout) })
+			p.onSettle(func() { timer.Stop() })
+		}
+		if call.Deadline != nil {
+			p.onSettle(call.Deadline.watch(func() {
+				p.halt(c.ErrorSerializer(ErrDeadlineExceeded), ErrDeadlineExceeded)
+			}))
+		}
+		if call.Signal != nil {
+			p.abortOn(call.Signal)
+		}
+		end := traceStart(p.Label())
+		schedulePriority(c.Scheduler, func() {
+			defer end()
+			if c.PanicPolicy == PanicReject {
+				defer func() {
+					if x := recover(); x != nil {
+						p.Reject(x)
+					}
+				}()
+			}
+			if err := c.ArgLimits.check(name, args); err != nil {
+				p.Reject(c.ErrorSerializer(err))
+				return
+			}
+			conv := newConverter(c)
+			in, err := conv.convertArgs(f.Type(), p, args)
+			if err != nil {
+				p.Reject(c.ErrorSerializer(err))
+				return
+			}
+			recorder, replayer := playbackMode()
+			var recorded []json.RawMessage
+			if recorder != nil || replayer != nil {
+				recorded = recordedArgs(f.Type(), in)
+			}
+			if replayer != nil {
+				if call, ok := replayer.match(name, recorded); ok {
+					call.replay(p)
+				} else {
+					p.Reject(c.ErrorSerializer(ErrNotRecorded))
+				}
+				return
+			}
+			start := time.Now()
+			results := f.Call(in)
 			value, err := splitResults(results, hasLastError(f.Type()))
+			recordCall(name, time.Since(start), err != nil)
+			value = jsOutcome(c, conv, value, err)
+			if recorder != nil {
+				recorder.record(name, recorded, err != nil, value)
+			}
 			if err == nil {
 				p.Resolve(value)
 			} else {
-				p.Reject(err.Error())
+				p.Reject(value)
 			}
-		}()
+		}, call.Priority)
 		return p.Js()
+	})
+}
+
+// jsOutcome returns what the promise of a promisified call settles with: the
+// result converted for JS with conv, or the error serialized by c.
+func jsOutcome(c Config, conv converter, value interface{}, err error) interface{} {
+	if err != nil {
+		return c.ErrorSerializer(err)
 	}
+	return conv.convertResult(value)
+}
+
+// Method converts fn into a function that runs fn asynchronously and returns
+// a Promise for its results.  It is the Go counterpart of Promisify: the
+// returned function is meant to be called from Go code rather than exported to
+// JS, so panics and errors become rejections instead of crashing the caller.
+//
+// The results of fn are interpreted with the same rules as Promisify, except
+// that the promise is rejected with the returned error value itself rather
+// than its message.  As with Promisify, a *Progress parameter is supplied for
+// the returned promise rather than taken from args.
+//
+// If the first of args is a context.Context, it is attached to the returned
+// promise (see WithContext) rather than passed as an argument, and a
+// context.Context parameter of fn receives it:
+//
+//	fetch := promise.Method(func(ctx context.Context, id int) (*User, error) {...})
+//	p.Then(func(id interface{}) interface{} {
+//		return fetch(promise.ContextOf(p), id)
+//	}, nil)
+func Method(fn interface{}) func(args ...interface{}) *Promise {
+	f := reflect.ValueOf(fn)
+	return func(args ...interface{}) *Promise {
+		p := newPromise()
+		if len(args) > 0 {
+			if ctx, ok := args[0].(context.Context); ok {
+				p.ctx, args = ctx, args[1:]
+			}
+		}
+		p.run(func() (interface{}, error) {
+			return splitResults(f.Call(callArgs(f.Type(), p, args)), hasLastError(f.Type()))
+		})
+		return p
+	}
+}
+
+// run schedules call and settles p with its result, rejecting p with the
+// error or the panic value if call fails, as Method does.
+func (p *Promise) run(call func() (interface{}, error)) {
+	p.schedule(func() {
+		defer func() {
+			if x := recover(); x != nil {
+				p.Reject(x)
+			}
+		}()
+		value, err := call()
+		if err == nil {
+			p.Resolve(value)
+		} else {
+			p.Reject(err)
+		}
+	})
 }
 
 var errorType = reflect.ValueOf((*error)(nil)).Type().Elem()
@@ -1,6 +1,9 @@
 package promise
 
 import (
+	"errors"
+	"fmt"
+	"sync"
 	"testing"
 	"time"
 
@@ -84,3 +87,205 @@ func TestPromiseRejected(t *testing.T) {
 	a.Then(panicIfCalled, done1.process)
 	assert.Equal(t, <-done1, 1)
 }
+
+func TestSettleInOwnHandler(t *testing.T) {
+	defer time.AfterFunc(time.Second, t.FailNow).Stop() // limit test to 1 second running time.
+
+	var a Promise
+	child := a.Then(func(v interface{}) interface{} { return a.Resolve(2) }, nil)
+	a.Resolve(1)
+	val, ok := settled(child)
+	assert.False(t, ok)
+	assert.Equal(t, ErrSettledInHandler, val)
+
+	var b Promise
+	child = b.Then(nil, func(v interface{}) interface{} { return b.Reject(2) })
+	b.Reject(1)
+	val, ok = settled(child)
+	assert.False(t, ok)
+	assert.Equal(t, ErrSettledInHandler, val)
+
+	// Outside of the handlers, it's the usual panic, even while one runs.
+	assert.PanicsWithError(t, "Cannot change p promise that isn't pending: fulfilled", func() { a.Resolve(3) })
+	var c Promise
+	running, done := make(chan bool), make(chan bool)
+	c.Then(func(v interface{}) interface{} { running <- true; <-done; return v }, nil)
+	c.Resolve(1)
+	<-running
+	assert.PanicsWithError(t, "Cannot change p promise that isn't pending: fulfilled", func() { c.Resolve(2) })
+	close(done)
+}
+
+func TestMethod(t *testing.T) {
+	defer time.AfterFunc(time.Second, t.FailNow).Stop() // limit test to 1 second running time.
+
+	failure := errors.New("negative")
+	sqrt := Method(func(x int) (int, error) {
+		if x < 0 {
+			return 0, failure
+		}
+		for i := 0; ; i++ {
+			if i*i >= x {
+				return i, nil
+			}
+		}
+	})
+
+	val, ok := settled(sqrt(16))
+	assert.True(t, ok)
+	assert.Equal(t, 4, val)
+
+	val, ok = settled(sqrt(-1))
+	assert.False(t, ok)
+	assert.Equal(t, failure, val)
+
+	// Wrong argument types panic inside reflection, which rejects the promise.
+	_, ok = settled(sqrt("sixteen"))
+	assert.False(t, ok)
+
+	// Multiple results are resolved as a slice, no results as nil.
+	val, _ = settled(Method(func() (int, string) { return 1, "a" })())
+	assert.Equal(t, []interface{}{1, "a"}, val)
+	val, _ = settled(Method(func() {})())
+	assert.Nil(t, val)
+}
+
+func TestConcurrentThenResolve(t *testing.T) {
+	defer time.AfterFunc(time.Second, t.FailNow).Stop() // limit test to 1 second running time.
+
+	for i := 0; i < 20; i++ {
+		p := newPromise()
+		results := make(chan interface{}, 10)
+		var wg sync.WaitGroup
+		for j := 0; j < 10; j++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				send := func(val interface{}) interface{} { results <- val; return nil }
+				p.Then(send, send)
+			}()
+		}
+		go p.Resolve(i)
+		go p.Cancel("maybe")
+		wg.Wait()
+		for j := 0; j < 10; j++ {
+			if val := <-results; val != i {
+				assert.Equal(t, &CancelError{"maybe"}, val)
+			}
+		}
+	}
+}
+
+func TestConcurrentSettings(t *testing.T) {
+	defer time.AfterFunc(time.Second, t.FailNow).Stop() // limit test to 1 second running time.
+
+	for i := 0; i < 20; i++ {
+		p := newPromise()
+		results := make(chan interface{}, 10)
+		var wg sync.WaitGroup
+		for j := 0; j < 5; j++ {
+			wg.Add(3)
+			go func() {
+				defer wg.Done()
+				p.Then(func(val interface{}) interface{} { results <- val; return nil }, nil)
+			}()
+			go func(j int) {
+				defer wg.Done()
+				p.WithMeta(fmt.Sprint(j), j).SetLabel("settings").SetPriority(j)
+			}(j)
+			go func() {
+				defer wg.Done()
+				p.OnProgress(func(interface{}) {})
+			}()
+		}
+		go p.Notify(0.5)
+		go p.Resolve(i)
+		wg.Wait()
+		for j := 0; j < 5; j++ {
+			assert.Equal(t, i, <-results)
+		}
+		assert.Len(t, p.AllMeta(), 5)
+	}
+}
+
+func TestCatchFinally(t *testing.T) {
+	defer time.AfterFunc(time.Second, t.FailNow).Stop() // limit test to 1 second running time.
+
+	failed := newPromise()
+	failed.Reject(errors.New("boom"))
+	val, ok := settled(Resolved(1).Catch(panicIfCalled))
+	assert.True(t, ok)
+	assert.Equal(t, 1, val)
+	val, ok = settled(failed.Catch(func(reason interface{}) interface{} { return Resolved("recovered") }))
+	assert.True(t, ok)
+	assert.Equal(t, "recovered", val)
+
+	calls := 0
+	val, ok = settled(Resolved(2).Finally(func() { calls++ }))
+	assert.True(t, ok)
+	assert.Equal(t, 2, val)
+	val, ok = settled(failed.Finally(func() { calls++ }))
+	assert.False(t, ok)
+	assert.EqualError(t, val.(error), "boom")
+	assert.Equal(t, 2, calls)
+
+	val, ok = settled(Resolved(3).Finally(func() { panic("cleanup failed") }))
+	assert.False(t, ok)
+	assert.Equal(t, "cleanup failed", val)
+
+	// Panics in callbacks of rejected promises reject as well.
+	val, ok = settled(failed.Finally(func() { panic("cleanup failed") }))
+	assert.False(t, ok)
+	assert.Equal(t, "cleanup failed", val)
+	val, ok = settled(failed.Catch(func(reason interface{}) interface{} { panic("recovery failed") }))
+	assert.False(t, ok)
+	assert.Equal(t, "recovery failed", val)
+}
+
+func TestResolvedRejected(t *testing.T) {
+	defer time.AfterFunc(time.Second, t.FailNow).Stop() // limit test to 1 second running time.
+
+	val, ok := settled(Resolved("done"))
+	assert.True(t, ok)
+	assert.Equal(t, "done", val)
+
+	pending := newPromise()
+	assert.Equal(t, pending, Resolved(pending))
+	cancelable := NewCancelable(nil)
+	adopting := Resolved(cancelable)
+	assert.True(t, adopting.IsPending())
+	cancelable.Resolve("later")
+	val, _ = settled(adopting)
+	assert.Equal(t, "later", val)
+
+	val, ok = settled(Rejected("failed"))
+	assert.False(t, ok)
+	assert.Equal(t, "failed", val)
+	val, ok = settled(Rejected(pending))
+	assert.False(t, ok)
+	assert.Equal(t, pending, val)
+}
+
+func TestStateInspection(t *testing.T) {
+	p := newPromise()
+	assert.Equal(t, StatePending, p.State())
+	assert.True(t, p.IsPending())
+	assert.Nil(t, p.Value())
+	assert.Nil(t, p.Reason())
+
+	p.Resolve("done")
+	assert.Equal(t, StateFulfilled, p.State())
+	assert.Equal(t, "fulfilled", p.State().String())
+	assert.True(t, p.IsFulfilled())
+	assert.Equal(t, "done", p.Value())
+	assert.Nil(t, p.Reason())
+
+	p = Rejected("failed")
+	assert.True(t, p.IsRejected())
+	assert.False(t, p.IsPending())
+	assert.Nil(t, p.Value())
+	assert.Equal(t, "failed", p.Reason())
+
+	// A promise adopting a pending one is still pending.
+	assert.True(t, Resolved(NewCancelable(nil)).IsPending())
+}
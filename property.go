@@ -0,0 +1,180 @@
+package promise
+
+import (
+	"reflect"
+	"sync"
+	"unicode"
+
+	"github.com/gopherjs/gopherjs/js"
+)
+
+// PropertyOptions configures ExportProperty.
+type PropertyOptions struct {
+	// Cached makes reads of the property return the most recently known value
+	// directly instead of a promise.  The value is loaded with the getter on
+	// the first read (which returns undefined) and updated by every write.
+	Cached bool
+}
+
+// ExportProperty defines an accessor property called name on obj that is
+// backed by a Go getter and setter, for JS frameworks that bind to properties
+// rather than methods.  Either of getter or setter may be nil to make the
+// property write-only or read-only.
+//
+// The getter is a function with no parameters and the setter is a function
+// with a single parameter, to which the assigned value is converted as
+// Promisify converts arguments.  Both are promisified: their results are
+// converted for JS, and their errors serialized, as by Promisify.
+//
+// Reading the property calls the getter and returns a promise for its result
+// (unless opts.Cached is set).  Assigning to the property calls the setter.
+// Since an assignment can't return a promise, a method called "set" + Name is
+// also defined that calls the setter and returns a promise of completion:
+//
+//	promise.ExportProperty(obj, "volume", player.Volume, player.SetVolume, promise.PropertyOptions{})
+//
+//	// In JS:
+//	obj.volume.then(function(v) { ... });
+//	obj.volume = 11;
+//	obj.setVolume(11).then(function() { ... });
+//
+// Reads and writes of the property are performed one at a time, in order, so
+// a read following a write sees the written value.
+func ExportProperty(obj *js.Object, name string, getter, setter interface{}, opts PropertyOptions) {
+	prop := newProperty(getter, setter)
+	desc := js.Global.Get("Object").New()
+	desc.Set("enumerable", true)
+	desc.Set("configurable", true)
+	if getter != nil {
+		desc.Set("get", func() interface{} {
+			if !opts.Cached {
+				return prop.read().Js()
+			}
+			val, loaded := prop.cached()
+			if !loaded {
+				prop.read()
+			}
+			return val
+		})
+	}
+	if setter != nil {
+		desc.Set("set", func(val *js.Object) { prop.write(val) })
+		obj.Set("set"+upperFirst(name), func(val *js.Object) *js.Object {
+			return prop.write(val).Js()
+		})
+	}
+	js.Global.Get("Object").Call("defineProperty", obj, name, desc)
+}
+
+// property serializes the reads and writes of an exported property and
+// remembers its latest value.
+type property struct {
+	get, set reflect.Value
+	q        Queue
+
+	mu     sync.Mutex
+	value  interface{}
+	loaded bool
+}
+
+func newProperty(getter, setter interface{}) *property {
+	prop := &property{}
+	if getter != nil {
+		prop.get = reflect.ValueOf(getter)
+	}
+	if setter != nil {
+		prop.set = reflect.ValueOf(setter)
+	}
+	return prop
+}
+
+// read returns a promise for the value returned by the getter.
+func (prop *property) read() *Promise {
+	return prop.q.Push(func() *Promise {
+		return prop.call(prop.get, func(conv converter, p *Promise) ([]reflect.Value, error) {
+			return callArgs(prop.get.Type(), p, nil), nil
+		}).Then(func(val interface{}) interface{} {
+			prop.remember(val)
+			return val
+		}, nil)
+	})
+}
+
+// write returns a promise for the result of calling the setter with val,
+// converted for its parameter.
+func (prop *property) write(val interface{}) *Promise {
+	return prop.q.Push(func() *Promise {
+		var written interface{}
+		return prop.call(prop.set, func(conv converter, p *Promise) ([]reflect.Value, error) {
+			arg, err := prop.convert(conv, val)
+			if err != nil {
+				return nil, err
+			}
+			written = conv.convertResult(arg.Interface())
+			return []reflect.Value{arg}, nil
+		}).Then(func(result interface{}) interface{} {
+			prop.remember(written)
+			return result
+		}, nil)
+	})
+}
+
+// call calls fn, the getter or the setter, with the arguments returned by args
+// and returns a promise for its results, converted and serialized for JS as
+// by Promisify.
+func (prop *property) call(fn reflect.Value, args func(conv converter, p *Promise) ([]reflect.Value, error)) *Promise {
+	c := Config{}.resolved()
+	conv := newConverter(c)
+	p := newPromiseConfig(&c, StackDefault)
+	p.schedule(func() {
+		defer func() {
+			if x := recover(); x != nil {
+				p.Reject(x)
+			}
+		}()
+		in, err := args(conv, p)
+		var value interface{}
+		if err == nil {
+			value, err = splitResults(fn.Call(in), hasLastError(fn.Type()))
+		}
+		if err != nil {
+			p.Reject(jsOutcome(c, conv, nil, err))
+		} else {
+			p.Resolve(jsOutcome(c, conv, value, nil))
+		}
+	})
+	return p
+}
+
+// convert converts val, a JS value or a Go value as decoded by gopherjs, for
+// the parameter of the setter.
+func (prop *property) convert(conv converter, val interface{}) (reflect.Value, error) {
+	t := prop.set.Type().In(0)
+	if o, ok := val.(*js.Object); ok {
+		return conv.convertArg(o, t, "value")
+	}
+	return conv.convertValue(val, t, "value")
+}
+
+// cached returns the latest known value and whether there is one.
+func (prop *property) cached() (interface{}, bool) {
+	prop.mu.Lock()
+	defer prop.mu.Unlock()
+	return prop.value, prop.loaded
+}
+
+func (prop *property) remember(val interface{}) {
+	prop.mu.Lock()
+	prop.value, prop.loaded = val, true
+	prop.mu.Unlock()
+}
+
+// upperFirst capitalizes the first letter of name.
+func upperFirst(name string) string {
+	if name == "" {
+		return name
+	}
+	runes := []rune(name)
+	runes[0] = unicode.ToUpper(runes[0])
+	return string(runes)
+}
@@ -0,0 +1,78 @@
+package promise
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProperty(t *testing.T) {
+	defer time.AfterFunc(time.Second, t.FailNow).Stop() // limit test to 1 second running time.
+
+	volume := 3
+	failure := errors.New("too loud")
+	prop := newProperty(
+		func() int { return volume },
+		func(v int) error {
+			if v > 10 {
+				return failure
+			}
+			volume = v
+			return nil
+		})
+
+	_, loaded := prop.cached()
+	assert.False(t, loaded)
+	val, ok := settled(prop.read())
+	assert.True(t, ok)
+	assert.Equal(t, 3, val)
+	val, loaded = prop.cached()
+	assert.True(t, loaded)
+	assert.Equal(t, 3, val)
+
+	// Reads and writes are performed in order.  Values are converted for
+	// the setter, e.g. JS numbers, which gopherjs decodes as float64.
+	write := prop.write(7.0)
+	read := prop.read()
+	_, ok = settled(write)
+	assert.True(t, ok)
+	val, _ = settled(read)
+	assert.Equal(t, 7, val)
+
+	// Errors are serialized for JS as by Promisify.
+	val, ok = settled(prop.write(11))
+	assert.False(t, ok)
+	assert.Equal(t, "too loud", val)
+	val, _ = prop.cached()
+	assert.Equal(t, 7, val)
+
+	_, ok = settled(prop.write("loud"))
+	assert.False(t, ok)
+	assert.Equal(t, 7, volume)
+}
+
+func TestPropertyResults(t *testing.T) {
+	defer time.AfterFunc(time.Second, t.FailNow).Stop() // limit test to 1 second running time.
+	defer Configure(Config{})
+
+	type player struct{ Volume int }
+	prop := newProperty(func() (*player, error) { return &player{3}, nil }, nil)
+	val, ok := settled(prop.read())
+	assert.True(t, ok)
+	assert.Equal(t, map[string]interface{}{"volume": 3}, val)
+
+	Configure(Config{ErrorSerializer: func(err error) interface{} { return "serialized: " + err.Error() }})
+	prop = newProperty(func() (int, error) { return 0, errors.New("muted") }, nil)
+	val, ok = settled(prop.read())
+	assert.False(t, ok)
+	assert.Equal(t, "serialized: muted", val)
+	_, loaded := prop.cached()
+	assert.False(t, loaded)
+}
+
+func TestUpperFirst(t *testing.T) {
+	assert.Equal(t, "Volume", upperFirst("volume"))
+	assert.Equal(t, "", upperFirst(""))
+}
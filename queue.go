@@ -0,0 +1,18 @@
+package promise
+
+// Queue runs promise-producing tasks strictly one at a time, in the order that
+// they were pushed.  A task is not started until the promise of the previous
+// task has settled.  The zero value is an empty queue ready to use.
+type Queue struct {
+	pool Pool
+}
+
+// Push adds task to the end of the queue and returns a promise that settles
+// the same way as the promise returned by task once it has run.  If the queue
+// is idle, task is called immediately.  A task that panics is treated as if
+// it returned a promise rejected with the panic value.
+func (q *Queue) Push(task func() *Promise) *Promise { return q.pool.Submit(task) }
+
+// Len returns the number of tasks waiting to run, not including the task that
+// is currently running.
+func (q *Queue) Len() int { return q.pool.Len() }
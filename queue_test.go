@@ -0,0 +1,41 @@
+package promise
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueue(t *testing.T) {
+	defer time.AfterFunc(time.Second, t.FailNow).Stop() // limit test to 1 second running time.
+
+	var q Queue
+	var g gauge
+	a := q.Push(g.task("a", false))
+	b := q.Push(g.task("b", true))
+	c := q.Push(func() *Promise { panic("c") })
+	d := q.Push(g.task("d", false))
+	assert.Equal(t, 3, q.Len())
+
+	val, ok := settled(d)
+	assert.True(t, ok)
+	assert.Equal(t, "d", val)
+	assert.Equal(t, 1, g.max)
+	assert.Equal(t, 0, q.Len())
+
+	val, ok = settled(a)
+	assert.True(t, ok)
+	assert.Equal(t, "a", val)
+	val, ok = settled(b)
+	assert.False(t, ok)
+	assert.Equal(t, "b", val)
+	val, ok = settled(c)
+	assert.False(t, ok)
+	assert.Equal(t, "c", val)
+
+	// An idle queue runs the next task right away.
+	val, ok = settled(q.Push(g.task("e", false)))
+	assert.True(t, ok)
+	assert.Equal(t, "e", val)
+}
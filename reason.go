@@ -0,0 +1,33 @@
+package promise
+
+import (
+	"log"
+
+	"github.com/gopherjs/gopherjs/js"
+)
+
+// coerceReason applies Config.NonErrorRejections to the reason that p is
+// being rejected with.
+func coerceReason(p *Promise, reason interface{}) interface{} {
+	switch reason.(type) {
+	case error, string, *js.Object:
+		return reason
+	}
+	c := Config{}.resolved()
+	switch c.NonErrorRejections {
+	case WrapNonErrors:
+		return RejectionError{reason}
+	case ReportNonErrors:
+		c.NonErrorHandler(p, reason)
+	}
+	return reason
+}
+
+// logNonError is the default Config.NonErrorHandler.
+func logNonError(p *Promise, reason interface{}) {
+	if label := p.Label(); label != "" {
+		log.Printf("promise: %s rejected with a non-error reason of type %T: %v", label, reason, reason)
+	} else {
+		log.Printf("promise: rejected with a non-error reason of type %T: %v", reason, reason)
+	}
+}
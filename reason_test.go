@@ -0,0 +1,37 @@
+package promise
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNonErrorRejections(t *testing.T) {
+	defer Configure(Config{})
+
+	rejected := func(reason interface{}) interface{} {
+		p := newPromise()
+		p.Reject(reason)
+		return p.value
+	}
+	type code struct{ N int }
+	err := errors.New("plain")
+
+	assert.Equal(t, code{1}, rejected(code{1}))
+
+	Configure(Config{NonErrorRejections: WrapNonErrors})
+	assert.Equal(t, RejectionError{code{2}}, rejected(code{2}))
+	assert.Equal(t, RejectionError{nil}, rejected(nil))
+	assert.Equal(t, err, rejected(err))
+	assert.Equal(t, "text", rejected("text"))
+
+	var reported []interface{}
+	Configure(Config{
+		NonErrorRejections: ReportNonErrors,
+		NonErrorHandler:    func(p *Promise, reason interface{}) { reported = append(reported, reason) },
+	})
+	assert.Equal(t, 3, rejected(3))
+	assert.Equal(t, err, rejected(err))
+	assert.Equal(t, []interface{}{3}, reported)
+}
@@ -0,0 +1,92 @@
+package promise
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/gopherjs/gopherjs/js"
+)
+
+// typeConverter converts values of one Go type, see RegisterConverter.
+type typeConverter struct {
+	toJS   func(v interface{}) *js.Object
+	fromJS func(o *js.Object) (interface{}, error)
+}
+
+var converters struct {
+	sync.RWMutex
+	byType map[reflect.Type]typeConverter
+}
+
+// RegisterConverter installs the conversion of the Go type goType for
+// promisified functions, for domain types that no built-in rule covers (UUIDs,
+// decimals, ...).  fromJS converts the arguments of type goType, including
+// struct fields, slice and array elements and map values of that type (e.g.
+// for a []uuid.UUID parameter), and must return a value of that type; an
+// error rejects the call.  toJS converts
+// results of type goType, including the elements of multiple results.  Either
+// function may be nil to leave that direction to the built-in rules.
+//
+// For example:
+//
+//	promise.RegisterConverter(reflect.TypeOf(uuid.UUID{}),
+//		func(v interface{}) *js.Object { return js.InternalObject(v.(uuid.UUID).String()) },
+//		func(o *js.Object) (interface{}, error) { return uuid.Parse(o.String()) })
+//
+// Registering a converter for a type replaces any previous one.
+func RegisterConverter(goType reflect.Type, toJS func(v interface{}) *js.Object, fromJS func(o *js.Object) (interface{}, error)) {
+	converters.Lock()
+	defer converters.Unlock()
+	if converters.byType == nil {
+		converters.byType = map[reflect.Type]typeConverter{}
+	}
+	converters.byType[goType] = typeConverter{toJS, fromJS}
+}
+
+func lookupConverter(t reflect.Type) (typeConverter, bool) {
+	converters.RLock()
+	defer converters.RUnlock()
+	c, ok := converters.byType[t]
+	return c, ok
+}
+
+// registeredFromJS converts arg for a parameter of type t with a registered
+// converter, if there is one.
+func registeredFromJS(arg *js.Object, t reflect.Type, path string) (reflect.Value, bool, error) {
+	c, ok := lookupConverter(t)
+	if !ok || c.fromJS == nil {
+		return reflect.Value{}, false, nil
+	}
+	v, err := c.convert(arg, t, path)
+	return v, true, err
+}
+
+// convert converts arg to type t with c.fromJS.
+func (c typeConverter) convert(arg *js.Object, t reflect.Type, path string) (reflect.Value, error) {
+	v, err := c.fromJS(arg)
+	if err != nil {
+		return reflect.Value{}, fmt.Errorf("promise: %s: %v", path, err)
+	}
+	rv := reflect.ValueOf(v)
+	if !rv.IsValid() {
+		return reflect.Zero(t), nil
+	}
+	if !rv.Type().AssignableTo(t) {
+		return reflect.Value{}, &ConversionError{path, t, v}
+	}
+	return rv, nil
+}
+
+// registeredToJS converts a result with a registered converter, if there is
+// one for its type.
+func registeredToJS(v interface{}) (interface{}, bool) {
+	if v == nil {
+		return nil, false
+	}
+	c, ok := lookupConverter(reflect.TypeOf(v))
+	if !ok || c.toJS == nil {
+		return nil, false
+	}
+	return c.toJS(v), true
+}
@@ -0,0 +1,86 @@
+package promise
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/gopherjs/gopherjs/js"
+	"github.com/stretchr/testify/assert"
+)
+
+type celsius float64
+
+func TestRegisterConverter(t *testing.T) {
+	typ := reflect.TypeOf(celsius(0))
+	defer func() {
+		converters.Lock()
+		delete(converters.byType, typ)
+		converters.Unlock()
+	}()
+
+	marker := &js.Object{}
+	RegisterConverter(typ,
+		func(v interface{}) *js.Object { return marker },
+		func(o *js.Object) (interface{}, error) {
+			if o == nil {
+				return nil, errors.New("missing")
+			}
+			return celsius(21), nil
+		})
+
+	assert.Equal(t, marker, converter{}.convertResult(celsius(3)))
+	assert.Equal(t, []interface{}{marker, 1}, converter{}.convertResult([]interface{}{celsius(3), 1}))
+	assert.Equal(t, 1.5, converter{}.convertResult(1.5))
+
+	v, ok, err := registeredFromJS(marker, typ, "arguments[0]")
+	assert.True(t, ok)
+	assert.NoError(t, err)
+	assert.Equal(t, celsius(21), v.Interface())
+
+	_, _, err = registeredFromJS(nil, typ, "arguments[1]")
+	assert.EqualError(t, err, "promise: arguments[1]: missing")
+
+	_, ok, _ = registeredFromJS(marker, reflect.TypeOf(0), "arguments[0]")
+	assert.False(t, ok)
+}
+
+func TestRegisteredConverterNested(t *testing.T) {
+	typ := reflect.TypeOf(celsius(0))
+	defer func() {
+		converters.Lock()
+		delete(converters.byType, typ)
+		converters.Unlock()
+	}()
+
+	marker := &js.Object{}
+	RegisterConverter(typ, nil, func(o *js.Object) (interface{}, error) {
+		if o != marker {
+			return nil, errors.New("not a temperature")
+		}
+		return celsius(21), nil
+	})
+	// Stands in for gopherjs, which converts the values back to JS.
+	c := converter{externalize: func(v interface{}) *js.Object {
+		if v == "21C" {
+			return marker
+		}
+		return nil
+	}}
+
+	v, err := c.convertValue([]interface{}{"21C", "21C"}, reflect.TypeOf([]celsius(nil)), "arguments[0]")
+	assert.NoError(t, err)
+	assert.Equal(t, []celsius{21, 21}, v.Interface())
+
+	type reading struct{ Temp celsius }
+	v, err = c.convertValue(map[string]interface{}{"temp": "21C"}, reflect.TypeOf(reading{}), "arguments[0]")
+	assert.NoError(t, err)
+	assert.Equal(t, reading{21}, v.Interface())
+
+	v, err = c.convertValue(map[string]interface{}{"kitchen": "21C"}, reflect.TypeOf(map[string]celsius(nil)), "arguments[0]")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]celsius{"kitchen": 21}, v.Interface())
+
+	_, err = c.convertValue([]interface{}{"21C", "hot"}, reflect.TypeOf([]celsius(nil)), "arguments[0]")
+	assert.EqualError(t, err, "promise: arguments[0][1]: not a temperature")
+}
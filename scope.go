@@ -0,0 +1,138 @@
+package promise
+
+import (
+	"errors"
+	"sync"
+)
+
+// ErrScopeEnded is the reason used to cancel the promises that are still
+// pending in a Scope when the scope's own work has finished.
+var ErrScopeEnded = errors.New("promise: scope ended")
+
+// Scope ties the lifetime of asynchronous work to the component that started
+// it (structured concurrency).  Promises are tracked by a scope with Track or
+// Go, and when the scope ends or fails all of its still-pending promises are
+// canceled, so no work leaks past the scope.
+//
+//	promise.NewScope().Run(func(s *promise.Scope) *promise.Promise {
+//		s.Go(keepAlive)
+//		return s.Track(loadDashboard())
+//	})
+type Scope struct {
+	token  *CancelToken
+	result *Promise
+
+	mu      sync.Mutex
+	active  int  // tracked promises that are still pending
+	ended   bool // the promise returned to Run has settled
+	settled bool // result has been settled
+	failed  bool
+	reason  interface{}
+	value   interface{}
+}
+
+// NewScope returns a new, empty scope.
+func NewScope() *Scope {
+	return &Scope{token: NewCancelToken(), result: newPromise()}
+}
+
+// Run calls fn with the scope and returns a promise for the scope's result.
+// The scope ends when the promise returned by fn settles, at which point all
+// tracked promises that are still pending are canceled with ErrScopeEnded.
+// If fn's promise or any tracked promise is rejected first, the scope fails:
+// the remaining promises (including fn's) are canceled with that reason.
+//
+// The returned promise settles only after every tracked promise has settled.
+// It is resolved with the value of fn's promise, or rejected with the reason
+// that the scope failed.  Run should be called at most once per scope.
+func (s *Scope) Run(fn func(s *Scope) *Promise) *Promise {
+	main := call(func() *Promise { return fn(s) })
+	main.WithToken(s.token)
+	main.Then(
+		func(val interface{}) interface{} {
+			s.end(val)
+			return val
+		},
+		func(err interface{}) interface{} {
+			s.fail(err)
+			s.end(nil)
+			return err
+		})
+	return s.result
+}
+
+// Track adds p to the scope and returns it.  If the scope has already ended,
+// p is canceled immediately.
+func (s *Scope) Track(p *Promise) *Promise {
+	s.mu.Lock()
+	s.active++
+	s.mu.Unlock()
+	p.WithToken(s.token)
+	p.Then(
+		func(val interface{}) interface{} {
+			s.untrack()
+			return val
+		},
+		func(err interface{}) interface{} {
+			s.fail(err)
+			s.untrack()
+			return err
+		})
+	return p
+}
+
+// Go calls task and tracks the promise it returns.  A task that panics is
+// treated as if it returned a promise rejected with the panic value.
+func (s *Scope) Go(task func() *Promise) *Promise { return s.Track(call(task)) }
+
+// Cancel fails the scope with reason, canceling all of its pending promises.
+func (s *Scope) Cancel(reason interface{}) { s.fail(reason) }
+
+// Token returns the CancelToken that is canceled when the scope ends or fails.
+// Producers can use it to learn that their work is no longer needed.
+func (s *Scope) Token() *CancelToken { return s.token }
+
+// fail records reason as the scope's failure and cancels everything, unless the
+// scope was already canceled (in which case reason is likely just the echo of
+// that cancellation).
+func (s *Scope) fail(reason interface{}) {
+	s.mu.Lock()
+	if s.token.Canceled() {
+		s.mu.Unlock()
+		return
+	}
+	s.failed, s.reason = true, reason
+	s.mu.Unlock()
+	s.token.Cancel(reason)
+}
+
+func (s *Scope) end(val interface{}) {
+	s.mu.Lock()
+	s.ended, s.value = true, val
+	s.mu.Unlock()
+	s.token.Cancel(ErrScopeEnded)
+	s.settleIfDone()
+}
+
+func (s *Scope) untrack() {
+	s.mu.Lock()
+	s.active--
+	s.mu.Unlock()
+	s.settleIfDone()
+}
+
+func (s *Scope) settleIfDone() {
+	s.mu.Lock()
+	if !s.ended || s.active > 0 || s.settled {
+		s.mu.Unlock()
+		return
+	}
+	s.settled = true
+	failed, reason, value := s.failed, s.reason, s.value
+	s.mu.Unlock()
+	if failed {
+		s.result.Reject(reason)
+	} else {
+		s.result.Resolve(value)
+	}
+}
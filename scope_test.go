@@ -0,0 +1,63 @@
+package promise
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScopeEnds(t *testing.T) {
+	defer time.AfterFunc(time.Second, t.FailNow).Stop() // limit test to 1 second running time.
+
+	var g gauge
+	stopped := make(chan interface{}, 1)
+	var background *Promise
+	val, ok := settled(NewScope().Run(func(s *Scope) *Promise {
+		background = s.Track(NewCancelable(func(r interface{}) { stopped <- r }).Promise)
+		return s.Go(g.task("done", false))
+	}))
+	assert.True(t, ok)
+	assert.Equal(t, "done", val)
+
+	// The background work was canceled when the scope ended.
+	assert.Equal(t, ErrScopeEnded, <-stopped)
+	val, ok = settled(background)
+	assert.False(t, ok)
+	assert.Equal(t, &CancelError{ErrScopeEnded}, val)
+}
+
+func TestScopeFails(t *testing.T) {
+	defer time.AfterFunc(time.Second, t.FailNow).Stop() // limit test to 1 second running time.
+
+	var g gauge
+	var never Promise
+	s := NewScope()
+	var slow *Promise
+	val, ok := settled(s.Run(func(s *Scope) *Promise {
+		slow = s.Track(&never)
+		s.Go(g.task("child failed", true))
+		return never.Then(panicIfCalled, nil)
+	}))
+	assert.False(t, ok)
+	assert.Equal(t, "child failed", val)
+	val, _ = settled(slow)
+	assert.Equal(t, &CancelError{"child failed"}, val)
+	assert.True(t, s.Token().Canceled())
+
+	// Tracking after the scope is over cancels right away.
+	val, ok = settled(s.Track(new(Promise)))
+	assert.False(t, ok)
+	assert.Equal(t, &CancelError{"child failed"}, val)
+}
+
+func TestScopeCancel(t *testing.T) {
+	defer time.AfterFunc(time.Second, t.FailNow).Stop() // limit test to 1 second running time.
+
+	s := NewScope()
+	result := s.Run(func(s *Scope) *Promise { return s.Track(new(Promise)) })
+	s.Cancel("unmounted")
+	val, ok := settled(result)
+	assert.False(t, ok)
+	assert.Equal(t, "unmounted", val)
+}
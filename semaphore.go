@@ -0,0 +1,120 @@
+package promise
+
+import "sync"
+
+// Semaphore limits access to a shared resource to a fixed number of holders
+// at a time.  Unlike Pool, the holders decide when to give up their slot,
+// which makes it suitable for guarding resources used across several steps of
+// a chain (e.g. at most 2 concurrent uploads).
+//
+// The zero value is a semaphore with a single slot ready to use.
+type Semaphore struct {
+	mu      sync.Mutex
+	size    int
+	held    int
+	waiters []*Promise
+
+	// onIdle, if set, is called whenever a release leaves the semaphore with
+	// no holders and no waiters.
+	onIdle func()
+}
+
+// NewSemaphore returns a semaphore with n slots.  An n less than 1 is treated
+// as 1.
+func NewSemaphore(n int) *Semaphore {
+	return &Semaphore{size: n}
+}
+
+// Acquire returns a promise that is resolved with a release function (of type
+// func()) once a slot is available.  The release function must be called to
+// give up the slot; calling it more than once has no effect.  Waiters are
+// granted slots in FIFO order.
+//
+// To abandon a pending Acquire, reject (or cancel) the promise that it
+// returned.  An abandoned waiter leaves the line and is never granted a slot.
+func (s *Semaphore) Acquire() *Promise {
+	p := newPromise()
+	s.mu.Lock()
+	if s.held < s.limit() {
+		s.held++
+		s.mu.Unlock()
+		p.Resolve(s.releaser())
+		return p
+	}
+	s.waiters = append(s.waiters, p)
+	s.mu.Unlock()
+	p.onSettle(func() { s.remove(p) })
+	return p
+}
+
+// remove removes the waiter p, once it has settled.
+func (s *Semaphore) remove(p *Promise) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, w := range s.waiters {
+		if w == p {
+			s.waiters = append(s.waiters[:i:i], s.waiters[i+1:]...)
+			return
+		}
+	}
+}
+
+// TryAcquire acquires a slot only if one is available immediately, returning
+// the release function and whether a slot was acquired.
+func (s *Semaphore) TryAcquire() (release func(), ok bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.held >= s.limit() {
+		return nil, false
+	}
+	s.held++
+	return s.releaser(), true
+}
+
+// Waiting returns the number of Acquire calls waiting for a slot.
+func (s *Semaphore) Waiting() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.waiters)
+}
+
+func (s *Semaphore) limit() int {
+	if s.size < 1 {
+		return 1
+	}
+	return s.size
+}
+
+// idle returns whether the semaphore has no holders and no waiters.
+func (s *Semaphore) idle() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.held == 0 && len(s.waiters) == 0
+}
+
+func (s *Semaphore) releaser() func() {
+	var once sync.Once
+	return func() { once.Do(s.release) }
+}
+
+// release hands the released slot to the next waiter that hasn't been
+// abandoned, or frees it if there is none.  A waiter abandoned while the slot
+// is being handed to it is skipped.
+func (s *Semaphore) release() {
+	s.mu.Lock()
+	for len(s.waiters) > 0 {
+		w := s.waiters[0]
+		s.waiters = s.waiters[1:]
+		s.mu.Unlock()
+		if w.tryResolve(s.releaser()) {
+			return
+		}
+		s.mu.Lock()
+	}
+	s.held--
+	idle := s.held == 0 && s.onIdle != nil
+	s.mu.Unlock()
+	if idle {
+		s.onIdle()
+	}
+}
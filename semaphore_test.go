@@ -0,0 +1,67 @@
+package promise
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSemaphore(t *testing.T) {
+	defer time.AfterFunc(time.Second, t.FailNow).Stop() // limit test to 1 second running time.
+
+	s := NewSemaphore(2)
+	r1, ok := settled(s.Acquire())
+	assert.True(t, ok)
+	release2, ok := s.TryAcquire()
+	assert.True(t, ok)
+	_, ok = s.TryAcquire()
+	assert.False(t, ok)
+
+	abandoned := s.Acquire()
+	third := s.Acquire()
+	assert.Equal(t, 2, s.Waiting())
+	abandoned.Reject("gave up")
+	assert.Equal(t, 1, s.Waiting())
+
+	// Releasing skips the abandoned waiter and grants the slot to the next.
+	r1.(func())()
+	r1.(func())() // releasing twice has no effect.
+	r3, ok := settled(third)
+	assert.True(t, ok)
+	assert.Equal(t, 0, s.Waiting())
+	_, ok = s.TryAcquire()
+	assert.False(t, ok)
+
+	release2()
+	r3.(func())()
+	release, ok := s.TryAcquire()
+	assert.True(t, ok)
+	release()
+}
+
+func TestSemaphoreAbandonRace(t *testing.T) {
+	defer time.AfterFunc(time.Second, t.FailNow).Stop() // limit test to 1 second running time.
+
+	s := NewSemaphore(1)
+	for i := 0; i < 50; i++ {
+		release, _ := s.TryAcquire()
+		w := s.Acquire()
+		var wg sync.WaitGroup
+		wg.Add(2)
+		go func() { defer wg.Done(); release() }()
+		go func() { defer wg.Done(); w.Cancel("gave up") }()
+		wg.Wait()
+		// Either the waiter got the slot before it was abandoned, or the
+		// slot was freed: it is never lost.
+		if val, ok := settled(w); ok {
+			val.(func())()
+		}
+		release, ok := s.TryAcquire()
+		if assert.True(t, ok, "slot lost") {
+			release()
+		}
+		assert.Equal(t, 0, s.Waiting())
+	}
+}
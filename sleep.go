@@ -0,0 +1,44 @@
+package promise
+
+import "time"
+
+// Sleep returns a promise that resolves with nil after d.  It is the timer to
+// await in a Coroutine body or to race against other promises as a timeout:
+//
+//	promise.Coroutine(func(await func(*promise.Promise) (interface{}, error)) (interface{}, error) {
+//		for !ready() {
+//			await(promise.Sleep(100 * time.Millisecond))
+//		}
+//		...
+//	})
+//
+// No goroutine waits for the timer.  Canceling the promise stops the timer.
+func Sleep(d time.Duration) *Promise {
+	var timer *time.Timer
+	p := NewCancelable(func(interface{}) { timer.Stop() })
+	timer = time.AfterFunc(d, func() { p.Resolve(nil) })
+	return p.Promise
+}
+
+// NextTick returns a promise that resolves with nil on the next turn of the
+// configured scheduler: a new goroutine by default, or the next macrotask or
+// microtask with a JS scheduler.  Waiting for it yields to the event loop in
+// the middle of a long computation, e.g. between chunks of work in a chain or
+// a Coroutine.
+func NextTick() *Promise {
+	p := newPromise()
+	p.schedule(func() { p.Resolve(nil) })
+	return p
+}
+
+// Never returns a promise that never settles: Resolve, Reject and Cancel have
+// no effect on it.  It is the neutral element when racing promises, e.g. an
+// optional timeout that isn't set, and a convenient pending promise in tests.
+//
+// Unlike a promise that is just never settled, it isn't counted as created
+// (and so as pending) by Stats, and EnableGraph doesn't highlight it as
+// stuck.
+func Never() *Promise {
+	c := CurrentConfig()
+	return &Promise{scheduler: c.Scheduler, synchronous: c.SynchronousThen, never: true}
+}
@@ -0,0 +1,66 @@
+package promise
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSleep(t *testing.T) {
+	defer time.AfterFunc(time.Second, t.FailNow).Stop() // limit test to 1 second running time.
+
+	start := time.Now()
+	val, ok := settled(Sleep(20 * time.Millisecond))
+	assert.True(t, ok)
+	assert.Nil(t, val)
+	assert.True(t, time.Since(start) >= 20*time.Millisecond)
+
+	// Canceling stops the timer, so the late Resolve doesn't happen.
+	p := Sleep(10 * time.Millisecond)
+	p.Cancel("no longer needed")
+	val, ok = settled(p)
+	assert.False(t, ok)
+	assert.Equal(t, &CancelError{"no longer needed"}, val)
+	time.Sleep(20 * time.Millisecond)
+	val, _ = settled(p)
+	assert.Equal(t, &CancelError{"no longer needed"}, val)
+}
+
+func TestNextTick(t *testing.T) {
+	defer time.AfterFunc(time.Second, t.FailNow).Stop() // limit test to 1 second running time.
+
+	defer Configure(Config{})
+	var tasks []func()
+	Configure(Config{Scheduler: SchedulerFunc(func(task func()) { tasks = append(tasks, task) })})
+
+	p := NextTick()
+	assert.True(t, p.IsPending())
+	if assert.Len(t, tasks, 1) {
+		tasks[0]()
+	}
+	assert.Equal(t, StateFulfilled, p.state)
+	assert.Nil(t, p.value)
+}
+
+func TestNever(t *testing.T) {
+	ResetStats()
+	EnableGraph(true)
+	defer EnableGraph(false)
+
+	p := Never()
+	p.Resolve(1)
+	p.Reject("no")
+	p.Cancel("stop")
+	assert.True(t, p.IsPending())
+	assert.Equal(t, int64(0), Stats().Created)
+
+	child := p.Then(panicIfCalled, panicIfCalled)
+	assert.True(t, child.IsPending())
+	nodes, _ := Graph()
+	if assert.Len(t, nodes, 2) {
+		assert.False(t, nodes[0].Never) // child
+		assert.True(t, nodes[1].Never)
+	}
+	assert.Contains(t, GraphDOT(), `p2 [label="#2\npending"];`)
+}
@@ -0,0 +1,190 @@
+package promise
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/gopherjs/gopherjs/js"
+)
+
+var (
+	// ErrStreamClosed is the rejection reason for promises from an EventStream
+	// that was closed before they were resolved.
+	ErrStreamClosed = errors.New("promise: event stream closed")
+	// ErrStreamGaveUp is the error reported by an EventStream that could not
+	// reconnect within SSEOptions.MaxRetries attempts.
+	ErrStreamGaveUp = errors.New("promise: event stream reconnect attempts exhausted")
+)
+
+// SSEEvent is a single Server-Sent Event.
+type SSEEvent struct {
+	Type string // "message" unless the server named the event
+	Data string
+	ID   string // the last event id seen on the stream
+}
+
+// SSEOptions configures ConnectSSE.
+type SSEOptions struct {
+	// Events lists the named event types to listen for in addition to the
+	// default "message" events.
+	Events []string
+	// WithCredentials sends cookies with cross-origin requests.
+	WithCredentials bool
+	// Backoff determines how long to wait before reconnecting after the
+	// connection is lost.  The attempt count is reset once a connection is
+	// opened.  If nil, a jittered exponential backoff from 1s to 30s is used.
+	Backoff Backoff
+	// MaxRetries is the number of consecutive reconnect attempts after which
+	// the stream gives up with ErrStreamGaveUp.  Zero means retry forever.
+	MaxRetries int
+}
+
+// EventStream is a Server-Sent Events connection, see ConnectSSE.
+type EventStream struct {
+	opts SSEOptions
+	dial func(s *EventStream) (close func())
+	all  broadcast
+
+	mu       sync.Mutex
+	close    func()
+	attempts int
+	delay    time.Duration
+	closed   bool
+}
+
+// ConnectSSE opens a Server-Sent Events connection to url using the browser's
+// EventSource and keeps it open until Close is called, reconnecting with
+// backoff whenever the browser gives up on the connection.
+//
+// For example:
+//
+//	stream := promise.ConnectSSE("/api/updates", promise.SSEOptions{})
+//	stream.Observable().Subscribe(promise.Observer{
+//		Next: func(v interface{}) { render(v.(promise.SSEEvent).Data) },
+//	})
+func ConnectSSE(url string, opts SSEOptions) *EventStream {
+	return newEventStream(opts, func(s *EventStream) func() {
+		init := js.Global.Get("Object").New()
+		init.Set("withCredentials", opts.WithCredentials)
+		source := js.Global.Get("EventSource").New(url, init)
+		source.Set("onopen", func() { s.opened() })
+		source.Set("onerror", func() {
+			// EventSource retries by itself unless the connection is CLOSED.
+			if source.Get("readyState").Int() == 2 {
+				s.lost()
+			}
+		})
+		listen := func(event *js.Object) {
+			s.received(SSEEvent{
+				Type: event.Get("type").String(),
+				Data: event.Get("data").String(),
+				ID:   event.Get("lastEventId").String(),
+			})
+		}
+		source.Call("addEventListener", "message", listen)
+		for _, name := range opts.Events {
+			source.Call("addEventListener", name, listen)
+		}
+		return func() { source.Call("close") }
+	})
+}
+
+func newEventStream(opts SSEOptions, dial func(s *EventStream) func()) *EventStream {
+	if opts.Backoff == nil {
+		opts.Backoff = ExponentialBackoff{Initial: time.Second, Max: 30 * time.Second, Jitter: true}
+	}
+	s := &EventStream{opts: opts, dial: dial}
+	s.connect()
+	return s
+}
+
+// Observable returns an observable of every SSEEvent received on the stream.
+// It completes when the stream is closed and errors with ErrStreamGaveUp if
+// reconnecting fails.
+func (s *EventStream) Observable() *Observable {
+	return NewObservable(func(o Observer) func() {
+		id := s.all.add(o)
+		return func() { s.all.remove(id) }
+	})
+}
+
+// Next returns a promise that is resolved with the next SSEEvent of the given
+// type.  It is rejected with ErrStreamClosed or ErrStreamGaveUp if the stream
+// ends first.
+func (s *EventStream) Next(event string) *Promise {
+	p := newPromise()
+	s.Observable().
+		Filter(func(v interface{}) bool { return v.(SSEEvent).Type == event }).
+		Take(1).
+		Subscribe(Observer{
+			Next:  func(v interface{}) { p.Resolve(v) },
+			Error: func(err interface{}) { p.Reject(err) },
+			Complete: func() {
+				if p.IsPending() {
+					p.Reject(ErrStreamClosed)
+				}
+			},
+		})
+	return p
+}
+
+// Close closes the connection and completes the stream.  Calling Close more
+// than once has no effect.
+func (s *EventStream) Close() {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return
+	}
+	s.closed = true
+	stop := s.close
+	s.close = nil
+	s.mu.Unlock()
+	if stop != nil {
+		stop()
+	}
+	s.all.complete()
+}
+
+func (s *EventStream) connect() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.closed {
+		s.close = s.dial(s)
+	}
+}
+
+func (s *EventStream) opened() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.attempts, s.delay = 0, 0
+}
+
+func (s *EventStream) received(e SSEEvent) {
+	s.all.next(e)
+}
+
+// lost schedules a reconnect after the connection has been closed by the
+// browser, or gives up if there were too many attempts.
+func (s *EventStream) lost() {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return
+	}
+	if s.close != nil {
+		s.close()
+		s.close = nil
+	}
+	s.attempts++
+	if s.opts.MaxRetries > 0 && s.attempts > s.opts.MaxRetries {
+		s.closed = true
+		s.mu.Unlock()
+		s.all.error(ErrStreamGaveUp)
+		return
+	}
+	s.delay = s.opts.Backoff.Delay(s.attempts, s.delay)
+	s.mu.Unlock()
+	time.AfterFunc(s.delay, s.connect)
+}
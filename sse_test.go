@@ -0,0 +1,73 @@
+package promise
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeSource records the connections made by an EventStream.
+type fakeSource struct {
+	dials, closes chan bool
+}
+
+func (f *fakeSource) dial(s *EventStream) func() {
+	f.dials <- true
+	return func() { f.closes <- true }
+}
+
+func TestEventStream(t *testing.T) {
+	defer time.AfterFunc(time.Second, t.FailNow).Stop() // limit test to 1 second running time.
+
+	f := &fakeSource{make(chan bool, 10), make(chan bool, 10)}
+	s := newEventStream(SSEOptions{Backoff: ConstantBackoff{time.Millisecond}}, f.dial)
+	<-f.dials
+
+	values, done := s.Observable().ToChannel(10)
+	tick := s.Next("tick")
+	s.received(SSEEvent{Type: "message", Data: "hello"})
+	s.received(SSEEvent{Type: "tick", Data: "1"})
+	val, ok := settled(tick)
+	assert.True(t, ok)
+	assert.Equal(t, SSEEvent{Type: "tick", Data: "1"}, val)
+
+	// Reconnects after the connection is lost.
+	s.lost()
+	<-f.closes
+	<-f.dials
+
+	pending := s.Next("tick")
+	s.Close()
+	s.Close()
+	<-f.closes
+	_, ok = settled(done)
+	assert.True(t, ok)
+	assert.Equal(t, 2, len(values))
+	val, ok = settled(pending)
+	assert.False(t, ok)
+	assert.Equal(t, ErrStreamClosed, val)
+}
+
+func TestEventStreamGivesUp(t *testing.T) {
+	defer time.AfterFunc(time.Second, t.FailNow).Stop() // limit test to 1 second running time.
+
+	f := &fakeSource{make(chan bool, 10), make(chan bool, 10)}
+	s := newEventStream(SSEOptions{Backoff: ConstantBackoff{time.Millisecond}, MaxRetries: 2}, f.dial)
+	<-f.dials
+	s.lost()
+	<-f.dials
+	s.opened() // resets the attempts
+	s.lost()
+	<-f.dials
+	s.lost()
+	<-f.dials
+	s.lost()
+
+	val, ok := settled(s.Observable().ToPromise())
+	assert.False(t, ok)
+	assert.Equal(t, ErrStreamGaveUp, val)
+	val, _ = settled(s.Next("message"))
+	assert.Equal(t, ErrStreamGaveUp, val)
+	assert.Equal(t, 0, len(f.dials))
+}
@@ -0,0 +1,33 @@
+package promise
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStats(t *testing.T) {
+	defer time.AfterFunc(time.Second, t.FailNow).Stop() // limit test to 1 second running time.
+
+	time.Sleep(10 * time.Millisecond) // let stragglers from other tests finish.
+	ResetStats()
+	assert.Equal(t, Counters{}, Stats())
+
+	done := make(incrementor, 1)
+	var a Promise
+	a.Then(done.process, panicIfCalled)
+	a.Resolve(1)
+	assert.Equal(t, <-done, 1)
+	time.Sleep(10 * time.Millisecond) // let the child promise finish settling.
+
+	assert.Equal(t, Counters{
+		Created:    1, // the child returned from Then
+		Settled:    2, // a and its child
+		Handlers:   1, // only done.process
+		Goroutines: 1, // dispatching a's callbacks, and the child's on its trampoline
+	}, Stats())
+
+	ResetStats()
+	assert.Equal(t, Counters{}, Stats())
+}
@@ -0,0 +1,209 @@
+package promise
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrSupervisorStopped is the reason used to cancel a supervised task's
+// promise when its Supervisor is stopped.
+var ErrSupervisorStopped = errors.New("promise: supervisor stopped")
+
+// RestartPolicy determines when a Supervisor restarts its task.
+type RestartPolicy int
+
+const (
+	// RestartOnError restarts the task only when its promise is rejected.
+	RestartOnError RestartPolicy = iota
+	// RestartAlways restarts the task whenever its promise settles.
+	RestartAlways
+)
+
+// SupervisorState is the state of a Supervisor.
+type SupervisorState int
+
+const (
+	SupervisorRunning  SupervisorState = iota // the task is running
+	SupervisorWaiting                         // waiting to restart the task
+	SupervisorStopped                         // stopped by Stop
+	SupervisorFinished                        // the task fulfilled and won't be restarted
+	SupervisorFailed                          // gave up after too many restarts
+)
+
+func (s SupervisorState) String() string {
+	switch s {
+	case SupervisorRunning:
+		return "running"
+	case SupervisorWaiting:
+		return "waiting"
+	case SupervisorStopped:
+		return "stopped"
+	case SupervisorFinished:
+		return "finished"
+	case SupervisorFailed:
+		return "failed"
+	default:
+		return "unknown"
+	}
+}
+
+// SupervisorOptions configures a Supervisor.
+type SupervisorOptions struct {
+	Policy RestartPolicy
+	// MaxRestarts is the maximum number of times that the task is restarted,
+	// or unlimited if 0.
+	MaxRestarts int
+	// Backoff determines how long to wait before each restart.  The attempt
+	// passed to it counts the consecutive failures, and is 1 for a restart
+	// after the task fulfilled.  Restarts wait at least minRestartDelay (10ms)
+	// in any case, so that a task that settles immediately doesn't spin.
+	Backoff Backoff
+}
+
+// minRestartDelay is the shortest time that a Supervisor waits before
+// restarting its task.
+const minRestartDelay = 10 * time.Millisecond
+
+// SupervisorStatus is a snapshot of the state of a Supervisor.
+type SupervisorStatus struct {
+	State     SupervisorState
+	Restarts  int         // number of times that the task was restarted
+	LastError interface{} // reason of the most recent rejection, if any
+}
+
+// Supervisor keeps a long-running, promise-producing task (e.g. a websocket
+// connection or a polling loop) alive by restarting it according to a policy.
+type Supervisor struct {
+	task func() *Promise
+	opts SupervisorOptions
+	done *Promise
+
+	mu       sync.Mutex
+	status   SupervisorStatus
+	failures int
+	delay    time.Duration
+	current  *Promise
+	timer    *time.Timer
+}
+
+// Supervise starts task and returns a supervisor that restarts it as specified
+// by opts.  A task that panics is treated as if it returned a promise rejected
+// with the panic value.
+func Supervise(task func() *Promise, opts SupervisorOptions) *Supervisor {
+	s := &Supervisor{task: task, opts: opts, done: newPromise()}
+	s.start()
+	return s
+}
+
+// Status returns the current status of the supervisor.
+func (s *Supervisor) Status() SupervisorStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.status
+}
+
+// Done returns a promise that settles once the supervisor is done with the
+// task: it is resolved with nil when stopped, resolved with the task's value
+// when the task fulfills and won't be restarted, and rejected with the task's
+// last rejection reason when the supervisor gives up.
+func (s *Supervisor) Done() *Promise { return s.done.Then(nil, nil) }
+
+// Stop stops restarting the task and cancels the current run, if any, with
+// ErrSupervisorStopped.
+func (s *Supervisor) Stop() {
+	s.mu.Lock()
+	if s.status.State != SupervisorRunning && s.status.State != SupervisorWaiting {
+		s.mu.Unlock()
+		return
+	}
+	s.status.State = SupervisorStopped
+	if s.timer != nil {
+		s.timer.Stop()
+	}
+	current := s.current
+	s.mu.Unlock()
+
+	if current != nil {
+		current.Cancel(ErrSupervisorStopped)
+	}
+	s.done.Resolve(nil)
+}
+
+func (s *Supervisor) start() {
+	s.mu.Lock()
+	if s.status.State == SupervisorStopped {
+		s.mu.Unlock()
+		return
+	}
+	s.status.State = SupervisorRunning
+	s.mu.Unlock()
+
+	run := call(s.task)
+	s.mu.Lock()
+	if s.status.State == SupervisorStopped {
+		// Stopped while the task was starting, before it could be canceled.
+		s.mu.Unlock()
+		run.Cancel(ErrSupervisorStopped)
+		return
+	}
+	s.current = run
+	s.mu.Unlock()
+	run.Then(
+		func(val interface{}) interface{} {
+			s.exited(val, false)
+			return val
+		},
+		func(err interface{}) interface{} {
+			s.exited(err, true)
+			return err
+		})
+}
+
+// exited decides what to do after a run of the task settles with result.
+func (s *Supervisor) exited(result interface{}, failed bool) {
+	s.mu.Lock()
+	if s.status.State == SupervisorStopped {
+		s.mu.Unlock()
+		return
+	}
+	s.current = nil
+	if failed {
+		s.failures++
+		s.status.LastError = result
+	} else {
+		s.failures = 0
+	}
+
+	finished := !failed && s.opts.Policy == RestartOnError
+	exhausted := s.opts.MaxRestarts > 0 && s.status.Restarts >= s.opts.MaxRestarts
+	if finished || exhausted {
+		if failed {
+			s.status.State = SupervisorFailed
+		} else {
+			s.status.State = SupervisorFinished
+		}
+		s.mu.Unlock()
+		if failed {
+			s.done.Reject(result)
+		} else {
+			s.done.Resolve(result)
+		}
+		return
+	}
+
+	s.status.Restarts++
+	s.status.State = SupervisorWaiting
+	attempt, previous := s.failures, s.delay
+	if !failed {
+		attempt, previous = 1, 0
+	}
+	s.delay = minRestartDelay
+	if s.opts.Backoff != nil {
+		if d := s.opts.Backoff.Delay(attempt, previous); d > s.delay {
+			s.delay = d
+		}
+	}
+	s.timer = time.AfterFunc(s.delay, s.start)
+	s.mu.Unlock()
+}
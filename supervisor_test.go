@@ -0,0 +1,99 @@
+package promise
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSupervisorRestartsOnError(t *testing.T) {
+	defer time.AfterFunc(time.Second, t.FailNow).Stop() // limit test to 1 second running time.
+
+	runs := 0
+	var g gauge
+	s := Supervise(func() *Promise {
+		runs++
+		return g.task(runs, runs < 3)()
+	}, SupervisorOptions{Backoff: ConstantBackoff{time.Millisecond}})
+
+	val, ok := settled(s.Done())
+	assert.True(t, ok)
+	assert.Equal(t, 3, val)
+	assert.Equal(t, SupervisorStatus{SupervisorFinished, 2, 2}, s.Status())
+}
+
+func TestSupervisorGivesUp(t *testing.T) {
+	defer time.AfterFunc(time.Second, t.FailNow).Stop() // limit test to 1 second running time.
+
+	runs := 0
+	s := Supervise(func() *Promise {
+		runs++
+		panic(runs)
+	}, SupervisorOptions{Policy: RestartAlways, MaxRestarts: 2})
+
+	val, ok := settled(s.Done())
+	assert.False(t, ok)
+	assert.Equal(t, 3, val)
+	assert.Equal(t, SupervisorStatus{SupervisorFailed, 2, 3}, s.Status())
+	assert.Equal(t, "failed", s.Status().State.String())
+}
+
+func TestSupervisorRestartDelay(t *testing.T) {
+	defer time.AfterFunc(time.Second, t.FailNow).Stop() // limit test to 1 second running time.
+
+	// A task that fulfills immediately is restarted after a delay, rather
+	// than in a tight loop.
+	start := time.Now()
+	s := Supervise(func() *Promise { return Resolved("ok") }, SupervisorOptions{Policy: RestartAlways, MaxRestarts: 3})
+	_, ok := settled(s.Done())
+	assert.True(t, ok)
+	assert.True(t, time.Since(start) >= 3*minRestartDelay)
+
+	// The backoff applies to restarts after the task fulfilled.
+	start = time.Now()
+	s = Supervise(func() *Promise { return Resolved("ok") }, SupervisorOptions{
+		Policy: RestartAlways, MaxRestarts: 2, Backoff: ConstantBackoff{30 * time.Millisecond}})
+	settled(s.Done())
+	assert.True(t, time.Since(start) >= 60*time.Millisecond)
+}
+
+func TestSupervisorStopWhileStarting(t *testing.T) {
+	defer time.AfterFunc(time.Second, t.FailNow).Stop() // limit test to 1 second running time.
+
+	starting, proceed := make(chan bool), make(chan bool)
+	stopped := make(chan interface{}, 1)
+	runs := 0
+	s := Supervise(func() *Promise {
+		if runs++; runs == 1 {
+			return Resolved(nil)
+		}
+		starting <- true
+		<-proceed
+		return NewCancelable(func(r interface{}) { stopped <- r }).Promise
+	}, SupervisorOptions{Policy: RestartAlways})
+
+	<-starting
+	s.Stop()
+	close(proceed)
+	assert.Equal(t, ErrSupervisorStopped, <-stopped)
+	assert.Equal(t, SupervisorStopped, s.Status().State)
+}
+
+func TestSupervisorStop(t *testing.T) {
+	defer time.AfterFunc(time.Second, t.FailNow).Stop() // limit test to 1 second running time.
+
+	stopped := make(chan interface{}, 1)
+	s := Supervise(func() *Promise {
+		return NewCancelable(func(r interface{}) { stopped <- r }).Promise
+	}, SupervisorOptions{Policy: RestartAlways})
+	assert.Equal(t, SupervisorRunning, s.Status().State)
+
+	s.Stop()
+	assert.Equal(t, ErrSupervisorStopped, <-stopped)
+	val, ok := settled(s.Done())
+	assert.True(t, ok)
+	assert.Nil(t, val)
+	assert.Equal(t, SupervisorStopped, s.Status().State)
+	s.Stop() // no effect
+}
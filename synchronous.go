@@ -0,0 +1,41 @@
+package promise
+
+// SetSynchronous selects whether callbacks registered with Then after p has
+// settled run synchronously, within the call to Then, rather than on p's
+// scheduler, and returns p for chaining.  Promises returned by Then inherit the
+// setting.  See also Config.SynchronousThen and Options.Synchronous.
+//
+// This saves a scheduling delay on latency-critical paths (e.g. rendering from
+// a cache of settled promises), but gives up the guarantee that callbacks
+// never run before Then returns, so code relying on that ordering breaks:
+//
+//	p.SetSynchronous(true)
+//	p.Then(func(v interface{}) interface{} { use(x); return nil }, nil)
+//	x = ... // too late if p was already settled
+//
+// Callbacks registered while p is pending are unaffected.
+func (p *Promise) SetSynchronous(on bool) *Promise {
+	p.mu.Lock()
+	p.synchronous = on
+	p.mu.Unlock()
+	return p
+}
+
+// runNow runs task immediately, as a Scheduler.
+func runNow(task func()) { task() }
+
+// SetConcurrentHandlers selects how the callbacks registered with Then on p
+// are dispatched once it settles, and returns p for chaining.  By default they
+// run one after the other in a single scheduler task, in the order in which
+// they were registered, so a slow callback delays the rest.  With on, each
+// callback is dispatched as a separate task, so that (with a scheduler that
+// runs tasks in parallel, like the default one) they are isolated from each
+// other, at the cost of a task each and of any ordering between them.
+//
+// The setting isn't inherited by the promises returned by Then.
+func (p *Promise) SetConcurrentHandlers(on bool) *Promise {
+	p.mu.Lock()
+	p.concurrent = on
+	p.mu.Unlock()
+	return p
+}
@@ -0,0 +1,74 @@
+package promise
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetSynchronous(t *testing.T) {
+	defer time.AfterFunc(time.Second, t.FailNow).Stop() // limit test to 1 second running time.
+
+	p := Resolved(1).SetSynchronous(true)
+	var got interface{}
+	child := p.Then(func(v interface{}) interface{} { got = v; return 2 }, nil)
+	assert.Equal(t, 1, got) // before Then returned
+	assert.False(t, child.IsPending())
+
+	// Children inherit the setting.
+	var grandchild interface{}
+	child.Then(func(v interface{}) interface{} { grandchild = v; return nil }, nil)
+	assert.Equal(t, 2, grandchild)
+
+	// Pending promises still dispatch on the scheduler.
+	pending := newPromise().SetSynchronous(true)
+	done := make(chan interface{}, 1)
+	pending.Then(func(v interface{}) interface{} { done <- v; return nil }, nil)
+	pending.Resolve(3)
+	assert.Equal(t, 3, <-done)
+
+	// Other promises don't run callbacks synchronously.
+	async := Resolved(4)
+	async.scheduler = SchedulerFunc(func(func()) {}) // never runs anything
+	called := false
+	async.Then(func(interface{}) interface{} { called = true; return nil }, nil)
+	assert.False(t, called)
+}
+
+func TestSynchronousThenConfig(t *testing.T) {
+	defer Configure(Config{})
+	Configure(Config{SynchronousThen: true})
+	assert.True(t, newPromise().synchronous)
+	assert.False(t, newPromise().SetSynchronous(false).synchronous)
+	Configure(Config{})
+	assert.True(t, NewWith(Options{Synchronous: true}, func(resolve, reject func(interface{})) {}).synchronous)
+}
+
+func TestSetConcurrentHandlers(t *testing.T) {
+	defer time.AfterFunc(time.Second, t.FailNow).Stop() // limit test to 1 second running time.
+
+	var queue []func()
+	sched := SchedulerFunc(func(task func()) { queue = append(queue, task) })
+	for _, concurrent := range []bool{false, true} {
+		queue = nil
+		p := NewWith(Options{Scheduler: sched, ConcurrentHandlers: concurrent}, func(resolve, reject func(interface{})) {})
+		var ran []int
+		for i := 0; i < 3; i++ {
+			i := i
+			p.Then(func(interface{}) interface{} { ran = append(ran, i); return nil }, nil)
+		}
+		p.Resolve(nil)
+		if concurrent {
+			assert.Equal(t, 3, len(queue))
+		} else {
+			assert.Equal(t, 1, len(queue))
+		}
+		for len(queue) > 0 {
+			task := queue[len(queue)-1]
+			queue = queue[:len(queue)-1]
+			task()
+		}
+		assert.Equal(t, 3, len(ran))
+	}
+}
@@ -0,0 +1,68 @@
+package promise
+
+import (
+	"strings"
+
+	"github.com/gopherjs/gopherjs/js"
+)
+
+// IsPromise reports whether v is a promise of this package: a non-nil
+// *Promise or *CancelablePromise.  Use IsThenable for JS values.
+func IsPromise(v interface{}) bool {
+	switch p := v.(type) {
+	case *Promise:
+		return p != nil
+	case *CancelablePromise:
+		return p != nil && p.Promise != nil
+	}
+	return false
+}
+
+// IsThenable reports whether o is a thenable in the sense of the Promises/A+
+// resolution procedure: an object or function with a callable "then"
+// property.  This includes native JS promises and the wrappers returned by
+// Promise.Js.  A "then" getter that throws makes o not a thenable.
+func IsThenable(o *js.Object) bool {
+	then, err := thenOf(o)
+	return then != nil && err == nil
+}
+
+// thenOf returns the "then" method of o if o is a thenable, or nil if it
+// isn't.  The property is read exactly once, as the resolution procedure
+// requires of getters, and an exception thrown by a getter is returned as a
+// *js.Error.
+func thenOf(o *js.Object) (then *js.Object, err error) {
+	if o == nil || o == js.Undefined || !isObject(o) {
+		return nil, nil
+	}
+	defer func() {
+		if x := recover(); x != nil {
+			jsErr, ok := x.(*js.Error)
+			if !ok {
+				panic(x)
+			}
+			then, err = nil, jsErr
+		}
+	}()
+	then = o.Get("then")
+	if !isFunction(then) {
+		return nil, nil
+	}
+	return then, nil
+}
+
+// isObject reports whether o is a JS object or function, as opposed to a
+// primitive such as a string or null.
+func isObject(o *js.Object) bool {
+	return o != nil && o != js.Undefined && js.Global.Call("Object", o) == o
+}
+
+// isFunction reports whether o is a JS function, including async and
+// generator functions.
+func isFunction(o *js.Object) bool {
+	if !isObject(o) {
+		return false
+	}
+	toString := js.Global.Get("Object").Get("prototype").Get("toString")
+	return strings.HasSuffix(toString.Call("call", o).String(), "Function]")
+}
@@ -0,0 +1,19 @@
+package promise
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsPromise(t *testing.T) {
+	assert.True(t, IsPromise(newPromise()))
+	assert.True(t, IsPromise(NewCancelable(nil)))
+	assert.True(t, IsPromise(Never()))
+	assert.False(t, IsPromise((*Promise)(nil)))
+	assert.False(t, IsPromise((*CancelablePromise)(nil)))
+	assert.False(t, IsPromise(Promise{}))
+	assert.False(t, IsPromise(nil))
+	assert.False(t, IsPromise("then"))
+	assert.False(t, IsThenable(nil))
+}
@@ -0,0 +1,70 @@
+package promise
+
+import (
+	"strconv"
+	"sync/atomic"
+
+	"github.com/gopherjs/gopherjs/js"
+)
+
+// SetLabel names p in diagnostics, such as the performance entries emitted when
+// tracing is enabled, and returns p for chaining.  Promises returned by Then
+// inherit their parent's label, and promises returned by promisified
+// functions are labeled with the Go name of the function.
+func (p *Promise) SetLabel(label string) *Promise {
+	p.mu.Lock()
+	p.label = label
+	p.mu.Unlock()
+	return p
+}
+
+// Label returns the label of p, see SetLabel.
+func (p *Promise) Label() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.label
+}
+
+var (
+	tracing  int32 // set by EnablePerformanceTracing
+	traceSeq int64 // makes performance mark names unique
+)
+
+// EnablePerformanceTracing turns on emitting performance.mark and
+// performance.measure entries (the User Timing API) so that async work shows
+// up in the browser's performance profiler: one measure for each promisified
+// call, from the call until it settles, and one for each callback run by a
+// labeled promise, named by the label.  Tracing is off by default.
+func EnablePerformanceTracing(enabled bool) {
+	var v int32
+	if enabled {
+		v = 1
+	}
+	atomic.StoreInt32(&tracing, v)
+}
+
+// traceStart marks the start of a span named name if tracing is enabled, and
+// returns a function that ends it with a performance measure.
+func traceStart(name string) (end func()) {
+	if name == "" || atomic.LoadInt32(&tracing) == 0 {
+		return func() {}
+	}
+	perf := js.Global.Get("performance")
+	if perf == js.Undefined || perf.Get("mark") == js.Undefined {
+		return func() {}
+	}
+	start := name + "#" + strconv.FormatInt(atomic.AddInt64(&traceSeq, 1), 10)
+	perf.Call("mark", start)
+	return func() {
+		perf.Call("measure", name, start)
+		perf.Call("clearMarks", start)
+	}
+}
+
+// traced wraps cb to be measured as a segment of the chain labeled label.
+func traced(label string, cb Callback) Callback {
+	return func(val interface{}) interface{} {
+		defer traceStart(label + " (then)")()
+		return cb(val)
+	}
+}
@@ -0,0 +1,20 @@
+package promise
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLabel(t *testing.T) {
+	defer time.AfterFunc(time.Second, t.FailNow).Stop() // limit test to 1 second running time.
+
+	var p Promise
+	assert.Equal(t, "", p.Label())
+	child := p.SetLabel("load").Then(nil, nil)
+	assert.Equal(t, "load", child.Label())
+	p.Resolve(1)
+	val, _ := settled(child)
+	assert.Equal(t, 1, val)
+}
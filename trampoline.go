@@ -0,0 +1,63 @@
+package promise
+
+import "reflect"
+
+// trampoline runs the dispatch of a chain of promises iteratively.  When a
+// callback settles the promise returned by its Then, the callbacks of that
+// promise are queued on the trampoline of the dispatch in progress instead of
+// being scheduled on their own.  So a chain thousands of links long, e.g.
+// built by a loop, is dispatched by a single scheduler task (one goroutine
+// with the default scheduler) without growing the stack, and callbacks keep
+// running in the order in which their promises settled.
+//
+// Only the callbacks of promises with the scheduler and priority of the
+// promise that started the dispatch are queued; those of a link moved to
+// another scheduler, e.g. with SetScheduler(IdleScheduler), or to another
+// priority are scheduled as usual.
+type trampoline struct {
+	queue []func(t *trampoline)
+
+	scheduler Scheduler // of the promise that started the dispatch
+	priority  int
+}
+
+func newTrampoline(scheduler Scheduler, priority int) *trampoline {
+	return &trampoline{scheduler: scheduler, priority: priority}
+}
+
+// runs reports whether the callbacks of a promise with scheduler and priority
+// may be queued on t.
+func (t *trampoline) runs(scheduler Scheduler, priority int) bool {
+	return priority == t.priority && sameScheduler(scheduler, t.scheduler)
+}
+
+// sameScheduler reports whether a and b are the same scheduler.  Schedulers
+// of func types, such as SchedulerFunc, are compared by their code pointer,
+// and other uncomparable ones are never the same.
+func sameScheduler(a, b Scheduler) bool {
+	ta, tb := reflect.TypeOf(a), reflect.TypeOf(b)
+	switch {
+	case ta != tb:
+		return false
+	case ta == nil || ta.Comparable():
+		return a == b
+	case ta.Kind() == reflect.Func:
+		return reflect.ValueOf(a).Pointer() == reflect.ValueOf(b).Pointer()
+	}
+	return false
+}
+
+func (t *trampoline) push(task func(t *trampoline)) {
+	t.queue = append(t.queue, task)
+}
+
+// run runs the queued tasks, including those that they queue, until there are
+// none left.
+func (t *trampoline) run() {
+	for len(t.queue) > 0 {
+		task := t.queue[0]
+		t.queue[0] = nil
+		t.queue = t.queue[1:]
+		task(t)
+	}
+}
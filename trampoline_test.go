@@ -0,0 +1,91 @@
+package promise
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTrampolineDeepChain(t *testing.T) {
+	defer time.AfterFunc(time.Second, t.FailNow).Stop() // limit test to 1 second running time.
+
+	var tasks int64
+	sched := SchedulerFunc(func(task func()) {
+		atomic.AddInt64(&tasks, 1)
+		go task()
+	})
+
+	root := newPromise()
+	root.scheduler = sched
+	var order []int
+	p := root
+	for i := 0; i < 10000; i++ {
+		i := i
+		p = p.Then(func(v interface{}) interface{} {
+			order = append(order, i)
+			return v.(int) + 1
+		}, nil)
+	}
+	root.Resolve(0)
+	val, ok := settled(p)
+	assert.True(t, ok)
+	assert.Equal(t, 10000, val)
+	assert.Equal(t, 10000, len(order))
+	assert.Equal(t, 9999, order[9999])
+	// One task for the chain, plus the one used by settled.
+	assert.True(t, atomic.LoadInt64(&tasks) <= 2, "%d tasks", tasks)
+}
+
+func TestTrampolineOtherScheduler(t *testing.T) {
+	defer time.AfterFunc(time.Second, t.FailNow).Stop() // limit test to 1 second running time.
+
+	var tasks, idleTasks int64
+	sched := SchedulerFunc(func(task func()) {
+		atomic.AddInt64(&tasks, 1)
+		go task()
+	})
+	idle := SchedulerFunc(func(task func()) {
+		atomic.AddInt64(&idleTasks, 1)
+		go task()
+	})
+
+	root := newPromise().SetScheduler(sched)
+	moved := root.Then(func(v interface{}) interface{} { return v }, nil).SetScheduler(idle)
+	last := moved.Then(func(v interface{}) interface{} { return v }, nil).SetScheduler(sched)
+	background := last.Then(func(v interface{}) interface{} { return v }, nil).SetPriority(BackgroundPriority)
+	done := make(chan interface{}, 1)
+	background.Then(func(v interface{}) interface{} { done <- v; return nil }, nil)
+	root.Resolve(1)
+	assert.Equal(t, 1, <-done)
+	// The callbacks of moved run on its own scheduler, which starts a new
+	// trampoline, and so do those of last, back on sched, and of background,
+	// at another priority.
+	assert.Equal(t, int64(1), atomic.LoadInt64(&idleTasks))
+	assert.Equal(t, int64(3), atomic.LoadInt64(&tasks))
+
+	assert.True(t, sameScheduler(nil, nil))
+	assert.True(t, sameScheduler(idle, idle))
+	assert.False(t, sameScheduler(idle, nil))
+	assert.False(t, sameScheduler(GoroutineScheduler, idle))
+}
+
+func TestTrampolineOrder(t *testing.T) {
+	defer time.AfterFunc(time.Second, t.FailNow).Stop() // limit test to 1 second running time.
+
+	var order []string
+	log := func(s string) Callback {
+		return func(v interface{}) interface{} { order = append(order, s); return v }
+	}
+	root := newPromise()
+	a := root.Then(log("a"), nil)
+	a.Then(log("a1"), nil)
+	b := root.Then(log("b"), nil)
+	b.Then(log("b1"), nil)
+	last := a.Then(log("a2"), nil)
+	root.Resolve(nil)
+	settled(last)
+	time.Sleep(10 * time.Millisecond)
+	assert.Equal(t, []string{"a", "b", "a1", "a2", "b1"}, order)
+}
@@ -0,0 +1,45 @@
+package promise
+
+import "errors"
+
+// Try calls fn immediately and returns a promise for its result.  If fn
+// returns a non-nil error the promise is rejected with that error, and if fn
+// panics the promise is rejected with the panic value.  Otherwise the promise
+// is resolved with the returned value.
+//
+// Try is a convenient way to begin a chain from synchronous code without
+// having to worry about panics escaping to the caller:
+//
+//	promise.Try(loadConfig).Then(connect, nil)
+func Try(fn func() (interface{}, error)) (p *Promise) {
+	p = newPromise()
+	defer func() {
+		if x := recover(); x != nil {
+			p.Reject(x)
+		}
+	}()
+	if value, err := fn(); err != nil {
+		p.Reject(err)
+	} else {
+		p.Resolve(value)
+	}
+	return p
+}
+
+var errNilPromise = errors.New("promise: task returned a nil *Promise")
+
+// call runs task and returns its promise, converting a panic or a nil promise
+// into a rejected promise so that callers always have something to chain on.
+func call(task func() *Promise) (p *Promise) {
+	defer func() {
+		if x := recover(); x != nil {
+			p = newPromise()
+			p.Reject(x)
+		}
+	}()
+	if p = task(); p == nil {
+		p = newPromise()
+		p.Reject(errNilPromise)
+	}
+	return p
+}
@@ -0,0 +1,41 @@
+package promise
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// settled waits for p to settle and returns the fulfilled value or rejection
+// reason along with whether it was fulfilled.
+func settled(p *Promise) (interface{}, bool) {
+	type result struct {
+		val interface{}
+		ok  bool
+	}
+	ch := make(chan result, 1)
+	p.Then(
+		func(v interface{}) interface{} { ch <- result{v, true}; return v },
+		func(v interface{}) interface{} { ch <- result{v, false}; return v })
+	r := <-ch
+	return r.val, r.ok
+}
+
+func TestTry(t *testing.T) {
+	defer time.AfterFunc(time.Second, t.FailNow).Stop() // limit test to 1 second running time.
+
+	val, ok := settled(Try(func() (interface{}, error) { return 3, nil }))
+	assert.True(t, ok)
+	assert.Equal(t, 3, val)
+
+	failure := errors.New("failed")
+	val, ok = settled(Try(func() (interface{}, error) { return 3, failure }))
+	assert.False(t, ok)
+	assert.Equal(t, failure, val)
+
+	val, ok = settled(Try(func() (interface{}, error) { panic("oops") }))
+	assert.False(t, ok)
+	assert.Equal(t, "oops", val)
+}
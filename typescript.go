@@ -0,0 +1,228 @@
+package promise
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gopherjs/gopherjs/js"
+)
+
+// classes records the constructors exported with ExportStruct, by JS name.
+var classes = struct {
+	sync.Mutex
+	m map[string]reflect.Type
+}{m: map[string]reflect.Type{}}
+
+func registerClass(name string, ctor reflect.Type) {
+	classes.Lock()
+	defer classes.Unlock()
+	classes.m[name] = ctor
+}
+
+// TypeScript generates TypeScript declarations (the contents of a .d.ts file)
+// for the JS API exported by this package, so that TypeScript consumers get
+// real types instead of any.  The declarations include a class for every call
+// to ExportStruct made so far, plus a declaration for each of globals: a
+// function (as passed to Promisify) or a module (as passed to ExportAll),
+// keyed by the global name that it is installed under.
+//
+// Go types are mapped to the TS types they are converted to, and the results
+// of promisified functions are wrapped in Promise<...>.  Since Go doesn't
+// retain parameter names, parameters are named arg0, arg1, ...
+//
+// A typical use is a small program run by go:generate:
+//
+//	func main() {
+//		api.Register() // calls ExportStruct
+//		os.Stdout.WriteString(promise.TypeScript(map[string]interface{}{
+//			"api": api.Module,
+//		}))
+//	}
+func TypeScript(globals map[string]interface{}) string {
+	var buf bytes.Buffer
+	classes.Lock()
+	for _, name := range sortedKeys(classes.m) {
+		writeClass(&buf, name, classes.m[name])
+	}
+	classes.Unlock()
+
+	names := make([]string, 0, len(globals))
+	for name := range globals {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		switch val := globals[name].(type) {
+		case map[string]interface{}:
+			fmt.Fprintf(&buf, "declare const %s: %s;\n", name, tsModule(val, ""))
+		default:
+			fn, doc := undocument(val)
+			fmt.Fprintf(&buf, "%sdeclare function %s%s;\n", tsComment(doc, ""), name, tsSignature(reflect.TypeOf(fn), 0, doc))
+		}
+	}
+	return buf.String()
+}
+
+func writeClass(buf *bytes.Buffer, name string, ctor reflect.Type) {
+	fmt.Fprintf(buf, "declare class %s {\n", name)
+	fmt.Fprintf(buf, "  constructor(%s);\n", tsParams(ctor, 0, FuncDoc{}))
+	obj := ctor.Out(0)
+	for i := 0; i < obj.NumMethod(); i++ {
+		m := obj.Method(i)
+		fmt.Fprintf(buf, "  %s%s;\n", jsName(m.Name), tsSignature(m.Type, 1, FuncDoc{})) // skip the receiver
+	}
+	buf.WriteString("}\n")
+}
+
+func tsModule(module map[string]interface{}, indent string) string {
+	var buf bytes.Buffer
+	buf.WriteString("{\n")
+	keys := make([]string, 0, len(module))
+	for k := range module {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, name := range keys {
+		switch val := module[name].(type) {
+		case map[string]interface{}:
+			fmt.Fprintf(&buf, "%s  %s: %s;\n", indent, name, tsModule(val, indent+"  "))
+		default:
+			fn, doc := undocument(val)
+			fmt.Fprintf(&buf, "%s%s  %s%s;\n", tsComment(doc, indent+"  "), indent, name, tsSignature(reflect.TypeOf(fn), 0, doc))
+		}
+	}
+	buf.WriteString(indent + "}")
+	return buf.String()
+}
+
+// tsSignature returns the TS call signature of a promisified function of type
+// t, e.g. "(arg0: string): Promise<number>", ignoring the parameters before
+// from.  Parameters are named according to doc.
+func tsSignature(t reflect.Type, from int, doc FuncDoc) string {
+	return fmt.Sprintf("(%s): Promise<%s>", tsParams(t, from, doc), tsResult(t))
+}
+
+func tsParams(t reflect.Type, from int, doc FuncDoc) string {
+	var params []string
+	for i := from; i < t.NumIn(); i++ {
+		if injected(t.In(i)) {
+			continue
+		}
+		name := doc.paramName(i - from)
+		if t.IsVariadic() && i == t.NumIn()-1 {
+			params = append(params, fmt.Sprintf("...%s: %s", name, tsType(t.In(i), nil)))
+		} else {
+			params = append(params, fmt.Sprintf("%s: %s", name, tsType(t.In(i), nil)))
+		}
+	}
+	return strings.Join(params, ", ")
+}
+
+// tsComment returns a doc comment line for doc, if it has a summary.
+func tsComment(doc FuncDoc, indent string) string {
+	if doc.Summary == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s/** %s */\n", indent, strings.Replace(doc.Summary, "\n", " ", -1))
+}
+
+// tsResult returns the TS type that a promisified function of type t resolves
+// with, following the rules of Promisify.
+func tsResult(t reflect.Type) string {
+	n := t.NumOut()
+	if hasLastError(t) {
+		n--
+	}
+	switch n {
+	case 0:
+		return "null"
+	case 1:
+		return tsType(t.Out(0), nil)
+	}
+	types := make([]string, n)
+	for i := range types {
+		types[i] = tsType(t.Out(i), nil)
+	}
+	return "[" + strings.Join(types, ", ") + "]"
+}
+
+var (
+	timeType     = reflect.TypeOf(time.Time{})
+	jsObjectType = reflect.TypeOf((*js.Object)(nil))
+)
+
+// tsType maps a Go type to the TS type that it is converted to.  seen guards
+// against infinitely expanding recursive types.
+func tsType(t reflect.Type, seen map[reflect.Type]bool) string {
+	if e, ok := lookupEnum(t); ok {
+		return e.tsType()
+	}
+	switch t {
+	case timeType:
+		return "Date"
+	case bigIntType:
+		return "bigint"
+	case jsObjectType, cancelTokenType, contextType:
+		return "any"
+	case errorType:
+		return "string"
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.String:
+		return "string"
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return "Uint8Array"
+		}
+		elem := tsType(t.Elem(), seen)
+		if strings.Contains(elem, " | ") {
+			elem = "(" + elem + ")"
+		}
+		return elem + "[]"
+	case reflect.Map:
+		return fmt.Sprintf("{[key: string]: %s}", tsType(t.Elem(), seen))
+	case reflect.Ptr:
+		return tsType(t.Elem(), seen) + " | null"
+	case reflect.Func:
+		return fmt.Sprintf("(%s) => any", tsParams(t, 0, FuncDoc{}))
+	case reflect.Struct:
+		if seen[t] {
+			return "any"
+		}
+		if seen == nil {
+			seen = map[reflect.Type]bool{}
+		}
+		seen[t] = true
+		defer delete(seen, t)
+		var fields []string
+		c := converter{naming: fieldNaming()}
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if name, _ := c.fieldName(f); f.PkgPath == "" && name != "-" {
+				fields = append(fields, fmt.Sprintf("%s: %s", name, tsType(f.Type, seen)))
+			}
+		}
+		return "{" + strings.Join(fields, "; ") + "}"
+	}
+	return "any"
+}
+
+func sortedKeys(m map[string]reflect.Type) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,77 @@
+package promise
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type tsUser struct {
+	Name    string
+	Age     int
+	Tags    []string
+	Friends []*tsUser
+	Created time.Time
+	secret  string
+}
+
+func TestTsType(t *testing.T) {
+	for _, test := range []struct {
+		val      interface{}
+		expected string
+	}{
+		{true, "boolean"},
+		{3.5, "number"},
+		{uint8(1), "number"},
+		{"s", "string"},
+		{[3]int{}, "number[]"},
+		{time.Time{}, "Date"},
+		{new(int), "number | null"},
+		{(func(int, string) bool)(nil), "(arg0: number, arg1: string) => any"},
+	} {
+		assert.Equal(t, test.expected, tsType(reflect.TypeOf(test.val), nil), "%T", test.val)
+	}
+	assert.Equal(t, "Uint8Array", tsType(reflect.TypeOf([]byte{}), nil))
+	assert.Equal(t, "{[key: string]: boolean[]}", tsType(reflect.TypeOf(map[string][]bool{}), nil))
+	assert.Equal(t, "any", tsType(reflect.TypeOf((*interface{})(nil)).Elem(), nil))
+	assert.Equal(t,
+		"{name: string; age: number; tags: string[]; friends: (any | null)[]; created: Date}",
+		tsType(reflect.TypeOf(tsUser{}), nil))
+}
+
+func TestTypeScript(t *testing.T) {
+	registerClass("Counter", reflect.TypeOf(func(start int) (*counter, error) { return nil, nil }))
+	defer func() {
+		classes.Lock()
+		delete(classes.m, "Counter")
+		classes.Unlock()
+	}()
+
+	assert.Equal(t, `declare class Counter {
+  constructor(arg0: number);
+  add(arg0: number): Promise<number>;
+  id(): Promise<string>;
+}
+declare const api: {
+  users: {
+    /** Fetches a user. */
+    get(id: number): Promise<{name: string}>;
+    list(...arg0: string[]): Promise<[number, boolean]>;
+  };
+  version(): Promise<string>;
+};
+declare function whoami(): Promise<null>;
+`, TypeScript(map[string]interface{}{
+		"whoami": func() error { return nil },
+		"api": map[string]interface{}{
+			"version": func() string { return "" },
+			"users": map[string]interface{}{
+				"get": Document(func(id int) (struct{ Name string }, error) { return struct{ Name string }{}, nil },
+					FuncDoc{Summary: "Fetches a user.", Params: []string{"id"}}),
+				"list": func(filters ...string) (int, bool) { return 0, false },
+			},
+		},
+	}))
+}
@@ -0,0 +1,128 @@
+package promise
+
+import (
+	"sync"
+	"time"
+
+	"github.com/gopherjs/gopherjs/js"
+)
+
+// rejectionGrace is how long a rejected promise may go without handlers
+// before it is reported as unhandled.  Promises are often rejected before
+// their consumers get a chance to call Then, so reporting immediately would
+// be mostly noise.
+var rejectionGrace = 10 * time.Millisecond
+
+// tracker holds the hooks installed by OnUnhandledRejection.
+var tracker struct {
+	mu       sync.Mutex
+	report   func(p *Promise, reason interface{})
+	handled  func(p *Promise)
+	reported map[*Promise]bool
+}
+
+// OnUnhandledRejection installs hooks for rejected promises that nobody
+// handles: report is called with a promise that was rejected and still had no
+// handlers registered with Then shortly afterwards, and handled is called if
+// one is registered later after all.  Since a failure callback's result
+// rejects the promise returned by Then, such rejections count as handled.
+// Either hook may be nil; passing nil for both turns tracking off, which is
+// the default.
+//
+// The hooks replace any previously installed ones, such as those installed
+// by DispatchRejectionEvents.
+func OnUnhandledRejection(report func(p *Promise, reason interface{}), handled func(p *Promise)) {
+	tracker.mu.Lock()
+	defer tracker.mu.Unlock()
+	tracker.report, tracker.handled = report, handled
+	tracker.reported = map[*Promise]bool{}
+}
+
+// DispatchRejectionEvents reports unhandled rejections of Go promises to the
+// browser by dispatching the standard "unhandledrejection" event on window,
+// and "rejectionhandled" if they are handled later, so that existing error
+// monitoring picks them up.  Since the events require a native Promise, each
+// reported promise is backed by a native promise rejected with the same
+// reason (errors are converted to their messages).  The metadata of the
+// promise (see WithMeta) is attached to the events as their "meta" property.
+func DispatchRejectionEvents() {
+	var mu sync.Mutex
+	natives := map[*Promise]*js.Object{}
+	dispatch := func(kind string, p *Promise, native, reason interface{}) {
+		init := js.Global.Get("Object").New()
+		init.Set("promise", native)
+		init.Set("reason", reason)
+		init.Set("cancelable", true)
+		if ctor := js.Global.Get("PromiseRejectionEvent"); ctor != js.Undefined {
+			event := ctor.New(kind, init)
+			if meta := p.AllMeta(); meta != nil {
+				event.Set("meta", meta)
+			}
+			js.Global.Call("dispatchEvent", event)
+		}
+	}
+	OnUnhandledRejection(
+		func(p *Promise, reason interface{}) {
+			reason = jsReason(reason)
+			native := js.Global.Get("Promise").Call("reject", reason)
+			native.Call("catch", func() {}) // the event stands in for the native report
+			mu.Lock()
+			natives[p] = native
+			mu.Unlock()
+			dispatch("unhandledrejection", p, native, reason)
+		},
+		func(p *Promise) {
+			mu.Lock()
+			native := natives[p]
+			delete(natives, p)
+			mu.Unlock()
+			if native != nil {
+				_, reason := p.current()
+				dispatch("rejectionhandled", p, native, jsReason(reason))
+			}
+		})
+}
+
+// trackRejection schedules p, which was just rejected without any handlers,
+// to be reported if it still has none after rejectionGrace.
+func trackRejection(p *Promise) {
+	tracker.mu.Lock()
+	enabled := tracker.report != nil || tracker.handled != nil
+	tracker.mu.Unlock()
+	if !enabled {
+		return
+	}
+	time.AfterFunc(rejectionGrace, func() {
+		p.mu.Lock()
+		handled := p.children > 0
+		p.mu.Unlock()
+		tracker.mu.Lock()
+		if handled || tracker.reported == nil {
+			tracker.mu.Unlock()
+			return
+		}
+		tracker.reported[p] = true
+		report := tracker.report
+		tracker.mu.Unlock()
+		if report != nil {
+			_, reason := p.current()
+			report(p, reason)
+		}
+	})
+}
+
+// trackHandler notes that a handler was registered on p, reporting it as
+// handled if it was reported as unhandled before.
+func trackHandler(p *Promise) {
+	tracker.mu.Lock()
+	if !tracker.reported[p] {
+		tracker.mu.Unlock()
+		return
+	}
+	delete(tracker.reported, p)
+	handled := tracker.handled
+	tracker.mu.Unlock()
+	if handled != nil {
+		handled(p)
+	}
+}
@@ -0,0 +1,38 @@
+package promise
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnhandledRejection(t *testing.T) {
+	defer time.AfterFunc(time.Second, t.FailNow).Stop() // limit test to 1 second running time.
+
+	reported := make(chan interface{}, 10)
+	handled := make(chan *Promise, 10)
+	OnUnhandledRejection(
+		func(p *Promise, reason interface{}) { reported <- reason },
+		func(p *Promise) { handled <- p })
+	defer OnUnhandledRejection(nil, nil)
+
+	failure := errors.New("failed")
+	// Handled right away: never reported.
+	settled(Try(func() (interface{}, error) { return nil, errors.New("handled") }))
+
+	// Not handled: the leaf of the chain is reported.
+	p := Try(func() (interface{}, error) { return nil, failure })
+	assert.Equal(t, failure, <-reported)
+
+	// Handled late.
+	settled(p)
+	assert.Equal(t, p, <-handled)
+
+	// Resolved promises are never reported.
+	Try(func() (interface{}, error) { return 1, nil })
+	time.Sleep(2 * rejectionGrace)
+	assert.Equal(t, 0, len(reported))
+	assert.Equal(t, 0, len(handled))
+}
@@ -0,0 +1,49 @@
+package promise
+
+// Using runs body with a resource and guarantees that the resource is disposed
+// of once body is done with it.
+//
+// acquire returns a promise for the resource along with its disposer.  When
+// the resource promise is fulfilled, body is called with the resource and the
+// disposer is run after the promise returned by body settles, whether it was
+// fulfilled or rejected (which includes a panic in body).  The returned promise
+// then settles the same way as body's promise.  If the resource promise is
+// rejected, body and the disposer are never called and the returned promise is
+// rejected with the same reason.
+//
+// If the disposer panics after body fulfilled, the returned promise is rejected
+// with the panic value.
+func Using(acquire func() (*Promise, func()), body func(resource interface{}) *Promise) *Promise {
+	result := newPromise()
+	var resource *Promise
+	var dispose func()
+	acquired := call(func() *Promise {
+		resource, dispose = acquire()
+		return resource
+	})
+	acquired.Then(func(r interface{}) interface{} {
+		used := call(func() *Promise { return body(r) })
+		used.Then(
+			func(val interface{}) interface{} {
+				if x := disposeSafely(dispose); x != nil {
+					return result.Reject(x)
+				}
+				return result.Resolve(val)
+			},
+			func(err interface{}) interface{} {
+				disposeSafely(dispose)
+				return result.Reject(err)
+			})
+		return r
+	}, result.Reject)
+	return result
+}
+
+// disposeSafely runs dispose (if any), returning the panic value if it panics.
+func disposeSafely(dispose func()) (panicked interface{}) {
+	defer func() { panicked = recover() }()
+	if dispose != nil {
+		dispose()
+	}
+	return nil
+}
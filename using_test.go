@@ -0,0 +1,61 @@
+package promise
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUsing(t *testing.T) {
+	defer time.AfterFunc(time.Second, t.FailNow).Stop() // limit test to 1 second running time.
+
+	disposed := make(chan string, 1)
+	acquire := func() (*Promise, func()) {
+		var p Promise
+		p.Resolve("conn")
+		return &p, func() { disposed <- "conn" }
+	}
+
+	// Body fulfills.
+	val, ok := settled(Using(acquire, func(r interface{}) *Promise {
+		var p Promise
+		p.Resolve(r.(string) + " used")
+		return &p
+	}))
+	assert.True(t, ok)
+	assert.Equal(t, "conn used", val)
+	assert.Equal(t, "conn", <-disposed)
+
+	// Body rejects.
+	failure := errors.New("failed")
+	val, ok = settled(Using(acquire, func(r interface{}) *Promise {
+		var p Promise
+		p.Reject(failure)
+		return &p
+	}))
+	assert.False(t, ok)
+	assert.Equal(t, failure, val)
+	assert.Equal(t, "conn", <-disposed)
+
+	// Body panics.
+	val, ok = settled(Using(acquire, func(r interface{}) *Promise { panic("oops") }))
+	assert.False(t, ok)
+	assert.Equal(t, "oops", val)
+	assert.Equal(t, "conn", <-disposed)
+
+	// Acquisition fails: neither body nor the disposer run.
+	val, ok = settled(Using(func() (*Promise, func()) {
+		var p Promise
+		p.Reject(failure)
+		return &p, func() { disposed <- "unexpected" }
+	}, func(r interface{}) *Promise { panic("body shouldn't be called") }))
+	assert.False(t, ok)
+	assert.Equal(t, failure, val)
+	select {
+	case d := <-disposed:
+		t.Fatalf("Disposed unexpectedly: %v", d)
+	case <-time.After(10 * time.Millisecond):
+	}
+}
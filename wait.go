@@ -0,0 +1,80 @@
+package promise
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/gopherjs/gopherjs/js"
+)
+
+// defaultWaitInterval is used by WaitFor when no interval is specified.
+const defaultWaitInterval = 50 * time.Millisecond
+
+// WaitOptions configures WaitFor.
+type WaitOptions struct {
+	// Interval between checks of the predicate.  Defaults to 50ms.
+	Interval time.Duration
+	// AnimationFrame checks the predicate once per animation frame (using
+	// requestAnimationFrame) instead of every Interval.  This is only
+	// available in the browser.
+	AnimationFrame bool
+	// Timeout, if positive, is how long to wait before rejecting the promise
+	// with ErrTimeout.
+	Timeout time.Duration
+}
+
+// WaitFor returns a promise that is resolved (with nil) once predicate returns
+// true.  The predicate is checked right away and then repeatedly as specified
+// by opts.  If predicate panics, the promise is rejected with the panic value.
+// Canceling the promise stops the checks, see Cancel.
+//
+// This is mostly useful for UI tests and DOM-readiness checks, e.g.:
+//
+//	promise.WaitFor(func() bool {
+//		return doc.Call("getElementById", "app") != nil
+//	}, promise.WaitOptions{AnimationFrame: true, Timeout: 5 * time.Second})
+func WaitFor(predicate func() bool, opts WaitOptions) *Promise {
+	if opts.AnimationFrame {
+		return waitForAnimationFrame(predicate, opts.Timeout)
+	}
+	interval := opts.Interval
+	if interval <= 0 {
+		interval = defaultWaitInterval
+	}
+	return Poll(func() (bool, interface{}, error) {
+		return predicate(), nil, nil
+	}, interval, opts.Timeout)
+}
+
+func waitForAnimationFrame(predicate func() bool, timeout time.Duration) *Promise {
+	p := NewCancelable(nil)
+	var finished int32
+	finish := func(settle func()) {
+		if atomic.CompareAndSwapInt32(&finished, 0, 1) {
+			settle()
+		}
+	}
+	p.onStop(func(interface{}) { atomic.StoreInt32(&finished, 1) })
+	if timeout > 0 {
+		time.AfterFunc(timeout, func() { finish(func() { p.Reject(ErrTimeout) }) })
+	}
+
+	var check func()
+	check = func() {
+		defer func() {
+			if x := recover(); x != nil {
+				finish(func() { p.Reject(x) })
+			}
+		}()
+		if atomic.LoadInt32(&finished) != 0 {
+			return
+		}
+		if predicate() {
+			finish(func() { p.Resolve(nil) })
+			return
+		}
+		js.Global.Call("requestAnimationFrame", check)
+	}
+	check()
+	return p.Promise
+}
@@ -0,0 +1,32 @@
+package promise
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWaitFor(t *testing.T) {
+	defer time.AfterFunc(time.Second, t.FailNow).Stop() // limit test to 1 second running time.
+
+	ready := make(chan bool)
+	time.AfterFunc(10*time.Millisecond, func() { close(ready) })
+	isReady := func() bool {
+		select {
+		case <-ready:
+			return true
+		default:
+			return false
+		}
+	}
+	_, ok := settled(WaitFor(isReady, WaitOptions{Interval: time.Millisecond}))
+	assert.True(t, ok)
+
+	val, ok := settled(WaitFor(func() bool { return false }, WaitOptions{
+		Interval: time.Millisecond,
+		Timeout:  10 * time.Millisecond,
+	}))
+	assert.False(t, ok)
+	assert.Equal(t, ErrTimeout, val)
+}
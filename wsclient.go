@@ -0,0 +1,257 @@
+package promise
+
+import (
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/gopherjs/gopherjs/js"
+)
+
+var (
+	// ErrDisconnected is the rejection reason for WSClient requests that are
+	// in flight, or waiting for the socket to connect, when the connection is
+	// lost.
+	ErrDisconnected = errors.New("promise: websocket disconnected")
+	// ErrClientClosed is the rejection reason for WSClient requests after Close.
+	ErrClientClosed = errors.New("promise: websocket client closed")
+)
+
+// RemoteError is the rejection reason for a WSClient request that the server
+// answered with an error.
+type RemoteError struct {
+	Message string
+}
+
+func (e RemoteError) Error() string { return e.Message }
+
+// WSOptions configures DialWS.
+type WSOptions struct {
+	// Timeout rejects requests with ErrTimeout if no response arrives within
+	// the duration.  Zero means no timeout.
+	Timeout time.Duration
+	// Backoff determines how long to wait before reconnecting after the
+	// connection is lost.  If nil, a jittered exponential backoff from 1s to
+	// 30s is used.
+	Backoff Backoff
+	// MaxRetries is the number of consecutive reconnect attempts after which
+	// the client gives up and closes.  Zero means retry forever.
+	MaxRetries int
+}
+
+// wsRequest and wsResponse are the JSON frames exchanged with the server.
+type wsRequest struct {
+	ID     int64       `json:"id"`
+	Method string      `json:"method"`
+	Params interface{} `json:"params,omitempty"`
+}
+
+type wsResponse struct {
+	ID     int64           `json:"id"`
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  *string         `json:"error,omitempty"`
+}
+
+// WSClient correlates requests and responses over a WebSocket, see DialWS.
+type WSClient struct {
+	opts          WSOptions
+	dial          func(c *WSClient) (send func(frame string), close func())
+	notifications broadcast
+
+	mu        sync.Mutex
+	send      func(frame string)
+	close     func()
+	connected bool
+	closed    bool
+	nextID    int64
+	inflight  map[int64]*Promise
+	pending   []string // frames of the requests made before the socket connected
+	attempts  int
+	delay     time.Duration
+}
+
+// DialWS connects to the WebSocket at url and keeps the connection open until
+// Close is called, reconnecting with backoff whenever it is lost.
+//
+// Each request is sent as a JSON text frame {"id": n, "method": ..., "params":
+// ...} and is resolved by the frame {"id": n, "result": ...} or rejected with a
+// RemoteError by {"id": n, "error": "message"}.  Frames without a known id are
+// published by Notifications.
+func DialWS(url string, opts WSOptions) *WSClient {
+	return newWSClient(opts, func(c *WSClient) (func(string), func()) {
+		ws := js.Global.Get("WebSocket").New(url)
+		ws.Set("onopen", func() { c.opened() })
+		ws.Set("onclose", func() { c.lost() })
+		ws.Set("onmessage", func(event *js.Object) { c.received(event.Get("data").String()) })
+		return func(frame string) { ws.Call("send", frame) },
+			func() {
+				ws.Set("onclose", nil)
+				ws.Call("close")
+			}
+	})
+}
+
+func newWSClient(opts WSOptions, dial func(c *WSClient) (func(string), func())) *WSClient {
+	if opts.Backoff == nil {
+		opts.Backoff = ExponentialBackoff{Initial: time.Second, Max: 30 * time.Second, Jitter: true}
+	}
+	c := &WSClient{opts: opts, dial: dial, inflight: map[int64]*Promise{}}
+	c.connect()
+	return c
+}
+
+// Call sends a request for method with params, which must be encodable as
+// JSON, and returns a promise for the decoded result.  Requests made while
+// the socket is connecting, e.g. right after DialWS, are sent once it is
+// open.
+func (c *WSClient) Call(method string, params interface{}) *Promise {
+	p := newPromise()
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		p.Reject(ErrClientClosed)
+		return p
+	}
+	c.nextID++
+	id := c.nextID
+	frame, err := json.Marshal(wsRequest{id, method, params})
+	if err != nil {
+		c.mu.Unlock()
+		p.Reject(err)
+		return p
+	}
+	c.inflight[id] = p
+	send := c.send
+	if !c.connected {
+		c.pending = append(c.pending, string(frame))
+		send = nil
+	}
+	c.mu.Unlock()
+
+	if c.opts.Timeout > 0 {
+		time.AfterFunc(c.opts.Timeout, func() {
+			if p := c.take(id); p != nil {
+				p.Reject(ErrTimeout)
+			}
+		})
+	}
+	if send != nil {
+		send(string(frame))
+	}
+	return p
+}
+
+// Notifications returns an observable of the frames received from the server
+// that are not responses to a pending request.  It completes when the client
+// is closed.
+func (c *WSClient) Notifications() *Observable {
+	return NewObservable(func(o Observer) func() {
+		id := c.notifications.add(o)
+		return func() { c.notifications.remove(id) }
+	})
+}
+
+// Close closes the connection and rejects all in-flight requests with
+// ErrClientClosed.  Calling Close more than once has no effect.
+func (c *WSClient) Close() {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return
+	}
+	c.closed = true
+	stop := c.close
+	c.close, c.send, c.connected, c.pending = nil, nil, false, nil
+	c.mu.Unlock()
+	if stop != nil {
+		stop()
+	}
+	c.rejectAll(ErrClientClosed)
+	c.notifications.complete()
+}
+
+// take removes and returns the in-flight request with the given id, or nil.
+func (c *WSClient) take(id int64) *Promise {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	p := c.inflight[id]
+	delete(c.inflight, id)
+	return p
+}
+
+func (c *WSClient) rejectAll(reason error) {
+	c.mu.Lock()
+	inflight := c.inflight
+	c.inflight = map[int64]*Promise{}
+	c.mu.Unlock()
+	for _, p := range inflight {
+		p.Reject(reason)
+	}
+}
+
+func (c *WSClient) connect() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.closed {
+		c.send, c.close = c.dial(c)
+	}
+}
+
+// opened sends the requests that were waiting for the socket to connect.
+func (c *WSClient) opened() {
+	c.mu.Lock()
+	c.connected = true
+	c.attempts, c.delay = 0, 0
+	pending, send := c.pending, c.send
+	c.pending = nil
+	c.mu.Unlock()
+	for _, frame := range pending {
+		send(frame)
+	}
+}
+
+func (c *WSClient) received(frame string) {
+	var resp wsResponse
+	if json.Unmarshal([]byte(frame), &resp) == nil && resp.ID != 0 {
+		if p := c.take(resp.ID); p != nil {
+			if resp.Error != nil {
+				p.Reject(RemoteError{*resp.Error})
+				return
+			}
+			var result interface{}
+			if err := json.Unmarshal(resp.Result, &result); err != nil && len(resp.Result) > 0 {
+				p.Reject(err)
+				return
+			}
+			p.Resolve(result)
+			return
+		}
+	}
+	c.notifications.next(frame)
+}
+
+// lost rejects the in-flight requests after the connection was closed and
+// schedules a reconnect, or gives up if there were too many attempts.
+func (c *WSClient) lost() {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return
+	}
+	c.connected, c.send, c.close, c.pending = false, nil, nil, nil
+	c.attempts++
+	giveUp := c.opts.MaxRetries > 0 && c.attempts > c.opts.MaxRetries
+	if !giveUp {
+		c.delay = c.opts.Backoff.Delay(c.attempts, c.delay)
+	}
+	delay := c.delay
+	c.mu.Unlock()
+
+	c.rejectAll(ErrDisconnected)
+	if giveUp {
+		c.Close()
+	} else {
+		time.AfterFunc(delay, c.connect)
+	}
+}
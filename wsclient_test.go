@@ -0,0 +1,114 @@
+package promise
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeSocket records the frames sent by a WSClient.
+type fakeSocket struct {
+	dials  chan bool
+	frames chan wsRequest
+}
+
+func newFakeSocket() *fakeSocket {
+	return &fakeSocket{make(chan bool, 10), make(chan wsRequest, 10)}
+}
+
+func (f *fakeSocket) dial(c *WSClient) (func(string), func()) {
+	f.dials <- true
+	return func(frame string) {
+		var req wsRequest
+		json.Unmarshal([]byte(frame), &req)
+		f.frames <- req
+	}, func() {}
+}
+
+func TestWSClient(t *testing.T) {
+	defer time.AfterFunc(time.Second, t.FailNow).Stop() // limit test to 1 second running time.
+
+	f := newFakeSocket()
+	c := newWSClient(WSOptions{}, f.dial)
+	<-f.dials
+
+	// Requests made while connecting are sent once the socket is open.
+	early := c.Call("early", nil)
+	select {
+	case req := <-f.frames:
+		t.Fatalf("Sent %v before the socket was open", req)
+	case <-time.After(10 * time.Millisecond):
+	}
+	c.opened()
+	assert.Equal(t, "early", (<-f.frames).Method)
+	c.received(`{"id": 1, "result": "hi"}`)
+	val, _ := settled(early)
+	assert.Equal(t, "hi", val)
+
+	notes, _ := c.Notifications().ToChannel(10)
+	sum, fail := c.Call("add", []int{1, 2}), c.Call("fail", nil)
+	reqSum, reqFail := <-f.frames, <-f.frames
+	assert.Equal(t, "add", reqSum.Method)
+	assert.Equal(t, []interface{}{1.0, 2.0}, reqSum.Params)
+
+	// Responses may arrive in any order.
+	c.received(`{"id": 3, "error": "nope"}`)
+	c.received(`{"id": 2, "result": 3}`)
+	c.received(`{"event": "ping"}`)
+	c.received(`{"id": 2, "result": 4}`) // no longer in flight
+
+	val, ok := settled(sum)
+	assert.True(t, ok)
+	assert.Equal(t, 3.0, val)
+	val, ok = settled(fail)
+	assert.False(t, ok)
+	assert.Equal(t, RemoteError{"nope"}, val)
+	assert.Equal(t, reqFail.ID, int64(3))
+
+	c.Close()
+	assert.Equal(t, `{"event": "ping"}`, <-notes)
+	assert.Equal(t, `{"id": 2, "result": 4}`, <-notes)
+	_, open := <-notes
+	assert.False(t, open)
+	val, _ = settled(c.Call("late", nil))
+	assert.Equal(t, ErrClientClosed, val)
+}
+
+func TestWSClientReconnect(t *testing.T) {
+	defer time.AfterFunc(time.Second, t.FailNow).Stop() // limit test to 1 second running time.
+
+	f := newFakeSocket()
+	c := newWSClient(WSOptions{Backoff: ConstantBackoff{time.Millisecond}, MaxRetries: 1}, f.dial)
+	<-f.dials
+	c.opened()
+
+	inflight := c.Call("slow", nil)
+	<-f.frames
+	c.lost()
+	val, _ := settled(inflight)
+	assert.Equal(t, ErrDisconnected, val)
+	<-f.dials
+
+	// Requests waiting for the socket to reconnect fail with it as well.
+	waiting := c.Call("waiting", nil)
+	c.lost() // second consecutive failure exceeds MaxRetries
+	val, _ = settled(waiting)
+	assert.Equal(t, ErrDisconnected, val)
+	val, _ = settled(c.Call("any", nil))
+	assert.Equal(t, ErrClientClosed, val)
+}
+
+func TestWSClientTimeout(t *testing.T) {
+	defer time.AfterFunc(time.Second, t.FailNow).Stop() // limit test to 1 second running time.
+
+	f := newFakeSocket()
+	c := newWSClient(WSOptions{Timeout: time.Millisecond}, f.dial)
+	<-f.dials
+	c.opened()
+	val, ok := settled(c.Call("slow", nil))
+	assert.False(t, ok)
+	assert.Equal(t, ErrTimeout, val)
+	c.received(`{"id": 1, "result": 1}`) // too late, ignored
+}
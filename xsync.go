@@ -0,0 +1,218 @@
+package promise
+
+import (
+	"context"
+	"sync"
+)
+
+// ErrGroup is the method set of *errgroup.Group from golang.org/x/sync, so
+// that code shared between browser and server builds can work with either
+// kind of group without this package depending on x/sync.  *Group implements
+// it.
+type ErrGroup interface {
+	Go(f func() error)
+	Wait() error
+}
+
+// Weighted is the method set of *semaphore.Weighted from golang.org/x/sync,
+// see ErrGroup.  Semaphore.Weighted adapts a Semaphore to it.
+type Weighted interface {
+	Acquire(ctx context.Context, n int64) error
+	TryAcquire(n int64) bool
+	Release(n int64)
+}
+
+// FromErrGroup returns a promise that is resolved with nil once g.Wait returns
+// nil, or rejected with the error that it returns.  Wait is called on a new
+// goroutine.
+func FromErrGroup(g ErrGroup) *Promise {
+	return Method(g.Wait)()
+}
+
+// Group is an ErrGroup whose functions run as promises: Go runs each function
+// with the configured scheduler, and Promise returns a promise for the
+// outcome of the group.  The zero value is an empty group ready to use.
+//
+// As with errgroup, the group's error is the first non-nil error returned by
+// its functions (or the first rejection of its promises), and the group is
+// done once all of them have returned, whether or not one failed.
+type Group struct {
+	mu      sync.Mutex
+	active  int
+	failed  bool
+	reason  interface{}
+	waiters []*Promise
+}
+
+// Go calls f in a new task of the group.  A panic counts as an error with the
+// panic value.
+func (g *Group) Go(f func() error) {
+	run := Method(f)
+	g.GoPromise(func() *Promise { return run() })
+}
+
+// GoPromise adds the promise returned by task to the group.  A task that
+// panics is treated as if it returned a promise rejected with the panic value.
+func (g *Group) GoPromise(task func() *Promise) {
+	g.mu.Lock()
+	g.active++
+	g.mu.Unlock()
+	call(task).Then(func(val interface{}) interface{} {
+		g.done(false, nil)
+		return val
+	}, func(reason interface{}) interface{} {
+		g.done(true, reason)
+		return reason
+	})
+}
+
+// Promise returns a promise that is resolved with nil once every function or
+// promise added so far has finished, or rejected with the group's first
+// error.
+func (g *Group) Promise() *Promise {
+	p := newPromise()
+	g.mu.Lock()
+	if g.active > 0 {
+		g.waiters = append(g.waiters, p)
+		g.mu.Unlock()
+		return p
+	}
+	failed, reason := g.failed, g.reason
+	g.mu.Unlock()
+	if failed {
+		p.Reject(reason)
+	} else {
+		p.Resolve(nil)
+	}
+	return p
+}
+
+// Wait blocks until every function or promise added so far has finished, and
+// returns the group's first error, like errgroup.Group.Wait.  Rejection
+// reasons that aren't errors are wrapped in a RejectionError.
+func (g *Group) Wait() error {
+	_, err := await(g.Promise())
+	return err
+}
+
+func (g *Group) done(failed bool, reason interface{}) {
+	g.mu.Lock()
+	if failed && !g.failed {
+		g.failed, g.reason = true, reason
+	}
+	g.active--
+	var waiters []*Promise
+	if g.active == 0 {
+		waiters, g.waiters = g.waiters, nil
+	}
+	failed, reason = g.failed, g.reason
+	g.mu.Unlock()
+	for _, p := range waiters {
+		if failed {
+			p.Reject(reason)
+		} else {
+			p.Resolve(nil)
+		}
+	}
+}
+
+// AcquireWeighted returns a promise that is resolved with a release function
+// (of type func()) once w.Acquire(ctx, n) succeeds, or rejected with its
+// error.  The release function releases the n units; calling it more than
+// once has no effect.  Acquire is called on a new goroutine.
+func AcquireWeighted(ctx context.Context, w Weighted, n int64) *Promise {
+	return Method(func() (func(), error) {
+		if err := w.Acquire(ctx, n); err != nil {
+			return nil, err
+		}
+		var once sync.Once
+		return func() { once.Do(func() { w.Release(n) }) }, nil
+	})()
+}
+
+// Weighted adapts s for code written against semaphore.Weighted: each unit of
+// weight is one slot of s.  Acquire blocks, so it must not be called from the
+// JS event loop.
+func (s *Semaphore) Weighted() Weighted {
+	return &weightedSemaphore{s: s}
+}
+
+type weightedSemaphore struct {
+	s *Semaphore
+
+	mu       sync.Mutex
+	releases []func()
+}
+
+func (w *weightedSemaphore) Acquire(ctx context.Context, n int64) error {
+	var acquired []func()
+	for i := int64(0); i < n; i++ {
+		release, err := w.acquireOne(ctx)
+		if err != nil {
+			for _, r := range acquired {
+				r()
+			}
+			return err
+		}
+		acquired = append(acquired, release)
+	}
+	w.hold(acquired)
+	return nil
+}
+
+// acquireOne waits for a slot of w.s, or abandons the wait if ctx is done
+// first.
+func (w *weightedSemaphore) acquireOne(ctx context.Context) (func(), error) {
+	p := w.s.Acquire()
+	done := make(chan func(), 1)
+	p.Then(func(release interface{}) interface{} {
+		done <- release.(func())
+		return nil
+	}, func(reason interface{}) interface{} { return reason })
+	select {
+	case release := <-done:
+		return release, nil
+	case <-ctx.Done():
+		if !p.cancel(ctx.Err()) {
+			// The slot was granted in the meantime.
+			(<-done)()
+		}
+		return nil, ctx.Err()
+	}
+}
+
+func (w *weightedSemaphore) TryAcquire(n int64) bool {
+	var acquired []func()
+	for i := int64(0); i < n; i++ {
+		release, ok := w.s.TryAcquire()
+		if !ok {
+			for _, r := range acquired {
+				r()
+			}
+			return false
+		}
+		acquired = append(acquired, release)
+	}
+	w.hold(acquired)
+	return true
+}
+
+func (w *weightedSemaphore) Release(n int64) {
+	w.mu.Lock()
+	if int64(len(w.releases)) < n {
+		w.mu.Unlock()
+		panic("semaphore: released more than held")
+	}
+	released := w.releases[:n]
+	w.releases = append([]func(){}, w.releases[n:]...)
+	w.mu.Unlock()
+	for _, r := range released {
+		r()
+	}
+}
+
+func (w *weightedSemaphore) hold(releases []func()) {
+	w.mu.Lock()
+	w.releases = append(w.releases, releases...)
+	w.mu.Unlock()
+}
@@ -0,0 +1,65 @@
+package promise
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGroup(t *testing.T) {
+	defer time.AfterFunc(time.Second, t.FailNow).Stop() // limit test to 1 second running time.
+
+	var g Group
+	var _ ErrGroup = &g
+	assert.NoError(t, g.Wait())
+
+	release := make(chan struct{})
+	g.Go(func() error { <-release; return nil })
+	g.Go(func() error { return errors.New("first") })
+	g.Go(func() error { return nil })
+	p := g.Promise()
+	assert.True(t, p.IsPending())
+	close(release)
+	val, ok := settled(p)
+	assert.False(t, ok)
+	assert.EqualError(t, val.(error), "first")
+	assert.EqualError(t, g.Wait(), "first")
+
+	val, ok = settled(FromErrGroup(new(Group)))
+	assert.True(t, ok)
+	assert.Nil(t, val)
+	_, ok = settled(FromErrGroup(&g))
+	assert.False(t, ok)
+
+	var panicked Group
+	panicked.GoPromise(func() *Promise { panic("boom") })
+	assert.Equal(t, RejectionError{"boom"}, panicked.Wait())
+}
+
+func TestSemaphoreWeighted(t *testing.T) {
+	defer time.AfterFunc(time.Second, t.FailNow).Stop() // limit test to 1 second running time.
+
+	s := NewSemaphore(3)
+	w := s.Weighted()
+	assert.NoError(t, w.Acquire(context.Background(), 2))
+	assert.False(t, w.TryAcquire(2))
+	assert.True(t, w.TryAcquire(1))
+
+	// Waiting for more than is available gives up with the context.
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.Equal(t, context.DeadlineExceeded, w.Acquire(ctx, 1))
+
+	w.Release(3)
+	assert.Panics(t, func() { w.Release(1) })
+
+	val, ok := settled(AcquireWeighted(context.Background(), w, 3))
+	assert.True(t, ok)
+	assert.False(t, w.TryAcquire(1))
+	val.(func())()
+	val.(func())()
+	assert.True(t, w.TryAcquire(3))
+}